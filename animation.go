@@ -0,0 +1,73 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+)
+
+// animationFrameDelay is the delay between animation frames, in 100ths of
+// a second.
+const animationFrameDelay = 25
+
+// RenderAnimatedRegionMap renders a region map as an animated GIF, where the
+// alternating water rows cycle between their shades each frame, like the
+// water on the in-game Town Map. The water repeats every two frames, so
+// the number of frames is clamped to 2 or 4, rounding odd counts down, so
+// that the animation loops without a stutter.
+func RenderAnimatedRegionMap(regionMap RegionMap, opts RenderOptions, numFrames int) *gif.GIF {
+	if numFrames < 2 {
+		numFrames = 2
+	} else if numFrames > 4 {
+		numFrames = 4
+	}
+	numFrames -= numFrames % 2
+	frames := make([]image.Image, numFrames)
+	for i := range frames {
+		frameOpts := opts
+		frameOpts.waterPhase = i
+		frames[i] = renderRegionMapImage(regionMap, frameOpts)
+	}
+	return encodeAnimation(frames, animationFrameDelay)
+}
+
+// encodeAnimation converts the frames into a looping GIF animation. The
+// frames share a single color palette.
+func encodeAnimation(frames []image.Image, delay int) *gif.GIF {
+	colors := getAnimationPalette(frames)
+	anim := &gif.GIF{}
+	for _, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), colors)
+		draw.Draw(paletted, frame.Bounds(), frame, frame.Bounds().Min, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return anim
+}
+
+// getAnimationPalette returns the exact colors used in the frames when
+// there are few enough of them for a GIF. Otherwise, a general-purpose
+// palette is used.
+func getAnimationPalette(frames []image.Image) color.Palette {
+	seen := map[color.RGBA]bool{}
+	colors := color.Palette{}
+	for _, frame := range frames {
+		bounds := frame.Bounds()
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				c := color.RGBAModel.Convert(frame.At(x, y)).(color.RGBA)
+				if seen[c] {
+					continue
+				}
+				if len(colors) == 256 {
+					return palette.Plan9
+				}
+				seen[c] = true
+				colors = append(colors, c)
+			}
+		}
+	}
+	return colors
+}
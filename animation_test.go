@@ -0,0 +1,25 @@
+package porygion
+
+import "testing"
+
+func TestRenderAnimatedRegionMapFrameCount(t *testing.T) {
+	regionMap := testRegionMap(t)
+	tests := []struct {
+		numFrames, want int
+	}{
+		{-1, 2},
+		{0, 2},
+		{1, 2},
+		{2, 2},
+		{3, 2},
+		{4, 4},
+		{5, 4},
+		{100, 4},
+	}
+	for _, test := range tests {
+		anim := RenderAnimatedRegionMap(regionMap, RenderOptions{}, test.numFrames)
+		if len(anim.Image) != test.want || len(anim.Delay) != test.want {
+			t.Errorf("RenderAnimatedRegionMap(%d) has %d frames, want %d", test.numFrames, len(anim.Image), test.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// ansiReset resets the terminal's colors.
+const ansiReset = "\x1b[0m"
+
+// RenderANSI renders a full region map as text, using 24-bit ANSI colors.
+// Each tile is drawn as two colored spaces, so that tiles appear roughly
+// square in most terminals.
+func RenderANSI(regionMap RegionMap) string {
+	return RenderANSIWithOptions(regionMap, RenderOptions{})
+}
+
+// RenderANSIWithOptions renders a full region map as ANSI-colored text,
+// using the given render options. Each tile's color is sampled from the
+// center of the tile.
+func RenderANSIWithOptions(regionMap RegionMap, opts RenderOptions) string {
+	opts.Scale = 1
+	opts.Labels = nil
+	opts.Legend = false
+	img := renderRegionMapImage(regionMap, opts)
+	bounds := img.Bounds()
+	var sb strings.Builder
+	for y := bounds.Min.Y; y+8 <= bounds.Max.Y; y += 8 {
+		for x := bounds.Min.X; x+8 <= bounds.Max.X; x += 8 {
+			sb.WriteString(ansiBackground(img.At(x+4, y+4)))
+			sb.WriteString("  ")
+		}
+		sb.WriteString(ansiReset)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func ansiBackground(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", rgba.R, rgba.G, rgba.B)
+}
+
+// RenderHalfBlocks renders a full region map as compact text for
+// high-density terminal previews. Each character is an upper half block,
+// whose foreground and background colors show two vertically-stacked
+// squares of the map. The map is shrunk to fit within the given number of
+// columns, and each square shows the most common color beneath it.
+func RenderHalfBlocks(regionMap RegionMap, opts RenderOptions, columns int) string {
+	opts.Scale = 1
+	opts.Labels = nil
+	opts.Legend = false
+	img := renderRegionMapImage(regionMap, opts)
+	bounds := img.Bounds()
+	if columns < 1 {
+		columns = 1
+	}
+	size := (bounds.Dx() + columns - 1) / columns
+	if size < 1 {
+		size = 1
+	}
+	var sb strings.Builder
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += size * 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x += size {
+			top := getMostCommonColor(img, image.Rect(x, y, x+size, y+size))
+			bottom := getMostCommonColor(img, image.Rect(x, y+size, x+size, y+size*2))
+			sb.WriteString(ansiForeground(top))
+			sb.WriteString(ansiBackground(bottom))
+			sb.WriteString("▀")
+		}
+		sb.WriteString(ansiReset)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// getMostCommonColor returns the most common color within the rectangle.
+// Pixels outside of the image are ignored, and an empty rectangle is black.
+func getMostCommonColor(img image.Image, rect image.Rectangle) color.Color {
+	rect = rect.Intersect(img.Bounds())
+	counts := map[color.RGBA]int{}
+	best := color.RGBA{0, 0, 0, 255}
+	bestCount := 0
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			counts[c]++
+			if counts[c] > bestCount {
+				best = c
+				bestCount = counts[c]
+			}
+		}
+	}
+	return best
+}
+
+func ansiForeground(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", rgba.R, rgba.G, rgba.B)
+}
@@ -0,0 +1,67 @@
+package porygion
+
+// routeShape is an 8x8 bitmap of the pixels in a tile that a thin route
+// path covers.
+type routeShape [8][8]bool
+
+// routeShapes holds the route shape for every combination of neighboring
+// route tiles, indexed by getRouteNeighborMask. This covers dead-ends,
+// straight paths, corners, T-junctions, and crossroads.
+var routeShapes = buildRouteShapes()
+
+func buildRouteShapes() [16]routeShape {
+	var shapes [16]routeShape
+	for mask := range shapes {
+		connects := func(d Direction) bool {
+			return mask&(1<<uint(d)) != 0
+		}
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				inBandX := i >= 2 && i < 6
+				inBandY := j >= 2 && j < 6
+				switch {
+				case inBandX && inBandY:
+					shapes[mask][i][j] = true
+				case inBandY && i < 2:
+					shapes[mask][i][j] = connects(West)
+				case inBandY && i >= 6:
+					shapes[mask][i][j] = connects(East)
+				case inBandX && j < 2:
+					shapes[mask][i][j] = connects(North)
+				case inBandX && j >= 6:
+					shapes[mask][i][j] = connects(South)
+				}
+			}
+		}
+		// Round off the outside of corners.
+		switch mask {
+		case 1<<North | 1<<East:
+			shapes[mask][2][5] = false
+		case 1<<East | 1<<South:
+			shapes[mask][2][2] = false
+		case 1<<South | 1<<West:
+			shapes[mask][5][2] = false
+		case 1<<West | 1<<North:
+			shapes[mask][5][5] = false
+		}
+	}
+	return shapes
+}
+
+// getRouteNeighborMask returns a bitmask of the directions in which the tile
+// has an adjacent route or city tile.
+func getRouteNeighborMask(t Tile, network map[Tile]bool) int {
+	mask := 0
+	for _, n := range t.neighbors() {
+		if network[n] {
+			mask |= 1 << uint(t.directionTo(n))
+		}
+	}
+	return mask
+}
+
+// isAutotileRoutePixel reports whether the pixel at (i, j) within a route
+// tile is covered by the tile's route shape.
+func isAutotileRoutePixel(t Tile, i, j int, network map[Tile]bool) bool {
+	return routeShapes[getRouteNeighborMask(t, network)][i][j]
+}
@@ -0,0 +1,397 @@
+package porygion
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// regionMapMagic identifies the binary region map format.
+const regionMapMagic = "PRGN"
+
+// regionMapBinaryVersion is the version of the binary format written by
+// WriteTo.
+const regionMapBinaryVersion = 1
+
+// maxBinaryDimension is the largest width or height, in pixels, and the
+// largest tile coordinate, that the binary format stores.
+const maxBinaryDimension = 1 << 15
+
+// maxBinaryPrealloc is the most values that are allocated ahead of reading
+// them, so that a corrupt count in a short input can't allocate much more
+// memory than the input backs.
+const maxBinaryPrealloc = 1024
+
+// WriteTo writes the region map in a compact binary format. Elevations are
+// quantized to 16 bits across the map's elevation range, and tiles are
+// stored as varints. The format starts with a magic string and version, so
+// that it can be read back with ReadFrom.
+func (r RegionMap) WriteTo(w io.Writer) (int64, error) {
+	if r.PixelWidth > maxBinaryDimension || r.PixelHeight > maxBinaryDimension {
+		return 0, fmt.Errorf("Failed to write region map: Region maps larger than %dx%d can't be written", maxBinaryDimension, maxBinaryDimension)
+	}
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	e := binaryEncoder{w: bw}
+	e.bytes([]byte(regionMapMagic))
+	e.uvarint(regionMapBinaryVersion)
+	e.varint(r.Seed)
+	e.uvarint(uint64(r.PixelWidth))
+	e.uvarint(uint64(r.PixelHeight))
+
+	min, max := getElevationRange(r.Elevations)
+	e.float64(min)
+	e.float64(max)
+	for _, column := range r.Elevations {
+		for _, elevation := range column {
+			e.uint16(quantizeElevation(elevation, min, max))
+		}
+	}
+
+	e.uvarint(uint64(len(r.Cities)))
+	for _, city := range r.Cities {
+		e.tile(city)
+		e.uvarint(uint64(r.CityKinds[city]))
+		e.string(r.CityNames[city])
+	}
+	e.uvarint(uint64(len(r.Routes)))
+	for _, route := range r.Routes {
+		e.tile(route)
+	}
+	e.uvarint(uint64(len(r.Connections)))
+	for _, c := range r.Connections {
+		e.tile(c.CityA)
+		e.tile(c.CityB)
+		e.uvarint(uint64(c.Class))
+		victoryRoad := uint64(0)
+		if c.VictoryRoad {
+			victoryRoad = 1
+		}
+		e.uvarint(victoryRoad)
+		// Route tiles are stored as offsets from the previous tile, which
+		// are almost always a single step.
+		e.uvarint(uint64(len(c.Tiles)))
+		prev := c.CityA
+		for _, t := range c.Tiles {
+			e.varint(int64(t.X - prev.X))
+			e.varint(int64(t.Y - prev.Y))
+			prev = t
+		}
+	}
+	if e.err == nil {
+		e.err = bw.Flush()
+	}
+	if e.err != nil {
+		return cw.n, fmt.Errorf("Failed to write region map: %s", e.err)
+	}
+	return cw.n, nil
+}
+
+// ReadFrom reads a region map in the binary format written by WriteTo,
+// replacing the contents of the region map. It reads until EOF, and fails
+// if there is any data after the region map.
+func (r *RegionMap) ReadFrom(reader io.Reader) (int64, error) {
+	cr := &countingReader{r: reader}
+	d := binaryDecoder{r: bufio.NewReader(cr)}
+	regionMap, err := d.regionMap()
+	if err == nil {
+		if _, err = d.r.ReadByte(); err == nil {
+			err = fmt.Errorf("Unexpected data after the region map")
+		} else if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return cr.n, fmt.Errorf("Failed to read region map: %s", err)
+	}
+	*r = regionMap
+	return cr.n, nil
+}
+
+func quantizeElevation(elevation, min, max float64) uint16 {
+	if max <= min {
+		return 0
+	}
+	return uint16(math.Round((elevation - min) / (max - min) * math.MaxUint16))
+}
+
+func dequantizeElevation(v uint16, min, max float64) float64 {
+	return min + float64(v)/math.MaxUint16*(max-min)
+}
+
+// binaryEncoder writes the values of the binary format, and remembers the
+// first error, so that it can be checked once at the end.
+type binaryEncoder struct {
+	w   io.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (e *binaryEncoder) bytes(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *binaryEncoder) uvarint(v uint64) {
+	e.bytes(e.buf[:binary.PutUvarint(e.buf[:], v)])
+}
+
+func (e *binaryEncoder) varint(v int64) {
+	e.bytes(e.buf[:binary.PutVarint(e.buf[:], v)])
+}
+
+func (e *binaryEncoder) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(e.buf[:], v)
+	e.bytes(e.buf[:2])
+}
+
+func (e *binaryEncoder) float64(v float64) {
+	binary.LittleEndian.PutUint64(e.buf[:], math.Float64bits(v))
+	e.bytes(e.buf[:8])
+}
+
+// tile writes a tile, which must have coordinates that ReadFrom accepts.
+func (e *binaryEncoder) tile(t Tile) {
+	if e.err == nil && (t.X < 0 || t.Y < 0 || t.X > maxBinaryDimension || t.Y > maxBinaryDimension) {
+		e.err = fmt.Errorf("Tile %v is out of range", t)
+	}
+	e.uvarint(uint64(t.X))
+	e.uvarint(uint64(t.Y))
+}
+
+func (e *binaryEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.bytes([]byte(s))
+}
+
+// binaryDecoder reads the values of the binary format.
+type binaryDecoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func (d *binaryDecoder) regionMap() (RegionMap, error) {
+	var regionMap RegionMap
+	if _, err := io.ReadFull(d.r, d.buf[:len(regionMapMagic)]); err != nil {
+		return regionMap, err
+	}
+	if string(d.buf[:len(regionMapMagic)]) != regionMapMagic {
+		return regionMap, fmt.Errorf("Not a region map file")
+	}
+	version, err := d.uvarint(math.MaxUint32)
+	if err != nil {
+		return regionMap, err
+	}
+	if version < 1 || version > regionMapBinaryVersion {
+		return regionMap, fmt.Errorf("Unsupported region map version %d", version)
+	}
+	if regionMap.Seed, err = binary.ReadVarint(d.r); err != nil {
+		return regionMap, err
+	}
+	width, err := d.uvarint(maxBinaryDimension)
+	if err != nil {
+		return regionMap, err
+	}
+	height, err := d.uvarint(maxBinaryDimension)
+	if err != nil {
+		return regionMap, err
+	}
+	if width < 8 || height < 8 {
+		return regionMap, fmt.Errorf("Invalid region map size %dx%d", width, height)
+	}
+	regionMap.PixelWidth = int(width)
+	regionMap.PixelHeight = int(height)
+
+	min, err := d.float64()
+	if err != nil {
+		return regionMap, err
+	}
+	max, err := d.float64()
+	if err != nil {
+		return regionMap, err
+	}
+	// Columns are allocated as they are read, so that a short input fails
+	// before the whole elevation map is allocated.
+	regionMap.Elevations = make([][]float64, 0, preallocSize(width))
+	for i := uint64(0); i < width; i++ {
+		column := make([]float64, height)
+		for j := range column {
+			if _, err := io.ReadFull(d.r, d.buf[:2]); err != nil {
+				return regionMap, err
+			}
+			column[j] = dequantizeElevation(binary.LittleEndian.Uint16(d.buf[:2]), min, max)
+		}
+		regionMap.Elevations = append(regionMap.Elevations, column)
+	}
+
+	maxTiles := uint64(width/8) * uint64(height/8)
+	numCities, err := d.uvarint(maxTiles)
+	if err != nil {
+		return regionMap, err
+	}
+	regionMap.Cities = make([]Tile, 0, preallocSize(numCities))
+	for i := uint64(0); i < numCities; i++ {
+		city, err := d.tile()
+		if err != nil {
+			return regionMap, err
+		}
+		regionMap.Cities = append(regionMap.Cities, city)
+		kind, err := d.uvarint(uint64(CityLeague))
+		if err != nil {
+			return regionMap, err
+		}
+		if kind != uint64(CityTown) {
+			if regionMap.CityKinds == nil {
+				regionMap.CityKinds = map[Tile]CityKind{}
+			}
+			regionMap.CityKinds[city] = CityKind(kind)
+		}
+		name, err := d.string()
+		if err != nil {
+			return regionMap, err
+		}
+		if name != "" {
+			if regionMap.CityNames == nil {
+				regionMap.CityNames = map[Tile]string{}
+			}
+			regionMap.CityNames[city] = name
+		}
+	}
+
+	numRoutes, err := d.uvarint(maxTiles)
+	if err != nil {
+		return regionMap, err
+	}
+	regionMap.Routes = make([]Tile, 0, preallocSize(numRoutes))
+	for i := uint64(0); i < numRoutes; i++ {
+		route, err := d.tile()
+		if err != nil {
+			return regionMap, err
+		}
+		regionMap.Routes = append(regionMap.Routes, route)
+	}
+
+	numConnections, err := d.uvarint(numCities * numCities)
+	if err != nil {
+		return regionMap, err
+	}
+	regionMap.Connections = make([]RouteConnection, 0, preallocSize(numConnections))
+	for i := uint64(0); i < numConnections; i++ {
+		var c RouteConnection
+		if c.CityA, err = d.tile(); err != nil {
+			return regionMap, err
+		}
+		if c.CityB, err = d.tile(); err != nil {
+			return regionMap, err
+		}
+		class, err := d.uvarint(uint64(RouteSpur))
+		if err != nil {
+			return regionMap, err
+		}
+		c.Class = RouteClass(class)
+		victoryRoad, err := d.uvarint(1)
+		if err != nil {
+			return regionMap, err
+		}
+		c.VictoryRoad = victoryRoad == 1
+		numTiles, err := d.uvarint(maxTiles)
+		if err != nil {
+			return regionMap, err
+		}
+		c.Tiles = make([]Tile, 0, preallocSize(numTiles))
+		prev := c.CityA
+		for j := uint64(0); j < numTiles; j++ {
+			dx, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return regionMap, err
+			}
+			dy, err := binary.ReadVarint(d.r)
+			if err != nil {
+				return regionMap, err
+			}
+			prev = Tile{prev.X + int(dx), prev.Y + int(dy)}
+			c.Tiles = append(c.Tiles, prev)
+		}
+		regionMap.Connections = append(regionMap.Connections, c)
+	}
+	return regionMap, nil
+}
+
+// preallocSize returns the capacity to allocate for n values that are yet
+// to be read, which is capped at maxBinaryPrealloc.
+func preallocSize(n uint64) int {
+	if n > maxBinaryPrealloc {
+		return maxBinaryPrealloc
+	}
+	return int(n)
+}
+
+// uvarint reads a varint, and checks that it is no larger than max.
+func (d *binaryDecoder) uvarint(max uint64) (uint64, error) {
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if v > max {
+		return 0, fmt.Errorf("Value %d is out of range", v)
+	}
+	return v, nil
+}
+
+func (d *binaryDecoder) float64() (float64, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:8]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(d.buf[:8])), nil
+}
+
+func (d *binaryDecoder) tile() (Tile, error) {
+	x, err := d.uvarint(maxBinaryDimension)
+	if err != nil {
+		return Tile{}, err
+	}
+	y, err := d.uvarint(maxBinaryDimension)
+	if err != nil {
+		return Tile{}, err
+	}
+	return Tile{int(x), int(y)}, nil
+}
+
+func (d *binaryDecoder) string() (string, error) {
+	n, err := d.uvarint(math.MaxUint16)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,136 @@
+package porygion
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegionMapBinaryRoundTrip(t *testing.T) {
+	regionMap := testRegionMap(t)
+	var buf bytes.Buffer
+	n, err := regionMap.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d, but wrote %d bytes", n, buf.Len())
+	}
+	data := buf.Bytes()
+
+	var decoded RegionMap
+	n, err = decoded.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFrom: %s", err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("ReadFrom returned %d, want %d", n, len(data))
+	}
+
+	// The elevations are quantized, so everything else must match exactly,
+	// and the elevations must be within one quantization step.
+	min, max := getElevationRange(regionMap.Elevations)
+	step := (max - min) / math.MaxUint16
+	for i, column := range regionMap.Elevations {
+		for j, elevation := range column {
+			if d := math.Abs(decoded.Elevations[i][j] - elevation); d > step {
+				t.Fatalf("Elevation (%d, %d) is %g, want %g", i, j, decoded.Elevations[i][j], elevation)
+			}
+		}
+	}
+	decoded.Elevations = regionMap.Elevations
+	if !reflect.DeepEqual(decoded, regionMap) {
+		t.Errorf("decoded region map differs from the original")
+	}
+}
+
+func TestRegionMapBinaryRoundTripIsStable(t *testing.T) {
+	// The first round trip quantizes the elevations, but after that, round
+	// trips must not change the map at all.
+	var once, twice RegionMap
+	var buf bytes.Buffer
+	if _, err := testRegionMap(t).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	if _, err := once.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom: %s", err)
+	}
+	if _, err := once.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	if _, err := twice.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom: %s", err)
+	}
+	if twice.Fingerprint() != once.Fingerprint() {
+		t.Errorf("Fingerprint() changed from %s to %s", once.Fingerprint(), twice.Fingerprint())
+	}
+}
+
+func TestRegionMapReadFromErrors(t *testing.T) {
+	var valid bytes.Buffer
+	if _, err := testRegionMap(t).WriteTo(&valid); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	header := func(width, height uint64) []byte {
+		var buf bytes.Buffer
+		e := binaryEncoder{w: &buf}
+		e.bytes([]byte(regionMapMagic))
+		e.uvarint(regionMapBinaryVersion)
+		e.varint(1)
+		e.uvarint(width)
+		e.uvarint(height)
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "EOF"},
+		{"wrong magic", []byte("not a region map"), "Not a region map file"},
+		{"truncated", valid.Bytes()[:valid.Len()/2], "EOF"},
+		{"trailing data", append(append([]byte{}, valid.Bytes()...), 0), "Unexpected data"},
+		{"too narrow", header(7, 8), "Invalid region map size 7x8"},
+		{"too short", header(8, 0), "Invalid region map size 8x0"},
+		{"too large", header(maxBinaryDimension+1, 8), "out of range"},
+		{"largest size without elevations", header(maxBinaryDimension, maxBinaryDimension), "EOF"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var regionMap RegionMap
+			_, err := regionMap.ReadFrom(bytes.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ReadFrom error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRegionMapWriteToErrors(t *testing.T) {
+	negativeCity := testRegionMap(t)
+	negativeCity.Cities = append(negativeCity.Cities, Tile{-1, 3})
+	negativeRoute := testRegionMap(t)
+	negativeRoute.Routes = append(negativeRoute.Routes, Tile{4, -2})
+	tooLarge := testRegionMap(t)
+	tooLarge.PixelWidth = maxBinaryDimension + 8
+	tests := []struct {
+		name      string
+		regionMap RegionMap
+		want      string
+	}{
+		{"negative city", negativeCity, "Tile {-1 3} is out of range"},
+		{"negative route", negativeRoute, "Tile {4 -2} is out of range"},
+		{"too large", tooLarge, "can't be written"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := test.regionMap.WriteTo(&buf)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("WriteTo error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// Biome is the kind of environment found at a point on the region map.
+type Biome int
+
+// Biomes.
+const (
+	BiomeOcean Biome = iota
+	BiomeBeach
+	BiomeDesert
+	BiomeGrassland
+	BiomeForest
+	BiomeJungle
+	BiomeTundra
+	BiomeMountain
+	BiomeSnow
+)
+
+// Biomes lists every biome, in order.
+var Biomes = []Biome{
+	BiomeOcean,
+	BiomeBeach,
+	BiomeDesert,
+	BiomeGrassland,
+	BiomeForest,
+	BiomeJungle,
+	BiomeTundra,
+	BiomeMountain,
+	BiomeSnow,
+}
+
+func (b Biome) String() string {
+	switch b {
+	case BiomeOcean:
+		return "ocean"
+	case BiomeBeach:
+		return "beach"
+	case BiomeDesert:
+		return "desert"
+	case BiomeGrassland:
+		return "grassland"
+	case BiomeForest:
+		return "forest"
+	case BiomeJungle:
+		return "jungle"
+	case BiomeTundra:
+		return "tundra"
+	case BiomeMountain:
+		return "mountain"
+	case BiomeSnow:
+		return "snow"
+	}
+	return "unknown"
+}
+
+// Biome classification thresholds.
+const (
+	beachElevation    = 0.08
+	mountainElevation = 0.85
+	snowElevation     = 1.10
+	coldTemperature   = 0.15
+	hotTemperature    = 0.7
+	dryMoisture       = 0.15
+	wetMoisture       = 0.45
+)
+
+// ClassifyBiomes returns the biome of every pixel in a region map, based on
+// its elevation and climate.
+func ClassifyBiomes(regionMap RegionMap) [][]Biome {
+	elevations := regionMap.Elevations
+	climate := ComputeClimate(regionMap)
+	biomes := make([][]Biome, len(elevations))
+	for i := range elevations {
+		biomes[i] = make([]Biome, len(elevations[i]))
+		for j, elevation := range elevations[i] {
+			biomes[i][j] = getBiome(elevation, climate.Temperature[i][j], climate.Moisture[i][j])
+		}
+	}
+	return biomes
+}
+
+func getBiome(elevation, temperature, moisture float64) Biome {
+	switch {
+	case elevation <= 0:
+		return BiomeOcean
+	case elevation > snowElevation:
+		return BiomeSnow
+	case elevation > mountainElevation:
+		return BiomeMountain
+	case temperature < coldTemperature:
+		return BiomeTundra
+	case elevation < beachElevation:
+		return BiomeBeach
+	case temperature > hotTemperature && moisture < dryMoisture:
+		return BiomeDesert
+	case temperature > hotTemperature && moisture > wetMoisture:
+		return BiomeJungle
+	case moisture > wetMoisture:
+		return BiomeForest
+	default:
+		return BiomeGrassland
+	}
+}
+
+// DefaultBiomePalette returns the standard colors used to render biomes.
+func DefaultBiomePalette() map[Biome]color.RGBA {
+	return map[Biome]color.RGBA{
+		BiomeOcean:     {64, 112, 200, 255},
+		BiomeBeach:     {240, 224, 160, 255},
+		BiomeDesert:    {224, 192, 112, 255},
+		BiomeGrassland: {136, 200, 80, 255},
+		BiomeForest:    {40, 128, 48, 255},
+		BiomeJungle:    {16, 96, 48, 255},
+		BiomeTundra:    {176, 192, 176, 255},
+		BiomeMountain:  {136, 120, 104, 255},
+		BiomeSnow:      {248, 248, 248, 255},
+	}
+}
+
+// RenderBiomeMap renders the biomes of a region map with the default biome
+// palette.
+func RenderBiomeMap(regionMap RegionMap) image.Image {
+	return RenderBiomeMapWithPalette(regionMap, DefaultBiomePalette())
+}
+
+// RenderBiomeMapWithPalette renders the biomes of a region map with the
+// given biome colors.
+func RenderBiomeMapWithPalette(regionMap RegionMap, palette map[Biome]color.RGBA) image.Image {
+	biomes := ClassifyBiomes(regionMap)
+	img := newRegionMapImage(regionMap)
+	for i := range biomes {
+		for j, biome := range biomes[i] {
+			img.SetRGBA(i, j, palette[biome])
+		}
+	}
+	return img
+}
@@ -0,0 +1,68 @@
+package porygion
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+)
+
+// Names of the files in a bundle.
+const (
+	bundleImageName  = "region.png"
+	bundleMapName    = "region.map"
+	bundleStatsName  = "stats.json"
+	bundleConfigName = "config.json"
+)
+
+// ExportBundle writes a zip file containing everything needed to share a
+// generated region map: the map rendered with the config's render options,
+// the region map in the file format written by WriteMapFile, its
+// statistics report, and the config itself.
+func ExportBundle(w io.Writer, regionMap RegionMap, config Config) error {
+	opts, err := config.RenderOptions()
+	if err != nil {
+		return err
+	}
+	var imageFile bytes.Buffer
+	if err := png.Encode(&imageFile, RenderRegionMap(regionMap, opts)); err != nil {
+		return fmt.Errorf("Failed to encode bundle image: %s", err)
+	}
+	var mapFile bytes.Buffer
+	if err := WriteMapFile(&mapFile, regionMap); err != nil {
+		return err
+	}
+	stats, err := ExportStats(regionMap)
+	if err != nil {
+		return err
+	}
+	var configFile bytes.Buffer
+	if err := SaveConfig(&configFile, config); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{bundleImageName, imageFile.Bytes()},
+		{bundleMapName, mapFile.Bytes()},
+		{bundleStatsName, stats},
+		{bundleConfigName, configFile.Bytes()},
+	}
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("Failed to write bundle: %s", err)
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return fmt.Errorf("Failed to write bundle: %s", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("Failed to write bundle: %s", err)
+	}
+	return nil
+}
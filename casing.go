@@ -0,0 +1,50 @@
+package porygion
+
+// RouteCasingOptions controls the darker border drawn along the edges of
+// routes.
+type RouteCasingOptions struct {
+	// Shade is the factor the route colors are multiplied by to make the
+	// casing color. Values below 1 darken the routes.
+	Shade float64 `json:"shade"`
+}
+
+// DefaultRouteCasingOptions returns a casing that is a darker shade of the
+// route beneath it.
+func DefaultRouteCasingOptions() RouteCasingOptions {
+	return RouteCasingOptions{Shade: 0.6}
+}
+
+// applyRouteCasing marks the route pixels along the outer edges of the
+// routes as casing. Edges where a route meets a city are left open, so
+// that the routes still run cleanly into the cities.
+func applyRouteCasing(mask [][]layerPixel, cities []Tile) {
+	width := len(mask)
+	height := len(mask[0])
+	isCity := map[Tile]bool{}
+	for _, city := range cities {
+		isCity[city] = true
+	}
+	isOutside := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return false
+		}
+		return mask[x][y] == layerEmpty && !isCity[Tile{x / 8, y / 8}]
+	}
+	var edges []Tile
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			if mask[i][j] != layerRoute {
+				continue
+			}
+			for _, n := range (Tile{i, j}).neighbors() {
+				if isOutside(n.X, n.Y) {
+					edges = append(edges, Tile{i, j})
+					break
+				}
+			}
+		}
+	}
+	for _, p := range edges {
+		mask[p.X][p.Y] = layerRouteCasing
+	}
+}
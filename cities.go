@@ -0,0 +1,125 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// CityKind describes the size and role of a city, which determines the
+// marker it is drawn with.
+type CityKind int
+
+// City kinds.
+const (
+	CityTown CityKind = iota
+	CityCity
+	CityCapital
+	CityPort
+	CityLeague
+)
+
+func (k CityKind) String() string {
+	switch k {
+	case CityTown:
+		return "town"
+	case CityCity:
+		return "city"
+	case CityCapital:
+		return "capital"
+	case CityPort:
+		return "port"
+	case CityLeague:
+		return "league"
+	}
+	return "unknown"
+}
+
+var colorCityOutline = color.RGBA{248, 248, 248, 255}
+
+// cityMarkers are the 8x8 markers drawn for each kind of city. Pixels
+// marked with '#' use the palette's city color, pixels marked with 'o' use
+// the outline color, and all other pixels are left untouched.
+var cityMarkers = map[CityKind][8]string{
+	CityTown: {
+		"########",
+		"########",
+		"########",
+		"########",
+		"########",
+		"########",
+		"########",
+		"########",
+	},
+	CityCity: {
+		"oooooooo",
+		"o######o",
+		"o######o",
+		"o######o",
+		"o######o",
+		"o######o",
+		"o######o",
+		"oooooooo",
+	},
+	CityCapital: {
+		"...##...",
+		"...##...",
+		"########",
+		".######.",
+		"..####..",
+		".##..##.",
+		".#....#.",
+		"........",
+	},
+	CityPort: {
+		"...##...",
+		"..#..#..",
+		"...##...",
+		".######.",
+		"...##...",
+		"#..##..#",
+		"##.##.##",
+		".######.",
+	},
+	CityLeague: {
+		"...oo...",
+		"..o##o..",
+		".o####o.",
+		"o######o",
+		"o######o",
+		".o####o.",
+		"..o##o..",
+		"...oo...",
+	},
+}
+
+// getCityMarkerPixel returns how the marker for the given kind of city
+// covers the pixel at (i, j) within the city's tile.
+func getCityMarkerPixel(kind CityKind, i, j int) layerPixel {
+	marker, ok := cityMarkers[kind]
+	if !ok {
+		marker = cityMarkers[CityTown]
+	}
+	switch marker[j][i] {
+	case '#':
+		return layerCityFill
+	case 'o':
+		return layerCityOutline
+	}
+	return layerEmpty
+}
+
+// drawCityMarker draws the marker for the given kind of city.
+func drawCityMarker(img *image.RGBA, city Tile, kind CityKind, palette Palette) {
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			x := city.X*8 + i
+			y := city.Y*8 + j
+			switch getCityMarkerPixel(kind, i, j) {
+			case layerCityFill:
+				img.SetRGBA(x, y, palette.City)
+			case layerCityOutline:
+				img.SetRGBA(x, y, colorCityOutline)
+			}
+		}
+	}
+}
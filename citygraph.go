@@ -0,0 +1,136 @@
+package porygion
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CityGraph is the region map's route network as a weighted graph, where
+// the nodes are cities and the edges are route connections.
+type CityGraph struct {
+	// Cities are the nodes of the graph. Edges and the matrix refer to
+	// cities by their index in this slice.
+	Cities []Tile
+	// Matrix is the adjacency matrix of the graph. Matrix[i][j] is the
+	// length of the shortest connection between cities i and j, or 0 when
+	// they aren't directly connected.
+	Matrix [][]int
+	Edges  []CityGraphEdge
+}
+
+// CityGraphEdge is a route connection between two cities in a CityGraph.
+type CityGraphEdge struct {
+	A, B        int
+	Length      int
+	Class       RouteClass
+	VictoryRoad bool
+}
+
+// CityGraph returns the region map's route network as a weighted graph.
+// Connections to tiles that aren't cities are left out.
+func (r RegionMap) CityGraph() CityGraph {
+	graph := CityGraph{
+		Cities: r.Cities,
+		Matrix: make([][]int, len(r.Cities)),
+	}
+	indexes := map[Tile]int{}
+	for i, city := range r.Cities {
+		indexes[city] = i
+		graph.Matrix[i] = make([]int, len(r.Cities))
+	}
+	for _, c := range r.Connections {
+		a, okA := indexes[c.CityA]
+		b, okB := indexes[c.CityB]
+		if !okA || !okB {
+			continue
+		}
+		length := c.Length()
+		graph.Edges = append(graph.Edges, CityGraphEdge{
+			A:           a,
+			B:           b,
+			Length:      length,
+			Class:       c.Class,
+			VictoryRoad: c.VictoryRoad,
+		})
+		if graph.Matrix[a][b] == 0 || length < graph.Matrix[a][b] {
+			graph.Matrix[a][b] = length
+			graph.Matrix[b][a] = length
+		}
+	}
+	return graph
+}
+
+// cityGraphJSON is the JSON encoding of a CityGraph.
+type cityGraphJSON struct {
+	Nodes  []cityGraphNodeJSON `json:"nodes"`
+	Matrix [][]int             `json:"matrix"`
+	Edges  []cityGraphEdgeJSON `json:"edges"`
+}
+
+type cityGraphNodeJSON struct {
+	ID   int      `json:"id"`
+	X    int      `json:"x"`
+	Y    int      `json:"y"`
+	Name string   `json:"name,omitempty"`
+	Kind CityKind `json:"kind"`
+}
+
+type cityGraphEdgeJSON struct {
+	Source      int        `json:"source"`
+	Target      int        `json:"target"`
+	Weight      int        `json:"weight"`
+	Class       RouteClass `json:"class"`
+	VictoryRoad bool       `json:"victory_road"`
+}
+
+// ExportCityGraph encodes the region map's city graph as JSON, with a
+// list of nodes, the adjacency matrix, and a weighted edge list. Nodes
+// are identified by their index.
+func ExportCityGraph(regionMap RegionMap) ([]byte, error) {
+	graph := regionMap.CityGraph()
+	out := cityGraphJSON{
+		Nodes:  []cityGraphNodeJSON{},
+		Matrix: graph.Matrix,
+		Edges:  []cityGraphEdgeJSON{},
+	}
+	for i, city := range graph.Cities {
+		out.Nodes = append(out.Nodes, cityGraphNodeJSON{
+			ID:   i,
+			X:    city.X,
+			Y:    city.Y,
+			Name: regionMap.CityNames[city],
+			Kind: regionMap.CityKinds[city],
+		})
+	}
+	for _, e := range graph.Edges {
+		out.Edges = append(out.Edges, cityGraphEdgeJSON{
+			Source:      e.A,
+			Target:      e.B,
+			Weight:      e.Length,
+			Class:       e.Class,
+			VictoryRoad: e.VictoryRoad,
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode city graph: %s", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteWeightedEdgeList writes the region map's city graph as a plain
+// weighted edge list, with one "a b length" line per connection, where a
+// and b are city indexes. This is the format read by most graph analysis
+// tools, such as NetworkX's read_weighted_edgelist.
+func WriteWeightedEdgeList(w io.Writer, regionMap RegionMap) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range regionMap.CityGraph().Edges {
+		fmt.Fprintf(bw, "%d %d %d\n", e.A, e.B, e.Length)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write edge list: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Climate holds the temperature and moisture of every pixel in a region
+// map, both in the range 0 to 1. The climate is derived entirely from the
+// terrain, so it never changes for a given region map.
+type Climate struct {
+	Temperature [][]float64
+	Moisture    [][]float64
+}
+
+// Climate tuning parameters.
+const (
+	// Temperatures rise from the northern edge of the map to the southern
+	// edge, and fall with elevation.
+	northTemperature = 0.35
+	southTemperature = 1.0
+	elevationLapse   = 0.45
+	// moistureFalloff is the distance from water, in pixels, over which the
+	// moisture falls by a factor of e.
+	moistureFalloff = 40.0
+)
+
+// ComputeClimate derives the temperature and moisture of a region map from
+// its elevations.
+func ComputeClimate(regionMap RegionMap) Climate {
+	elevations := regionMap.Elevations
+	width := len(elevations)
+	height := len(elevations[0])
+	distances := getDistancesToWater(elevations)
+	climate := Climate{
+		Temperature: getNewElevationMap(width, height),
+		Moisture:    getNewElevationMap(width, height),
+	}
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			latitude := float64(j) / float64(height)
+			temperature := northTemperature + (southTemperature-northTemperature)*latitude
+			temperature -= math.Max(elevations[i][j], 0) * elevationLapse
+			climate.Temperature[i][j] = math.Max(0, math.Min(1, temperature))
+			climate.Moisture[i][j] = math.Exp(-float64(distances[i][j]) / moistureFalloff)
+		}
+	}
+	return climate
+}
+
+// getDistancesToWater returns the manhattan distance, in pixels, from every
+// pixel to the nearest water pixel. When there is no water at all, every
+// distance is the size of the map.
+func getDistancesToWater(elevations [][]float64) [][]int {
+	return getDistancesTo(elevations, func(e float64) bool { return e <= 0 })
+}
+
+// getDistancesToLand returns the manhattan distance, in pixels, from every
+// pixel to the nearest land pixel. When there is no land at all, every
+// distance is the size of the map.
+func getDistancesToLand(elevations [][]float64) [][]int {
+	return getDistancesTo(elevations, func(e float64) bool { return e > 0 })
+}
+
+// getDistancesTo returns the manhattan distance, in pixels, from every pixel
+// to the nearest pixel whose elevation matches the target.
+func getDistancesTo(elevations [][]float64, target func(e float64) bool) [][]int {
+	width := len(elevations)
+	height := len(elevations[0])
+	distances := make([][]int, width)
+	queue := []Tile{}
+	for i := range distances {
+		distances[i] = make([]int, height)
+		for j := range distances[i] {
+			if target(elevations[i][j]) {
+				queue = append(queue, Tile{i, j})
+			} else {
+				distances[i][j] = -1
+			}
+		}
+	}
+	if len(queue) == 0 {
+		for i := range distances {
+			for j := range distances[i] {
+				distances[i][j] = width + height
+			}
+		}
+		return distances
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, n := range p.neighbors() {
+			if n.X < 0 || n.Y < 0 || n.X >= width || n.Y >= height || distances[n.X][n.Y] != -1 {
+				continue
+			}
+			distances[n.X][n.Y] = distances[p.X][p.Y] + 1
+			queue = append(queue, n)
+		}
+	}
+	return distances
+}
+
+// Endpoints of the false-color climate gradients.
+var (
+	colorClimateLow  = color.RGBA{32, 64, 224, 255}
+	colorClimateHigh = color.RGBA{224, 32, 32, 255}
+)
+
+// RenderTemperatureMap renders the temperature of a region map as a
+// false-color image, from blue for cold to red for hot.
+func RenderTemperatureMap(regionMap RegionMap) image.Image {
+	climate := ComputeClimate(regionMap)
+	return renderClimateLayer(climate.Temperature, colorClimateLow, colorClimateHigh)
+}
+
+// RenderMoistureMap renders the moisture of a region map as a false-color
+// image, from red for dry to blue for wet.
+func RenderMoistureMap(regionMap RegionMap) image.Image {
+	climate := ComputeClimate(regionMap)
+	return renderClimateLayer(climate.Moisture, colorClimateHigh, colorClimateLow)
+}
+
+func renderClimateLayer(values [][]float64, low, high color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, len(values), len(values[0])))
+	for i := range values {
+		for j, v := range values[i] {
+			img.SetRGBA(i, j, lerpColor(low, high, v))
+		}
+	}
+	return img
+}
+
+// lerpColor linearly interpolates between two colors, where t is clamped to
+// the range 0 to 1.
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
@@ -0,0 +1,171 @@
+// Command libporygion builds the region map generator as a C shared
+// library, so that C, C++, and Python tools can embed it. Build it with:
+//
+//	go build -buildmode=c-shared -o libporygion.so ./cmd/libporygion
+//
+// which also writes the libporygion.h header. The library exports:
+//
+//	char *porygion_generate(long long seed, int width, int height, int num_cities, char **error);
+//	unsigned char *porygion_render_png(const char *map_json, const char *theme, int scale, size_t *length, char **error);
+//	unsigned char *porygion_export(const char *map_json, const char *format, size_t *length, char **error);
+//	char *porygion_export_formats(void);
+//	void porygion_free(void *p);
+//
+// porygion_generate returns the region map as a NUL-terminated JSON string,
+// and porygion_render_png renders that JSON as PNG bytes. Pass an empty or
+// NULL theme to use the default palette. porygion_export converts that JSON
+// into one of the formats of porygion.Export, like "geojson" or "stats",
+// and porygion_export_formats lists those formats, separated by commas.
+// The width and height of generated maps, and their number of cities, can
+// be at most 4096, and the scale must be between 1 and 8. On failure, each function returns
+// NULL, and sets *error, if error isn't NULL, to a message. Every returned
+// pointer, including error messages, must be released with porygion_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"unsafe"
+
+	"github.com/huderlem/porygion"
+)
+
+// maxGenerateDimension is the largest width or height, in pixels, of the
+// maps that porygion_generate generates, and the most cities it places, so
+// that a bad argument can't tie up the host for long.
+const maxGenerateDimension = 4096
+
+// maxRenderScale is the largest scale that porygion_render_png renders
+// maps at, so that a bad argument can't exhaust the host's memory.
+const maxRenderScale = 8
+
+func main() {}
+
+//export porygion_generate
+func porygion_generate(seed C.longlong, width, height, numCities C.int, cerr **C.char) (result *C.char) {
+	defer recoverError(cerr)
+	if width > maxGenerateDimension || height > maxGenerateDimension || numCities > maxGenerateDimension {
+		return setError(cerr, fmt.Errorf("Maps larger than %dx%d can't be generated", maxGenerateDimension, maxGenerateDimension))
+	}
+	config := porygion.NewConfig(porygion.MapParams{
+		Seed:        int64(seed),
+		PixelWidth:  int(width),
+		PixelHeight: int(height),
+		NumCities:   int(numCities),
+	})
+	if err := config.Validate(); err != nil {
+		return setError(cerr, err)
+	}
+	regionMap, err := config.Generate()
+	if err != nil {
+		return setError(cerr, err)
+	}
+	data, err := regionMap.MarshalJSON()
+	if err != nil {
+		return setError(cerr, err)
+	}
+	return C.CString(string(data))
+}
+
+//export porygion_render_png
+func porygion_render_png(mapJSON *C.char, theme *C.char, scale C.int, length *C.size_t, cerr **C.char) (result *C.uchar) {
+	defer recoverError(cerr)
+	if scale < 1 || scale > maxRenderScale {
+		setError(cerr, fmt.Errorf("Invalid scale %d. The scale must be between 1 and %d", int(scale), maxRenderScale))
+		return nil
+	}
+	regionMap, err := parseRegionMap(mapJSON)
+	if err != nil {
+		setError(cerr, err)
+		return nil
+	}
+	opts := porygion.RenderOptions{Scale: int(scale)}
+	if theme != nil && C.GoString(theme) != "" {
+		palette, err := porygion.ThemePalette(C.GoString(theme))
+		if err != nil {
+			setError(cerr, err)
+			return nil
+		}
+		opts.Palette = &palette
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		setError(cerr, fmt.Errorf("Failed to encode PNG: %s", err))
+		return nil
+	}
+	return copyBytes(buf.Bytes(), length)
+}
+
+//export porygion_export
+func porygion_export(mapJSON *C.char, format *C.char, length *C.size_t, cerr **C.char) (result *C.uchar) {
+	defer recoverError(cerr)
+	regionMap, err := parseRegionMap(mapJSON)
+	if err != nil {
+		setError(cerr, err)
+		return nil
+	}
+	if format == nil {
+		setError(cerr, fmt.Errorf("Missing export format"))
+		return nil
+	}
+	data, _, err := porygion.Export(regionMap, C.GoString(format))
+	if err != nil {
+		setError(cerr, err)
+		return nil
+	}
+	return copyBytes(data, length)
+}
+
+//export porygion_export_formats
+func porygion_export_formats() *C.char {
+	return C.CString(strings.Join(porygion.ExportFormatNames(), ","))
+}
+
+//export porygion_free
+func porygion_free(p unsafe.Pointer) {
+	C.free(p)
+}
+
+// setError stores a copy of err's message in *cerr, if cerr isn't NULL, and
+// returns NULL.
+func setError(cerr **C.char, err error) *C.char {
+	if cerr != nil {
+		*cerr = C.CString(err.Error())
+	}
+	return nil
+}
+
+// recoverError recovers from a panic in an exported function, and reports
+// it as an error, since a panic would abort the host process. It must be
+// deferred directly, and the function's named result is left as NULL.
+func recoverError(cerr **C.char) {
+	if r := recover(); r != nil {
+		setError(cerr, fmt.Errorf("Internal error: %v", r))
+	}
+}
+
+// parseRegionMap parses a region map from a C string of JSON.
+func parseRegionMap(mapJSON *C.char) (porygion.RegionMap, error) {
+	var regionMap porygion.RegionMap
+	if mapJSON == nil {
+		return regionMap, fmt.Errorf("Missing region map JSON")
+	}
+	err := regionMap.UnmarshalJSON([]byte(C.GoString(mapJSON)))
+	return regionMap, err
+}
+
+// copyBytes copies data into memory allocated by C, and stores its length
+// in *length, if length isn't NULL.
+func copyBytes(data []byte, length *C.size_t) *C.uchar {
+	if length != nil {
+		*length = C.size_t(len(data))
+	}
+	return (*C.uchar)(C.CBytes(data))
+}
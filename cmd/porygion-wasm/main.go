@@ -0,0 +1,145 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command porygion-wasm exposes the region map generator to JavaScript, so
+// that it can run entirely in the browser. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o porygion.wasm ./cmd/porygion-wasm
+//
+// and load it with the wasm_exec.js file that ships with Go. Once running,
+// it defines a global porygion object with these functions:
+//
+//	porygion.generate({seed, width, height, cities}) -> map JSON string
+//	porygion.renderPNG(mapJSON, {theme, scale}) -> Uint8Array
+//	porygion.renderImageData(mapJSON, {theme, scale}) -> ImageData
+//
+// Options may be omitted, and default to those of the porygion command.
+// The functions return an Error, rather than throwing it, when they fail.
+// index.html is a small demo page.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"syscall/js"
+
+	"github.com/huderlem/porygion"
+)
+
+func main() {
+	js.Global().Set("porygion", map[string]interface{}{
+		"generate":        wrap(generate),
+		"renderPNG":       wrap(renderPNG),
+		"renderImageData": wrap(renderImageData),
+	})
+	select {}
+}
+
+// wrap adapts fn into a JavaScript function that returns an Error when fn
+// fails.
+func wrap(fn func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result, err := fn(args)
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return result
+	})
+}
+
+func generate(args []js.Value) (interface{}, error) {
+	opts := getArg(args, 0)
+	config := porygion.NewConfig(porygion.MapParams{
+		Seed:        int64(getInt(opts, "seed", 0)),
+		PixelWidth:  getInt(opts, "width", 240),
+		PixelHeight: getInt(opts, "height", 160),
+		NumCities:   getInt(opts, "cities", 12),
+	})
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	regionMap, err := config.Generate()
+	if err != nil {
+		return nil, err
+	}
+	data, err := regionMap.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func renderPNG(args []js.Value) (interface{}, error) {
+	img, err := render(args)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("Failed to encode PNG: %s", err)
+	}
+	return toUint8Array(buf.Bytes()), nil
+}
+
+func renderImageData(args []js.Value) (interface{}, error) {
+	img, err := render(args)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	pixels := js.Global().Get("Uint8ClampedArray").New(toUint8Array(rgba.Pix).Get("buffer"))
+	return js.Global().Get("ImageData").New(pixels, bounds.Dx(), bounds.Dy()), nil
+}
+
+// render parses the map JSON and render options arguments, and renders the
+// region map.
+func render(args []js.Value) (image.Image, error) {
+	mapJSON := getArg(args, 0)
+	if mapJSON.Type() != js.TypeString {
+		return nil, fmt.Errorf("Expected the region map JSON as the first argument")
+	}
+	var regionMap porygion.RegionMap
+	if err := regionMap.UnmarshalJSON([]byte(mapJSON.String())); err != nil {
+		return nil, err
+	}
+	opts := getArg(args, 1)
+	renderOpts := porygion.RenderOptions{Scale: getInt(opts, "scale", 1)}
+	if theme := opts.Get("theme"); theme.Type() == js.TypeString {
+		palette, err := porygion.ThemePalette(theme.String())
+		if err != nil {
+			return nil, err
+		}
+		renderOpts.Palette = &palette
+	}
+	return porygion.RenderRegionMap(regionMap, renderOpts), nil
+}
+
+// getArg returns the i-th argument, or an empty object if it was omitted.
+func getArg(args []js.Value, i int) js.Value {
+	if i >= len(args) || args[i].Type() == js.TypeUndefined || args[i].Type() == js.TypeNull {
+		return js.Global().Get("Object").New()
+	}
+	return args[i]
+}
+
+// getInt returns the named numeric property of an object, or def if it
+// isn't set.
+func getInt(obj js.Value, name string, def int) int {
+	v := obj.Get(name)
+	if v.Type() != js.TypeNumber {
+		return def
+	}
+	return v.Int()
+}
+
+// toUint8Array copies data into a new JavaScript Uint8Array.
+func toUint8Array(data []byte) js.Value {
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return array
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/huderlem/porygion"
+)
+
+// maxBatchSeeds limits the number of seeds in a batch, to catch typos like
+// "1-10000000" before they fill up the disk.
+const maxBatchSeeds = 100000
+
+// runBatch generates and renders a region map for every seed in a range,
+// with a pool of workers, and writes each map's image and stats report.
+func runBatch(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	seedRanges := fs.String("seeds", "", "seeds to generate, like 1-500 or 1,5,10-20")
+	out := fs.String("out", ".", "directory to write the images and stats to")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of maps to generate at once")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if isFlagSet(fs, "seed") {
+		return fmt.Errorf("Use -seeds instead of -seed")
+	}
+	seeds, err := parseSeedRanges(*seedRanges)
+	if err != nil {
+		return err
+	}
+	if *workers < 1 {
+		return fmt.Errorf("Invalid number of workers %d", *workers)
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	return forEachSeed(seeds, *workers, func(seed int64) error {
+		c := config
+		c.Seed = seed
+		return writeBatchMap(gen, c, *out)
+	})
+}
+
+// forEachSeed calls fn for each seed, from a pool of workers, and prints
+// the progress. Failed seeds are reported, but don't stop the others.
+func forEachSeed(seeds []int64, workers int, fn func(seed int64) error) error {
+	jobs := make(chan int64)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range jobs {
+				err := fn(seed)
+				mu.Lock()
+				if err != nil {
+					failed++
+					fmt.Fprintf(os.Stderr, "Seed %d: %s\n", seed, err)
+				} else {
+					fmt.Printf("Seed %d: done\n", seed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, seed := range seeds {
+		jobs <- seed
+	}
+	close(jobs)
+	wg.Wait()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d seeds failed", failed, len(seeds))
+	}
+	return nil
+}
+
+// writeBatchMap generates the region map for a config, and writes its
+// image and stats report to the directory, named after its seed.
+func writeBatchMap(gen *generationFlags, config porygion.Config, dir string) error {
+	regionMap, err := gen.generate(config)
+	if err != nil {
+		return err
+	}
+	opts, err := config.RenderOptions()
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("seed-%d", config.Seed))
+	if err := writePNG(name+".png", porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		return err
+	}
+	stats, err := porygion.ExportStats(regionMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name+".json", stats, 0644)
+}
+
+// parseSeedRanges parses a comma-separated list of seeds and inclusive
+// ranges of seeds, like "1,5,10-20".
+func parseSeedRanges(s string) ([]int64, error) {
+	if s == "" {
+		return nil, fmt.Errorf("No seeds given. Use -seeds, like -seeds 1-500")
+	}
+	seeds := []int64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		// Look for the dash after the first character, so that negative
+		// seeds can be given on their own.
+		first, last := part, part
+		if len(part) > 1 {
+			if i := strings.Index(part[1:], "-"); i >= 0 {
+				first, last = part[:i+1], part[i+2:]
+			}
+		}
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid seed range %q", part)
+		}
+		end, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("Invalid seed range %q", part)
+		}
+		count := end - start + 1
+		if count <= 0 || count > maxBatchSeeds-int64(len(seeds)) {
+			return nil, fmt.Errorf("Too many seeds. At most %d seeds can be generated at once", maxBatchSeeds)
+		}
+		for i := int64(0); i < count; i++ {
+			seeds = append(seeds, start+i)
+		}
+	}
+	return seeds, nil
+}
+
+// isFlagSet reports whether a flag was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
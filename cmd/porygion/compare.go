@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/huderlem/porygion"
+)
+
+// runCompare compares two saved region maps, prints the cities and route
+// tiles that were added or removed, and optionally writes an image of the
+// differences.
+func runCompare(fs *flag.FlagSet, args []string) error {
+	out := fs.String("out", "", "path to write the diff image to, as a PNG")
+	tolerance := fs.Float64("tolerance", 0, "largest elevation difference that counts as unchanged")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 2 {
+		return fmt.Errorf("Expected two region map files to compare")
+	}
+	a, err := loadRegionMap(paths[0])
+	if err != nil {
+		return err
+	}
+	b, err := loadRegionMap(paths[1])
+	if err != nil {
+		return err
+	}
+	diff, err := porygion.DiffRegionMaps(a, b, *tolerance)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range diff.AddedCities {
+		fmt.Printf("+ city %s\n", formatCity(b, t))
+	}
+	for _, t := range diff.RemovedCities {
+		fmt.Printf("- city %s\n", formatCity(a, t))
+	}
+	for _, t := range diff.AddedRoutes {
+		fmt.Printf("+ route (%d, %d)\n", t.X, t.Y)
+	}
+	for _, t := range diff.RemovedRoutes {
+		fmt.Printf("- route (%d, %d)\n", t.X, t.Y)
+	}
+	if diff.Empty() {
+		fmt.Println("The region maps are the same")
+	} else {
+		fmt.Printf("%d elevations changed, %d cities added, %d cities removed, %d route tiles added, %d route tiles removed\n",
+			len(diff.ChangedElevations), len(diff.AddedCities), len(diff.RemovedCities), len(diff.AddedRoutes), len(diff.RemovedRoutes))
+	}
+	if *out != "" {
+		return writePNG(*out, porygion.RenderRegionMapDiff(b, diff))
+	}
+	return nil
+}
+
+// formatCity describes a city tile, including its name if it has one.
+func formatCity(regionMap porygion.RegionMap, t porygion.Tile) string {
+	if name, ok := regionMap.CityNames[t]; ok {
+		return fmt.Sprintf("%s (%d, %d)", name, t.X, t.Y)
+	}
+	return fmt.Sprintf("(%d, %d)", t.X, t.Y)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/huderlem/porygion"
+)
+
+// exportFormat is a format that the export command can convert region
+// maps into.
+type exportFormat struct {
+	// addFlags registers the format's own flags, and returns the function
+	// that exports a region map into a directory. Each file is named
+	// after name, the base name of the region map's file.
+	addFlags func(fs *flag.FlagSet) func(e exportJob) error
+}
+
+// exportJob is a region map to export, and where to write it.
+type exportJob struct {
+	regionMap porygion.RegionMap
+	palette   porygion.Palette
+	dir       string
+	name      string
+}
+
+// path returns the path of an exported file with the given suffix.
+func (e exportJob) path(suffix string) string {
+	return filepath.Join(e.dir, e.name+suffix)
+}
+
+// exportFormats are the formats that the export command supports.
+var exportFormats = map[string]exportFormat{
+	"tiled":   {noExportFlags(exportTilemap(porygion.ExportTiled, ".tmj"))},
+	"unity":   {noExportFlags(exportTilemap(porygion.ExportUnityTilemap, ".unity.json"))},
+	"godot":   {noExportFlags(exportGodot)},
+	"emerald": {noExportFlags(exportTownMap(porygion.TownMapEmerald))},
+	"frlg":    {noExportFlags(exportTownMap(porygion.TownMapFRLG))},
+	"geojson": {noExportFlags(exportBytes(porygion.ExportGeoJSON, ".geojson"))},
+	"obj":     {addOBJFlags},
+	"svg": {noExportFlags(func(e exportJob) error {
+		return writeFile(e.path(".svg"), func(f *os.File) error { return porygion.WriteSVG(f, e.regionMap, e.palette) })
+	})},
+	"heightfield": {addHeightfieldFlags},
+	"stats":       {noExportFlags(exportBytes(porygion.ExportStats, ".stats.json"))},
+	"city-graph":  {noExportFlags(exportBytes(porygion.ExportCityGraph, ".graph.json"))},
+	"dot":         {noExportFlags(exportWriter(porygion.WriteDOT, ".dot"))},
+	"lua":         {noExportFlags(exportWriter(porygion.WriteLua, ".lua"))},
+	"porymap":     {noExportFlags(exportBytes(porygion.ExportPorymapSections, ".porymap.json"))},
+}
+
+// runExport converts a saved region map into one of the export formats.
+func runExport(fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "", "format to export to: "+strings.Join(getExportFormatNames(), ", "))
+	out := fs.String("out", ".", "directory to write the exported files to")
+	theme := fs.String("theme", "", "palette theme for formats that include colors")
+	exporters := map[string]func(exportJob) error{}
+	for name, f := range exportFormats {
+		exporters[name] = f.addFlags(fs)
+	}
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 1 {
+		return fmt.Errorf("Expected one region map file to export")
+	}
+	export, ok := exporters[*format]
+	if !ok {
+		return fmt.Errorf("Unknown export format '%s'. Valid formats are: %s", *format, strings.Join(getExportFormatNames(), ", "))
+	}
+	regionMap, err := loadRegionMap(paths[0])
+	if err != nil {
+		return err
+	}
+	palette := porygion.DefaultPalette()
+	if *theme != "" {
+		if palette, err = porygion.ThemePalette(*theme); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(paths[0]), filepath.Ext(paths[0]))
+	if err := export(exportJob{regionMap, palette, *out, name}); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %s to %s as %s\n", paths[0], *out, *format)
+	return nil
+}
+
+// getExportFormatNames returns the names of the export formats, sorted.
+func getExportFormatNames() []string {
+	names := []string{}
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// noExportFlags is the addFlags function of a format without any flags of
+// its own.
+func noExportFlags(export func(e exportJob) error) func(fs *flag.FlagSet) func(e exportJob) error {
+	return func(fs *flag.FlagSet) func(e exportJob) error {
+		return export
+	}
+}
+
+// exportBytes adapts an exporter that returns the encoded file.
+func exportBytes(export func(porygion.RegionMap) ([]byte, error), suffix string) func(e exportJob) error {
+	return func(e exportJob) error {
+		data, err := export(e.regionMap)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(e.path(suffix), data, 0644)
+	}
+}
+
+// exportWriter adapts an exporter that writes the file to an io.Writer.
+func exportWriter(export func(io.Writer, porygion.RegionMap) error, suffix string) func(e exportJob) error {
+	return func(e exportJob) error {
+		return writeFile(e.path(suffix), func(f *os.File) error { return export(f, e.regionMap) })
+	}
+}
+
+// exportTilemap adapts a tile-based map exporter, which also writes a
+// tileset image next to the map.
+func exportTilemap(export func(porygion.RegionMap, porygion.Palette, string) (porygion.TilemapExport, error), suffix string) func(e exportJob) error {
+	return func(e exportJob) error {
+		tileset := e.name + "_tiles.png"
+		tilemap, err := export(e.regionMap, e.palette, tileset)
+		if err != nil {
+			return err
+		}
+		return writeTilemap(e, tilemap, suffix, tileset)
+	}
+}
+
+func exportGodot(e exportJob) error {
+	tileset := e.name + "_tiles.png"
+	tilemap, err := porygion.ExportGodotScene(e.regionMap, e.palette, "res://"+tileset)
+	if err != nil {
+		return err
+	}
+	return writeTilemap(e, tilemap, ".tscn", tileset)
+}
+
+// writeTilemap writes an exported tile-based map and its tileset image.
+func writeTilemap(e exportJob, tilemap porygion.TilemapExport, suffix, tileset string) error {
+	if err := ioutil.WriteFile(e.path(suffix), tilemap.Map, 0644); err != nil {
+		return err
+	}
+	return writePNG(filepath.Join(e.dir, tileset), tilemap.Tileset)
+}
+
+// exportTownMap exports the Town Map graphics for a target game, as the
+// .bin, .4bpp, and .gbapal files its decompilation project uses.
+func exportTownMap(target porygion.TownMapTarget) func(e exportJob) error {
+	return func(e exportJob) error {
+		townMap, err := porygion.ExportTownMap(e.regionMap, porygion.RenderOptions{Palette: &e.palette}, target)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(e.path(".bin"), townMap.Tilemap, 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(e.path(".4bpp"), townMap.Tiles, 0644); err != nil {
+			return err
+		}
+		return writeFile(e.path(".gbapal"), func(f *os.File) error { return porygion.WriteGBAPalette(f, townMap.Palette) })
+	}
+}
+
+func addOBJFlags(fs *flag.FlagSet) func(e exportJob) error {
+	verticalScale := fs.Float64("obj-vertical-scale", 16, "obj: factor to multiply elevations by")
+	return func(e exportJob) error {
+		var buf bytes.Buffer
+		if err := porygion.ExportMeshOBJ(e.regionMap, *verticalScale, &buf); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(e.path(".obj"), buf.Bytes(), 0644)
+	}
+}
+
+func addHeightfieldFlags(fs *flag.FlagSet) func(e exportJob) error {
+	format := fs.String("heightfield-format", "r16", "heightfield: height format, one of r16, raw8, or r32")
+	bigEndian := fs.Bool("heightfield-big-endian", false, "heightfield: write 16-bit and 32-bit heights in big endian")
+	return func(e exportJob) error {
+		opts := porygion.DefaultHeightfieldOptions()
+		suffix := "." + *format
+		switch *format {
+		case "r16":
+			opts.Format = porygion.HeightfieldR16
+		case "raw8":
+			opts.Format = porygion.HeightfieldRaw8
+			suffix = ".raw"
+		case "r32":
+			opts.Format = porygion.HeightfieldR32
+		default:
+			return fmt.Errorf("Unknown heightfield format '%s'. Valid formats are: r16, raw8, r32", *format)
+		}
+		if *bigEndian {
+			opts.ByteOrder = binary.BigEndian
+		}
+		return writeFile(e.path(suffix), func(f *os.File) error { return porygion.WriteHeightfield(f, e.regionMap, opts) })
+	}
+}
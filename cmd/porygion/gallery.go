@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/huderlem/porygion"
+)
+
+// galleryTemplate is the HTML page of a gallery.
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Porygion gallery</title>
+<style>
+body { font-family: sans-serif; background: #222; color: #eee; }
+.maps { display: flex; flex-wrap: wrap; gap: 16px; }
+.map { background: #333; padding: 8px; }
+.map img { image-rendering: pixelated; display: block; width: {{.ThumbnailWidth}}px; }
+.map h2 { font-size: 16px; margin: 8px 0 4px; }
+.map p { font-size: 12px; margin: 0; }
+</style>
+</head>
+<body>
+<h1>Porygion gallery</h1>
+<div class="maps">
+{{range .Maps}}<div class="map">
+<a href="{{.Image}}"><img src="{{.Image}}" alt="Seed {{.Seed}}"></a>
+<h2>Seed {{.Seed}}</h2>
+{{if .Error}}<p>{{.Error}}</p>{{else}}<p>{{printf "%.0f" .Stats.LandPercentage}}% land, {{.Stats.IslandCount}} islands</p>
+<p>{{.Stats.CityCount}} cities, {{.Stats.RouteLength}} route tiles</p>{{end}}
+</div>
+{{end}}</div>
+</body>
+</html>
+`))
+
+// galleryMap is a single map in the gallery page.
+type galleryMap struct {
+	Seed  int64
+	Image string
+	Stats porygion.RegionMapStats
+	Error string
+}
+
+// runGallery generates region maps for a number of seeds, and writes an
+// HTML page that shows their images and stats side by side.
+func runGallery(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	count := fs.Int("count", 24, "number of seeds to generate")
+	start := fs.Int64("start", 0, "first seed to generate (default random)")
+	thumbnailWidth := fs.Int("thumbnail-width", 240, "width of the thumbnails on the page, in pixels")
+	out := fs.String("out", "gallery", "directory to write the page and images to")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of maps to generate at once")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if isFlagSet(fs, "seed") {
+		return fmt.Errorf("Use -start instead of -seed")
+	}
+	if *count < 1 || *count > maxBatchSeeds {
+		return fmt.Errorf("Invalid number of seeds %d", *count)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("Invalid number of workers %d", *workers)
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+	if !isFlagSet(fs, "start") {
+		*start = time.Now().UnixNano()
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	seeds := make([]int64, *count)
+	maps := make([]galleryMap, *count)
+	indexes := map[int64]int{}
+	for i := range seeds {
+		seeds[i] = *start + int64(i)
+		indexes[seeds[i]] = i
+	}
+	var mu sync.Mutex
+	// Failed seeds are still shown on the page, with their error, so the
+	// page is written even when some of them fail.
+	genErr := forEachSeed(seeds, *workers, func(seed int64) error {
+		c := config
+		c.Seed = seed
+		m, err := renderGalleryMap(gen, c, *out)
+		if err != nil {
+			m.Error = err.Error()
+		}
+		mu.Lock()
+		maps[indexes[seed]] = m
+		mu.Unlock()
+		return err
+	})
+
+	page := filepath.Join(*out, "index.html")
+	err = writeFile(page, func(f *os.File) error {
+		return galleryTemplate.Execute(f, struct {
+			ThumbnailWidth int
+			Maps           []galleryMap
+		}{*thumbnailWidth, maps})
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", page)
+	return genErr
+}
+
+// renderGalleryMap generates and renders the region map for a config into
+// the gallery directory.
+func renderGalleryMap(gen *generationFlags, config porygion.Config, dir string) (galleryMap, error) {
+	m := galleryMap{
+		Seed:  config.Seed,
+		Image: fmt.Sprintf("seed-%d.png", config.Seed),
+	}
+	regionMap, err := gen.generate(config)
+	if err != nil {
+		return m, err
+	}
+	opts, err := config.RenderOptions()
+	if err != nil {
+		return m, err
+	}
+	m.Stats = regionMap.Stats()
+	return m, writePNG(filepath.Join(dir, m.Image), porygion.RenderRegionMap(regionMap, opts))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/huderlem/porygion"
+)
+
+// runGenerate generates a region map and writes the rendered PNG, and
+// optionally the region map itself, so that it can be rendered again
+// later.
+func runGenerate(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	out := fs.String("out", "map.png", "path of the rendered PNG")
+	save := fs.String("save", "", "path to also save the region map to, as JSON")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+	regionMap, err := gen.generate(config)
+	if err != nil {
+		return err
+	}
+	opts, err := config.RenderOptions()
+	if err != nil {
+		return err
+	}
+	if err := writePNG(*out, porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		return err
+	}
+	if *save != "" {
+		err := writeFile(*save, func(f *os.File) error {
+			return json.NewEncoder(f).Encode(regionMap)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote %s (seed %d)\n", *out, config.Seed)
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/huderlem/porygion"
+)
+
+// runGRPC serves the MapService gRPC API. gRPC needs HTTP/2, which Go's
+// HTTP server only speaks over TLS, so a certificate is required.
+func runGRPC(fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", "localhost:50051", "address to listen on")
+	cert := fs.String("cert", "", "TLS certificate file (required)")
+	key := fs.String("key", "", "TLS private key file (required)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *cert == "" || *key == "" {
+		return fmt.Errorf("-cert and -key are required")
+	}
+	fmt.Printf("Serving porygion.MapService on %s\n", *addr)
+	return http.ListenAndServeTLS(*addr, *cert, *key, porygion.NewGRPCHandler())
+}
@@ -0,0 +1,107 @@
+// Command porygion generates and renders region maps from the command line.
+//
+// Usage:
+//
+//	porygion <command> [arguments]
+//
+// Run "porygion help" for the list of commands, and
+// "porygion <command> -h" for the arguments of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	name string
+	// usage is the line describing the command's arguments.
+	usage   string
+	summary string
+	// run runs the command. The flag set is empty, and prints the
+	// command's usage when its flags fail to parse.
+	run func(fs *flag.FlagSet, args []string) error
+}
+
+// commands are the CLI's subcommands, in the order they are listed in the
+// help.
+var commands = []command{
+	{"generate", "generate [flags]", "Generate a region map and render it as a PNG", runGenerate},
+	{"render", "render [flags] <map.json>", "Render a saved region map as a PNG", runRender},
+	{"retheme", "retheme [flags] <map.json>", "Render a presentation-quality image of a saved region map", runRetheme},
+	{"reroll-cities", "reroll-cities [flags] <map.json>", "Place new cities and routes on a saved region map", runRerollCities},
+	{"reroll-routes", "reroll-routes [flags] <map.json>", "Plan new routes on a saved region map", runRerollRoutes},
+	{"compare", "compare [flags] <a.json> <b.json>", "Show the differences between two saved region maps", runCompare},
+	{"export", "export -format <format> [flags] <map.json>", "Convert a saved region map into another format", runExport},
+	{"stats", "stats [flags] [map.json]", "Print statistics about a region map", runStats},
+	{"batch", "batch -seeds <range> [flags]", "Generate and render region maps for a range of seeds", runBatch},
+	{"search", "search [flags]", "Find seeds whose region maps meet constraints", runSearch},
+	{"gallery", "gallery [flags]", "Generate region maps and write an HTML page to browse them", runGallery},
+	{"serve", "serve [flags]", "Start a web server with a live preview of the generator", runServe},
+	{"grpc", "grpc -cert <file> -key <file> [flags]", "Start a gRPC server for the MapService API", runGRPC},
+	{"tui", "tui [flags]", "Explore seeds interactively in the terminal", runTUI},
+	{"watch", "watch [flags] <config>", "Re-render a region map whenever its config file changes", runWatch},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(newFlagSet(c), os.Args[2:]); err != nil {
+				if err != flag.ErrHelp {
+					fmt.Fprintf(os.Stderr, "porygion %s: %s\n", name, err)
+				}
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "porygion: unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(2)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: porygion <command> [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+// newFlagSet returns the flag set for a command, which prints the
+// command's usage line before its flags.
+func newFlagSet(c command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: porygion %s\n\n%s.\n\nFlags:\n", c.usage, c.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of a command, allowing them to come before
+// or after its positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
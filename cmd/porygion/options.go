@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"time"
+
+	"github.com/huderlem/porygion"
+)
+
+// Defaults for the generation flags.
+const (
+	defaultWidth  = 240
+	defaultHeight = 160
+	defaultCities = 12
+)
+
+// generationFlags are the flags shared by the commands that generate
+// region maps. The generation config is read from a config file or
+// profile when one is given, and the other flags override it.
+type generationFlags struct {
+	seed     int64
+	width    int
+	height   int
+	cities   int
+	theme    string
+	scale    int
+	config   string
+	profiles string
+	profile  string
+	plugins  pluginFlags
+	workers  int
+}
+
+// addGenerationFlags adds the generation flags to a flag set.
+func addGenerationFlags(fs *flag.FlagSet) *generationFlags {
+	f := &generationFlags{}
+	fs.Int64Var(&f.seed, "seed", 0, "seed to generate from (default random)")
+	fs.IntVar(&f.width, "width", defaultWidth, "width of the map, in pixels")
+	fs.IntVar(&f.height, "height", defaultHeight, "height of the map, in pixels")
+	fs.IntVar(&f.cities, "cities", defaultCities, "number of cities")
+	fs.StringVar(&f.theme, "theme", "", "palette theme to render with")
+	fs.IntVar(&f.scale, "scale", 1, "integer factor to enlarge the rendered map by")
+	fs.StringVar(&f.config, "config", "", "JSON config file to generate from")
+	fs.StringVar(&f.profiles, "profiles", "", "TOML file of profiles, used with -profile")
+	fs.StringVar(&f.profile, "profile", "", "name of the profile to generate from")
+	fs.IntVar(&f.workers, "elevation-workers", 0, "number of goroutines that generate the elevations (default one per CPU)")
+	fs.Var(&f.plugins, "plugin", "plugin to run after a generation stage, like elevations=./erode (may be repeated)")
+	return f
+}
+
+// getConfig returns the generation config described by the flags.
+func (f *generationFlags) getConfig(fs *flag.FlagSet) (porygion.Config, error) {
+	config := porygion.NewConfig(porygion.MapParams{
+		Seed:        time.Now().UnixNano(),
+		PixelWidth:  defaultWidth,
+		PixelHeight: defaultHeight,
+		NumCities:   defaultCities,
+	})
+	switch {
+	case f.config != "" && f.profile != "":
+		return config, fmt.Errorf("The -config and -profile flags can't be used together")
+	case f.config != "":
+		file, err := os.Open(f.config)
+		if err != nil {
+			return config, err
+		}
+		defer file.Close()
+		if config, err = porygion.LoadConfig(file); err != nil {
+			return config, err
+		}
+	case f.profile != "":
+		if f.profiles == "" {
+			return config, fmt.Errorf("The -profile flag requires -profiles")
+		}
+		file, err := os.Open(f.profiles)
+		if err != nil {
+			return config, err
+		}
+		defer file.Close()
+		profiles, err := porygion.LoadProfiles(file)
+		if err != nil {
+			return config, err
+		}
+		if err := profiles.Apply(&config, f.profile); err != nil {
+			return config, err
+		}
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "seed":
+			config.Seed = f.seed
+		case "width":
+			config.PixelWidth = f.width
+		case "height":
+			config.PixelHeight = f.height
+		case "cities":
+			config.NumCities = f.cities
+		case "theme":
+			config.Theme = f.theme
+			config.Render.Palette = nil
+		case "scale":
+			config.Render.Scale = f.scale
+		case "elevation-workers":
+			config.ElevationWorkers = f.workers
+		}
+	})
+	return config, config.Validate()
+}
+
+// generate generates the region map for a config, running the plugins
+// given by the flags.
+func (f *generationFlags) generate(config porygion.Config) (porygion.RegionMap, error) {
+	return config.GenerateWithPlugins(f.plugins)
+}
+
+// pluginFlags is a repeatable flag for generation plugins.
+type pluginFlags []porygion.Plugin
+
+func (p *pluginFlags) String() string {
+	return fmt.Sprint(len(*p), " plugins")
+}
+
+func (p *pluginFlags) Set(s string) error {
+	plugin, err := porygion.ParsePlugin(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, plugin)
+	return nil
+}
+
+// writePNG encodes an image as a PNG file.
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to write %s: %s", path, err)
+	}
+	return file.Close()
+}
+
+// writeFile creates a file and writes to it with the given function.
+func writeFile(path string, write func(f *os.File) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
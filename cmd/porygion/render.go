@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/huderlem/porygion"
+)
+
+// runRender renders a saved region map with new render options, without
+// regenerating it.
+func runRender(fs *flag.FlagSet, args []string) error {
+	theme := fs.String("theme", "", "palette theme to render with")
+	scale := fs.Int("scale", 1, "integer factor to enlarge the rendered map by")
+	legend := fs.Bool("legend", false, "add a legend beneath the map")
+	night := fs.Bool("night", false, "render the night variant of the palette")
+	hillshade := fs.Bool("hillshade", false, "shade the land by the slope of the terrain")
+	compass := fs.Bool("compass", false, "draw a compass rose")
+	out := fs.String("out", "map.png", "path of the rendered PNG")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	regionMap, err := loadRegionMap(positional[0])
+	if err != nil {
+		return err
+	}
+
+	opts := porygion.RenderOptions{
+		Scale:  *scale,
+		Legend: *legend,
+		Night:  *night,
+	}
+	if *theme != "" {
+		palette, err := porygion.ThemePalette(*theme)
+		if err != nil {
+			return err
+		}
+		opts.Palette = &palette
+	}
+	if *hillshade {
+		hillshadeOpts := porygion.DefaultHillshadeOptions()
+		opts.Hillshade = &hillshadeOpts
+	}
+	if *compass {
+		compassOpts := porygion.DefaultCompassOptions()
+		opts.Compass = &compassOpts
+	}
+	if err := writePNG(*out, porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", *out)
+	return nil
+}
+
+// loadRegionMap reads a saved region map, either as JSON, or in the region
+// map file format.
+func loadRegionMap(path string) (porygion.RegionMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return porygion.RegionMap{}, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	first, err := r.Peek(1)
+	if err != nil {
+		return porygion.RegionMap{}, fmt.Errorf("Failed to read %s: %s", path, err)
+	}
+	var regionMap porygion.RegionMap
+	if first[0] == '{' {
+		err = json.NewDecoder(r).Decode(&regionMap)
+	} else {
+		regionMap, err = porygion.ReadMapFile(r)
+	}
+	if err != nil {
+		return porygion.RegionMap{}, fmt.Errorf("Failed to read %s: %s", path, err)
+	}
+	return regionMap, nil
+}
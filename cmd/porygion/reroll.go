@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huderlem/porygion"
+)
+
+// runRerollCities places new cities on a saved region map, keeping its
+// terrain, and plans new routes between them.
+func runRerollCities(fs *flag.FlagSet, args []string) error {
+	cities := fs.Int("cities", 0, "number of cities (default the map's current number)")
+	return runReroll(fs, args, func(seed int64, regionMap porygion.RegionMap) (porygion.RegionMap, error) {
+		n := *cities
+		if n == 0 {
+			n = len(regionMap.Cities)
+		}
+		if n < 2 {
+			return regionMap, fmt.Errorf("Invalid number of cities %d", n)
+		}
+		regionMap = porygion.GenerateRegionMapWithCities(seed, n, regionMap)
+		regionMap.CityNames = nil
+		regionMap.CityKinds = nil
+		return porygion.GenerateRegionMapWithRoutes(seed, regionMap)
+	})
+}
+
+// runRerollRoutes plans new routes between the cities of a saved region
+// map, keeping its terrain and cities.
+func runRerollRoutes(fs *flag.FlagSet, args []string) error {
+	return runReroll(fs, args, porygion.GenerateRegionMapWithRoutes)
+}
+
+// runReroll loads a saved region map, regenerates part of it with reroll,
+// and writes the updated map and its image. The map is saved back to the
+// file it was loaded from, unless -save is given.
+func runReroll(fs *flag.FlagSet, args []string, reroll func(seed int64, regionMap porygion.RegionMap) (porygion.RegionMap, error)) error {
+	seed := fs.Int64("seed", 0, "seed to regenerate from (default random)")
+	out := fs.String("out", "map.png", "path of the rendered PNG")
+	save := fs.String("save", "", "path to save the updated region map to (default the input file)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	regionMap, err := loadRegionMap(positional[0])
+	if err != nil {
+		return err
+	}
+	if !isFlagSet(fs, "seed") {
+		*seed = time.Now().UnixNano()
+	}
+	if regionMap, err = reroll(*seed, regionMap); err != nil {
+		return err
+	}
+
+	path := *save
+	if path == "" {
+		path = positional[0]
+	}
+	if err := saveRegionMap(path, regionMap); err != nil {
+		return err
+	}
+	if err := writePNG(*out, porygion.RenderRegionMap(regionMap, porygion.RenderOptions{})); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s and %s (seed %d)\n", path, *out, *seed)
+	return nil
+}
+
+// saveRegionMap writes a region map as JSON if the path ends in .json,
+// and in the region map file format otherwise, so that loadRegionMap can
+// read it back.
+func saveRegionMap(path string, regionMap porygion.RegionMap) error {
+	return writeFile(path, func(f *os.File) error {
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			return json.NewEncoder(f).Encode(regionMap)
+		}
+		return porygion.WriteMapFile(f, regionMap)
+	})
+}
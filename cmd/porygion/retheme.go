@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/huderlem/porygion"
+)
+
+// runRetheme renders a presentation-quality image of a saved region map,
+// without regenerating it. Unlike render, it enlarges the map and turns on
+// the finishing touches by default, and it can label the cities.
+func runRetheme(fs *flag.FlagSet, args []string) error {
+	theme := fs.String("theme", "", "palette theme to render with")
+	scale := fs.Int("scale", 3, "integer factor to enlarge the rendered map by")
+	labels := fs.Bool("labels", false, "label the cities. Unnamed cities are labeled like TOWN 1")
+	fontPath := fs.String("font", "", "TrueType font file for the labels (default built-in font)")
+	fontSize := fs.Float64("font-size", 16, "point size of the label font")
+	night := fs.Bool("night", false, "render the night variant of the palette")
+	hillshade := fs.Bool("hillshade", true, "shade the land by the slope of the terrain")
+	coastline := fs.Bool("coastline", true, "outline the coasts")
+	casing := fs.Bool("route-casing", true, "outline the routes")
+	legend := fs.Bool("legend", false, "add a legend beneath the map")
+	compass := fs.Bool("compass", false, "draw a compass rose")
+	scaleBar := fs.Bool("scale-bar", false, "draw a scale bar")
+	out := fs.String("out", "", "path of the rendered PNG (default named after the map and theme)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if *scale < 1 {
+		return fmt.Errorf("Invalid scale %d", *scale)
+	}
+	regionMap, err := loadRegionMap(positional[0])
+	if err != nil {
+		return err
+	}
+
+	opts := porygion.RenderOptions{
+		Scale:  *scale,
+		Legend: *legend,
+		Night:  *night,
+	}
+	if *theme != "" {
+		palette, err := porygion.ThemePalette(*theme)
+		if err != nil {
+			return err
+		}
+		opts.Palette = &palette
+	}
+	if *labels {
+		labelOpts := porygion.DefaultLabelOptions()
+		if *fontPath != "" {
+			ttf, err := ioutil.ReadFile(*fontPath)
+			if err != nil {
+				return err
+			}
+			if labelOpts.Face, err = porygion.LoadLabelFont(ttf, *fontSize); err != nil {
+				return err
+			}
+		}
+		opts.Labels = &labelOpts
+		regionMap.CityNames = getLabelNames(regionMap)
+	}
+	if *hillshade {
+		hillshadeOpts := porygion.DefaultHillshadeOptions()
+		opts.Hillshade = &hillshadeOpts
+	}
+	if *coastline {
+		coastlineOpts := porygion.DefaultCoastlineOptions()
+		opts.Coastline = &coastlineOpts
+	}
+	if *casing {
+		casingOpts := porygion.DefaultRouteCasingOptions()
+		opts.RouteCasing = &casingOpts
+	}
+	if *compass {
+		compassOpts := porygion.DefaultCompassOptions()
+		opts.Compass = &compassOpts
+	}
+	if *scaleBar {
+		scaleBarOpts := porygion.DefaultScaleBarOptions()
+		opts.ScaleBar = &scaleBarOpts
+	}
+
+	path := *out
+	if path == "" {
+		name := strings.TrimSuffix(filepath.Base(positional[0]), filepath.Ext(positional[0]))
+		if *theme != "" {
+			name += "-" + *theme
+		}
+		path = name + ".png"
+	}
+	if err := writePNG(path, porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+// getLabelNames returns the names to label the cities with. Cities
+// without names are given the same numbered names as their map sections.
+func getLabelNames(regionMap porygion.RegionMap) map[porygion.Tile]string {
+	names := map[porygion.Tile]string{}
+	sections := regionMap.MapSections()
+	for i, city := range regionMap.Cities {
+		names[city] = sections[i].Name
+	}
+	return names
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/huderlem/porygion"
+)
+
+// percentFlag is a flag for a percentage, which may be written with or
+// without a trailing percent sign.
+type percentFlag float64
+
+func (p *percentFlag) String() string {
+	return strconv.FormatFloat(float64(*p), 'g', -1, 64) + "%"
+}
+
+func (p *percentFlag) Set(s string) error {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return fmt.Errorf("Invalid percentage %q", s)
+	}
+	*p = percentFlag(v)
+	return nil
+}
+
+// runSearch generates the region maps for a range of seeds, with a pool
+// of workers, and prints the seeds whose maps meet all of the constraints.
+func runSearch(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	seedRanges := fs.String("seeds", "1-1000", "seeds to search, like 1-500 or 1,5,10-20")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of maps to generate at once")
+	var minLand, maxLand percentFlag
+	fs.Var(&minLand, "min-land", "minimum percentage of land, like 35%")
+	fs.Var(&maxLand, "max-land", "maximum percentage of land, like 60%")
+	minIslands := fs.Int("min-islands", 0, "minimum number of islands")
+	maxIslands := fs.Int("max-islands", 0, "maximum number of islands")
+	minCities := fs.Int("min-cities", 0, "minimum number of cities that were placed")
+	maxRouteLength := fs.Int("max-route-length", 0, "maximum length, in tiles, of any route between two cities")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if isFlagSet(fs, "seed") {
+		return fmt.Errorf("Use -seeds instead of -seed")
+	}
+	seeds, err := parseSeedRanges(*seedRanges)
+	if err != nil {
+		return err
+	}
+	if *workers < 1 {
+		return fmt.Errorf("Invalid number of workers %d", *workers)
+	}
+	constraints := porygion.SearchConstraints{
+		MinLandPercentage: float64(minLand),
+		MaxLandPercentage: float64(maxLand),
+		MinIslands:        *minIslands,
+		MaxIslands:        *maxIslands,
+		MinCities:         *minCities,
+		MaxRouteLength:    *maxRouteLength,
+	}
+	if err := constraints.Validate(); err != nil {
+		return err
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Searching %d seeds for %s\n", len(seeds), constraints)
+	matches := searchSeeds(gen, config, seeds, *workers, constraints)
+	for i, seed := range seeds {
+		if matches[i] {
+			fmt.Println(seed)
+		}
+	}
+	return nil
+}
+
+// searchSeeds reports, for each seed, whether its region map meets the
+// constraints. Seeds that fail to generate don't match.
+func searchSeeds(gen *generationFlags, config porygion.Config, seeds []int64, workers int, constraints porygion.SearchConstraints) []bool {
+	matches := make([]bool, len(seeds))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := config
+				c.Seed = seeds[i]
+				regionMap, err := gen.generate(c)
+				matches[i] = err == nil && constraints.Match(regionMap)
+			}
+		}()
+	}
+	for i := range seeds {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return matches
+}
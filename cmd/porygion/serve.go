@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/huderlem/porygion"
+)
+
+// maxServeDimension is the largest width or height, in pixels, of the maps
+// that the server generates, so that a single request can't tie it up for
+// long.
+const maxServeDimension = 4096
+
+// maxServeScale is the largest scale that the server renders maps at.
+const maxServeScale = 8
+
+// previewTemplate is the live preview page. Changing any field reloads
+// the preview image with the new parameters.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Porygion preview</title>
+<style>
+body { font-family: sans-serif; background: #222; color: #eee; }
+form { display: flex; flex-wrap: wrap; gap: 12px; align-items: end; margin-bottom: 16px; }
+label { display: flex; flex-direction: column; font-size: 12px; }
+input { width: 8em; }
+img { image-rendering: pixelated; }
+</style>
+</head>
+<body>
+<form id="params">
+<label>Seed <input name="seed" type="number" value="{{.Seed}}"></label>
+<label>Width <input name="w" type="number" min="8" max="{{.MaxDimension}}" step="8" value="{{.Width}}"></label>
+<label>Height <input name="h" type="number" min="8" max="{{.MaxDimension}}" step="8" value="{{.Height}}"></label>
+<label>Cities <input name="cities" type="number" min="2" value="{{.Cities}}"></label>
+<label>Scale <input name="scale" type="number" min="1" max="{{.MaxScale}}" value="{{.Scale}}"></label>
+<label>Theme <select name="theme">{{range .Themes}}<option{{if eq . $.Theme}} selected{{end}}>{{.}}</option>{{end}}</select></label>
+<button type="button" id="random">Random seed</button>
+</form>
+<p>Permalink: <a id="permalink"></a></p>
+<p id="status"></p>
+<img id="preview" alt="Region map">
+<script>
+const form = document.getElementById("params");
+const preview = document.getElementById("preview");
+const status = document.getElementById("status");
+const permalink = document.getElementById("permalink");
+function show(query) {
+  status.textContent = "Generating...";
+  preview.src = "map.png?" + query;
+  fetch("permalink?" + query).then(r => r.json()).then(p => {
+    permalink.href = p.url;
+    permalink.textContent = p.url;
+  });
+}
+function update() {
+  show(new URLSearchParams(new FormData(form)));
+}
+preview.onload = () => { status.textContent = ""; };
+preview.onerror = () => { status.textContent = "Failed to generate the map. Check the parameters."; };
+form.addEventListener("change", update);
+document.getElementById("random").onclick = () => {
+  form.seed.value = Math.floor(Math.random() * 2147483647);
+  update();
+};
+const code = {{.Code}};
+if (code) {
+  show(new URLSearchParams({code: code}));
+} else {
+  update();
+}
+</script>
+</body>
+</html>
+`))
+
+// runServe starts a local web server with a live preview page, which
+// regenerates the map whenever its parameters change. The server also
+// serves maps by URL, so that web apps can embed them:
+//
+//	GET /map.png?seed=42&w=1920&h=1080&cities=10&theme=frlg&scale=2
+//	GET /map.json?seed=42&w=1920&h=1080&cities=10
+//
+// Every preview has a permalink, /?code=..., which holds its full config
+// as a config code, so that it can be shared and reproduced exactly. The
+// map endpoints accept the code parameter too, and /permalink returns the
+// permalink for a set of parameters.
+func runServe(fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handlePreviewPage)
+	mux.HandleFunc("/map.png", handleMapPNG)
+	mux.HandleFunc("/map.json", handleMapJSON)
+	mux.HandleFunc("/permalink", handlePermalink)
+	fmt.Printf("Serving the preview at http://%s/\n", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+func handlePreviewPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	config := porygion.NewConfig(porygion.MapParams{
+		Seed:        1,
+		PixelWidth:  defaultWidth,
+		PixelHeight: defaultHeight,
+		NumCities:   defaultCities,
+	})
+	code := r.URL.Query().Get("code")
+	if code != "" {
+		var err error
+		if config, err = getQueryConfig(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if config.Render.Scale < 1 {
+		config.Render.Scale = 1
+	}
+	if config.Theme == "" {
+		config.Theme = "rse"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	previewTemplate.Execute(w, struct {
+		Seed                   int64
+		Width, Height, Cities  int
+		Scale                  int
+		Theme, Code            string
+		MaxDimension, MaxScale int
+		Themes                 []string
+	}{config.Seed, config.PixelWidth, config.PixelHeight, config.NumCities, config.Render.Scale, config.Theme, code,
+		maxServeDimension, maxServeScale, porygion.ThemeNames()})
+}
+
+// handlePermalink returns the permalink of the preview for the map
+// described by the query parameters, along with its config code.
+func handlePermalink(w http.ResponseWriter, r *http.Request) {
+	config, err := getQueryConfig(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	code, err := porygion.EncodeConfigCode(config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(struct {
+		Code string `json:"code"`
+		URL  string `json:"url"`
+	}{code, "/?" + url.Values{"code": {code}}.Encode()})
+}
+
+// handleMapPNG generates the map described by the query parameters, and
+// streams it as a PNG.
+func handleMapPNG(w http.ResponseWriter, r *http.Request) {
+	config, regionMap, ok := serveRegionMap(w, r)
+	if !ok {
+		return
+	}
+	opts, err := config.RenderOptions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, porygion.RenderRegionMap(regionMap, opts))
+}
+
+// handleMapJSON generates the map described by the query parameters, and
+// writes it as JSON.
+func handleMapJSON(w http.ResponseWriter, r *http.Request) {
+	_, regionMap, ok := serveRegionMap(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regionMap)
+}
+
+// serveRegionMap generates the map for a map request, and sets the
+// caching headers of the response. The same parameters always give the
+// same map, so responses can be cached indefinitely, and requests for a
+// map that the client already has are answered without generating it.
+// ok is false when the response has already been written.
+func serveRegionMap(w http.ResponseWriter, r *http.Request) (porygion.Config, porygion.RegionMap, bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return porygion.Config{}, porygion.RegionMap{}, false
+	}
+	config, err := getQueryConfig(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return config, porygion.RegionMap{}, false
+	}
+	etag, err := getConfigETag(r.URL.Path, config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return config, porygion.RegionMap{}, false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return config, porygion.RegionMap{}, false
+	}
+	regionMap, err := config.Generate()
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return config, porygion.RegionMap{}, false
+	}
+	return config, regionMap, true
+}
+
+// getConfigETag returns an entity tag that identifies the response of an
+// endpoint for a config. It includes the generator version, so that
+// cached maps are replaced when the generator changes.
+func getConfigETag(endpoint string, config porygion.Config) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, endpoint)
+	if err := porygion.SaveConfig(h, config); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("\"%x\"", h.Sum(nil)[:16]), nil
+}
+
+// getQueryConfig returns the generation config described by URL query
+// parameters: seed, w, h, cities, theme, and scale. Missing parameters
+// use the same defaults as the generate command, except that the seed
+// defaults to 0, so that the same URL always gives the same map. The
+// code parameter gives the full config as a config code instead.
+func getQueryConfig(q url.Values) (porygion.Config, error) {
+	if code := q.Get("code"); code != "" {
+		config, err := porygion.DecodeConfigCode(code)
+		if err != nil {
+			return config, err
+		}
+		if config.PixelWidth > maxServeDimension || config.PixelHeight > maxServeDimension || config.NumCities > maxServeDimension {
+			return config, fmt.Errorf("Maps larger than %dx%d can't be generated", maxServeDimension, maxServeDimension)
+		}
+		if config.Render.Scale > maxServeScale {
+			return config, fmt.Errorf("Maps can't be rendered at a scale larger than %d", maxServeScale)
+		}
+		return config, nil
+	}
+	config := porygion.NewConfig(porygion.MapParams{
+		PixelWidth:  defaultWidth,
+		PixelHeight: defaultHeight,
+		NumCities:   defaultCities,
+	})
+	if v := q.Get("seed"); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return config, fmt.Errorf("Invalid seed %q", v)
+		}
+		config.Seed = seed
+	}
+	ints := []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"w", &config.PixelWidth, maxServeDimension},
+		{"h", &config.PixelHeight, maxServeDimension},
+		{"cities", &config.NumCities, maxServeDimension},
+		{"scale", &config.Render.Scale, maxServeScale},
+	}
+	for _, p := range ints {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > p.max {
+			return config, fmt.Errorf("Invalid %s %q", p.name, v)
+		}
+		*p.value = n
+	}
+	config.Theme = q.Get("theme")
+	return config, config.Validate()
+}
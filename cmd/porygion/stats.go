@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/huderlem/porygion"
+)
+
+// maxStatsIslands is the number of islands listed individually in the
+// stats table. The rest are summarized.
+const maxStatsIslands = 10
+
+// histogramBuckets is the most buckets that a histogram is divided into.
+const histogramBuckets = 8
+
+// histogramWidth is the length of the longest bar of a histogram.
+const histogramWidth = 30
+
+// runStats prints the statistics of a saved region map, or of a region
+// map generated from the flags, as a table or as JSON.
+func runStats(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	format := fs.String("format", "table", "output format: table or json")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("Unknown format '%s'. Valid formats are: table, json", *format)
+	}
+	var regionMap porygion.RegionMap
+	switch len(paths) {
+	case 0:
+		config, err := gen.getConfig(fs)
+		if err != nil {
+			return err
+		}
+		if regionMap, err = gen.generate(config); err != nil {
+			return err
+		}
+	case 1:
+		if fs.NFlag() > 0 && !(fs.NFlag() == 1 && isFlagSet(fs, "format")) {
+			return fmt.Errorf("Generation flags can't be used with a region map file")
+		}
+		if regionMap, err = loadRegionMap(paths[0]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Expected at most one region map file")
+	}
+
+	if *format == "json" {
+		data, err := porygion.ExportStats(regionMap)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	writeStatsTable(os.Stdout, regionMap.Seed, regionMap.Stats())
+	return nil
+}
+
+// writeStatsTable writes a region map's statistics as a human-readable
+// table.
+func writeStatsTable(w io.Writer, seed int64, stats porygion.RegionMapStats) {
+	fmt.Fprintf(w, "Seed           %d\n", seed)
+	fmt.Fprintf(w, "Size           %dx%d pixels\n", stats.PixelWidth, stats.PixelHeight)
+	fmt.Fprintf(w, "Land           %.1f%%\n", stats.LandPercentage)
+	fmt.Fprintf(w, "Islands        %d\n", stats.IslandCount)
+	land := 0
+	for _, size := range stats.IslandSizes {
+		land += size
+	}
+	for i, size := range stats.IslandSizes {
+		if i == maxStatsIslands {
+			fmt.Fprintf(w, "  ...and %d smaller islands\n", len(stats.IslandSizes)-maxStatsIslands)
+			break
+		}
+		fmt.Fprintf(w, "  #%-11d %d pixels (%.1f%% of land)\n", i+1, size, float64(size)*100/float64(land))
+	}
+	fmt.Fprintf(w, "Cities         %d\n", stats.CityCount)
+	fmt.Fprintf(w, "City spacing   %s\n", summarizeInts(stats.CitySpacings))
+	writeHistogram(w, stats.CitySpacings)
+	fmt.Fprintf(w, "Routes         %d routes, %d tiles\n", len(stats.RouteLengths), stats.RouteLength)
+	fmt.Fprintf(w, "Route length   %s\n", summarizeInts(stats.RouteLengths))
+	writeHistogram(w, stats.RouteLengths)
+
+	biomes := []string{}
+	for biome := range stats.BiomeCoverage {
+		biomes = append(biomes, biome)
+	}
+	sort.Slice(biomes, func(i, j int) bool {
+		if stats.BiomeCoverage[biomes[i]] != stats.BiomeCoverage[biomes[j]] {
+			return stats.BiomeCoverage[biomes[i]] > stats.BiomeCoverage[biomes[j]]
+		}
+		return biomes[i] < biomes[j]
+	})
+	fmt.Fprintf(w, "Biomes\n")
+	for _, biome := range biomes {
+		fmt.Fprintf(w, "  %-12s %.1f%%\n", biome, stats.BiomeCoverage[biome])
+	}
+}
+
+// summarizeInts describes the smallest, median, and largest of some
+// sorted values.
+func summarizeInts(values []int) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("min %d, median %d, max %d", values[0], values[len(values)/2], values[len(values)-1])
+}
+
+// writeHistogram draws a text histogram of some sorted values, with
+// equally-sized buckets.
+func writeHistogram(w io.Writer, values []int) {
+	if len(values) == 0 {
+		return
+	}
+	first, last := values[0], values[len(values)-1]
+	size := (last - first + histogramBuckets) / histogramBuckets
+	counts := make([]int, (last-first)/size+1)
+	most := 0
+	for _, v := range values {
+		i := (v - first) / size
+		counts[i]++
+		if counts[i] > most {
+			most = counts[i]
+		}
+	}
+	for i, count := range counts {
+		start := first + i*size
+		bar := strings.Repeat("#", (count*histogramWidth+most-1)/most)
+		if size == 1 {
+			fmt.Fprintf(w, "  %-12d %-*s %d\n", start, histogramWidth, bar, count)
+		} else {
+			fmt.Fprintf(w, "  %-12s %-*s %d\n", fmt.Sprintf("%d-%d", start, start+size-1), histogramWidth, bar, count)
+		}
+	}
+}
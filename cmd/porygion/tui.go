@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/huderlem/porygion"
+)
+
+// tuiKeys describes the key bindings of the TUI, shown below the map.
+const tuiKeys = "←/→ seed  ↑/↓ seed ± 100  space random  r routes  c cities  h hillshade  l contours  n night  s save  q quit"
+
+// tuiState is the seed and toggled layers being explored in the TUI.
+type tuiState struct {
+	config    porygion.Config
+	routes    bool
+	cities    bool
+	hillshade bool
+	contours  bool
+	night     bool
+	message   string
+}
+
+// runTUI explores seeds interactively in the terminal. The terminal is put
+// into raw mode with stty, so this only works on Unix-like systems.
+func runTUI(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	save := fs.String("save", "seeds.txt", "file to append saved seeds to")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+	restore, err := enterRawMode()
+	if err != nil {
+		return err
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		restore()
+	}()
+
+	state := tuiState{config: config, routes: true, cities: true}
+	key := make([]byte, 8)
+	for {
+		if err := drawTUI(gen, state); err != nil {
+			return err
+		}
+		n, err := os.Stdin.Read(key)
+		if err != nil {
+			return err
+		}
+		state.message = ""
+		switch string(key[:n]) {
+		case "q", "\x03":
+			return nil
+		case "\x1b[C":
+			state.config.Seed++
+		case "\x1b[D":
+			state.config.Seed--
+		case "\x1b[A":
+			state.config.Seed += 100
+		case "\x1b[B":
+			state.config.Seed -= 100
+		case " ":
+			state.config.Seed = rand.Int63()
+		case "r":
+			state.routes = !state.routes
+		case "c":
+			state.cities = !state.cities
+		case "h":
+			state.hillshade = !state.hillshade
+		case "l":
+			state.contours = !state.contours
+		case "n":
+			state.night = !state.night
+		case "s":
+			if err := appendSeed(*save, state.config.Seed); err != nil {
+				state.message = err.Error()
+			} else {
+				state.message = fmt.Sprintf("Saved seed %d to %s", state.config.Seed, *save)
+			}
+		}
+	}
+}
+
+// drawTUI generates the current seed's region map, and redraws the screen.
+func drawTUI(gen *generationFlags, state tuiState) error {
+	regionMap, err := gen.generate(state.config)
+	if err != nil {
+		return err
+	}
+	opts, err := state.config.RenderOptions()
+	if err != nil {
+		return err
+	}
+	if !state.routes {
+		regionMap.Routes = nil
+	}
+	if !state.cities {
+		regionMap.Cities = nil
+	}
+	if state.hillshade {
+		hillshade := porygion.DefaultHillshadeOptions()
+		opts.Hillshade = &hillshade
+	}
+	if state.contours {
+		contours := porygion.DefaultContourOptions()
+		opts.Contours = &contours
+	}
+	opts.Night = opts.Night || state.night
+
+	rows, columns := getTerminalSize()
+	text := porygion.RenderHalfBlocks(regionMap, opts, getTUIColumns(regionMap, rows-3, columns))
+	layers := []string{}
+	for _, layer := range []struct {
+		name string
+		on   bool
+	}{{"routes", state.routes}, {"cities", state.cities}, {"hillshade", state.hillshade}, {"contours", state.contours}, {"night", opts.Night}} {
+		if layer.on {
+			layers = append(layers, layer.name)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	sb.WriteString(strings.Replace(text, "\n", "\r\n", -1))
+	fmt.Fprintf(&sb, "Seed %d    Layers: %s\r\n%s\r\n%s", state.config.Seed, strings.Join(layers, ", "), tuiKeys, state.message)
+	_, err = os.Stdout.WriteString(sb.String())
+	return err
+}
+
+// getTUIColumns returns the number of columns to render the region map
+// with, so that it fits within the given number of terminal rows and
+// columns. Each row of half blocks shows two squares of the map.
+func getTUIColumns(regionMap porygion.RegionMap, rows, columns int) int {
+	if rows < 1 {
+		rows = 1
+	}
+	width, height := regionMap.PixelWidth, regionMap.PixelHeight
+	size := (width + columns - 1) / columns
+	if fit := (height + rows*2 - 1) / (rows * 2); fit > size {
+		size = fit
+	}
+	if size < 1 {
+		size = 1
+	}
+	return (width + size - 1) / size
+}
+
+// enterRawMode puts the terminal into raw mode, so that key presses are
+// read immediately, and returns a function that restores its old state.
+func enterRawMode() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("The TUI requires an interactive terminal: %s", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("Failed to put the terminal into raw mode: %s", err)
+	}
+	return func() { stty(strings.TrimSpace(state)) }, nil
+}
+
+// getTerminalSize returns the number of rows and columns in the terminal,
+// or the size of a classic 80x24 terminal if it can't be determined.
+func getTerminalSize() (int, int) {
+	rows, columns := 24, 80
+	if out, err := stty("size"); err == nil {
+		fmt.Sscan(out, &rows, &columns)
+	}
+	return rows, columns
+}
+
+// stty runs the stty command on the terminal connected to standard input.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// appendSeed appends a seed to the end of a file, on its own line.
+func appendSeed(path string, seed int64) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(file, seed); err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to write %s: %s", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huderlem/porygion"
+)
+
+// runWatch re-generates and re-renders a region map whenever its config
+// file changes, always writing to the same output path, so that an image
+// viewer can refresh it automatically. The file is either a JSON config, or
+// a TOML file of profiles.
+func runWatch(fs *flag.FlagSet, args []string) error {
+	gen := addGenerationFlags(fs)
+	out := fs.String("out", "map.png", "path of the rendered PNG")
+	interval := fs.Duration("interval", 500*time.Millisecond, "how often to check the config file for changes")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 1 {
+		return fmt.Errorf("Expected one config file to watch")
+	}
+	if gen.config != "" || gen.profiles != "" {
+		return fmt.Errorf("Give the config file to watch as an argument, instead of with -config or -profiles")
+	}
+	path := paths[0]
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		gen.profiles = path
+	} else {
+		gen.config = path
+	}
+
+	fmt.Printf("Watching %s. Press Ctrl+C to stop.\n", path)
+	var lastModTime time.Time
+	var lastSize int64 = -1
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		} else if !info.ModTime().Equal(lastModTime) || info.Size() != lastSize {
+			lastModTime, lastSize = info.ModTime(), info.Size()
+			if err := renderWatchedConfig(gen, fs, *out); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", time.Now().Format("15:04:05"), err)
+			}
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// renderWatchedConfig generates and renders the watched config. The image
+// is written to a temporary file first, and then renamed over the output,
+// so that viewers never see a partially-written image.
+func renderWatchedConfig(gen *generationFlags, fs *flag.FlagSet, out string) error {
+	if gen.profiles != "" && gen.profile == "" {
+		name, err := getOnlyProfile(gen.profiles)
+		if err != nil {
+			return err
+		}
+		gen.profile = name
+		defer func() { gen.profile = "" }()
+	}
+	config, err := gen.getConfig(fs)
+	if err != nil {
+		return err
+	}
+	regionMap, err := gen.generate(config)
+	if err != nil {
+		return err
+	}
+	opts, err := config.RenderOptions()
+	if err != nil {
+		return err
+	}
+	tmp := out + ".tmp"
+	if err := writePNG(tmp, porygion.RenderRegionMap(regionMap, opts)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return err
+	}
+	fmt.Printf("%s: Wrote %s (seed %d)\n", time.Now().Format("15:04:05"), out, config.Seed)
+	return nil
+}
+
+// getOnlyProfile returns the name of the only profile in a profiles file,
+// so that -profile can be left out when there's just one.
+func getOnlyProfile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	profiles, err := porygion.LoadProfiles(file)
+	if err != nil {
+		return "", err
+	}
+	names := profiles.Names()
+	if len(names) != 1 {
+		return "", fmt.Errorf("%s has %d profiles. Choose one with -profile: %s", path, len(names), strings.Join(names, ", "))
+	}
+	return names[0], nil
+}
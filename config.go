@@ -0,0 +1,285 @@
+package porygion
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// configVersion is the version of the config file format written by
+// SaveConfig. Version 1 configs used the Go field names as their keys, and
+// are still read.
+const configVersion = 2
+
+// configCodePrefix starts every config code that holds a full config,
+// rather than just a map code.
+const configCodePrefix = "c1."
+
+// maxConfigCodeSize limits the decompressed size of a config code, so that
+// a small code can't expand into an enormous config.
+const maxConfigCodeSize = 1 << 16
+
+// Config is the full configuration used to generate and render a region
+// map, so that a setup can be stored in a project file and reproduced
+// exactly. Functions, fonts, and fog of war tiles can't be stored, and
+// are left out of the saved config. Configs are saved as JSON, with the
+// same snake_case keys as the JSON region map format, except for colors,
+// which keep the R, G, B, and A fields of color.RGBA. Other formats, like
+// YAML, are deliberately not supported, to keep the package free of
+// dependencies.
+type Config struct {
+	// Version is the version of the config file format.
+	Version int `json:"version"`
+	// GeneratorVersion is the version of the generator that the config was
+	// saved with. A config saved with a different generator would not
+	// reproduce the same map, so loading it fails.
+	GeneratorVersion int          `json:"generator_version"`
+	Seed             int64        `json:"seed"`
+	PixelWidth       int          `json:"pixel_width"`
+	PixelHeight      int          `json:"pixel_height"`
+	NumCities        int          `json:"num_cities"`
+	Routes           RouteOptions `json:"routes"`
+	// ElevationWorkers is the number of goroutines that generate the
+	// elevations. It doesn't change the map. When it is 0, there is one
+	// per CPU.
+	ElevationWorkers int `json:"elevation_workers,omitempty"`
+	// Theme is the name of a built-in palette theme to render with. It is
+	// ignored when Render has its own Palette.
+	Theme  string        `json:"theme,omitempty"`
+	Render RenderOptions `json:"render"`
+}
+
+// NewConfig returns a config that generates a region map with the given
+// parameters, using the default route and render options.
+func NewConfig(p MapParams) Config {
+	return Config{
+		Version:          configVersion,
+		GeneratorVersion: generatorVersion,
+		Seed:             p.Seed,
+		PixelWidth:       p.PixelWidth,
+		PixelHeight:      p.PixelHeight,
+		NumCities:        p.NumCities,
+	}
+}
+
+// Params returns the basic generation parameters of the config.
+func (c Config) Params() MapParams {
+	return MapParams{
+		Seed:        c.Seed,
+		PixelWidth:  c.PixelWidth,
+		PixelHeight: c.PixelHeight,
+		NumCities:   c.NumCities,
+	}
+}
+
+// Generate generates the region map described by the config.
+func (c Config) Generate() (RegionMap, error) {
+	return generateRegionMap(c.Seed, c.PixelWidth, c.PixelHeight, c.NumCities, c.Routes, c.ElevationWorkers, nil, nil)
+}
+
+// RenderOptions returns the config's render options, with the palette
+// filled in from the config's theme.
+func (c Config) RenderOptions() (RenderOptions, error) {
+	opts := c.Render
+	if opts.Palette == nil && c.Theme != "" {
+		palette, err := ThemePalette(c.Theme)
+		if err != nil {
+			return RenderOptions{}, err
+		}
+		opts.Palette = &palette
+	}
+	return opts, nil
+}
+
+// Validate checks that the config can be used to generate a region map.
+func (c Config) Validate() error {
+	if c.Version < 1 || c.Version > configVersion {
+		return fmt.Errorf("Unsupported config version %d", c.Version)
+	}
+	if c.GeneratorVersion != generatorVersion {
+		return fmt.Errorf("Config was saved with generator version %d, but this is version %d", c.GeneratorVersion, generatorVersion)
+	}
+	if c.PixelWidth < 8 || c.PixelHeight < 8 || c.PixelWidth > maxBinaryDimension || c.PixelHeight > maxBinaryDimension {
+		return fmt.Errorf("Invalid map size %dx%d", c.PixelWidth, c.PixelHeight)
+	}
+	if c.NumCities < 0 {
+		return fmt.Errorf("Invalid number of cities %d", c.NumCities)
+	}
+	if pair := c.Routes.VictoryRoad; pair != nil {
+		// The cities aren't known until the map is generated, so only
+		// check that both ends could be cities of a map this size.
+		tilesWidth, tilesHeight := c.PixelWidth/8, c.PixelHeight/8
+		for _, t := range []Tile{pair.A, pair.B} {
+			if t.X < 0 || t.Y < 0 || t.X >= tilesWidth || t.Y >= tilesHeight {
+				return fmt.Errorf("Invalid victory road: %v is outside the %dx%d tile map", t, tilesWidth, tilesHeight)
+			}
+		}
+		if pair.A == pair.B {
+			return fmt.Errorf("Invalid victory road: both ends are city %v", pair.A)
+		}
+	}
+	if c.ElevationWorkers < 0 {
+		return fmt.Errorf("Invalid number of elevation workers %d", c.ElevationWorkers)
+	}
+	if c.Theme != "" {
+		if _, err := ThemePalette(c.Theme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads a config in the JSON format written by SaveConfig, and
+// validates it. Unknown fields are rejected, to catch typos. Version 1
+// configs are migrated to the current version.
+func LoadConfig(r io.Reader) (Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Config{}, fmt.Errorf("Failed to read config: %s", err)
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Config{}, fmt.Errorf("Failed to read config: %s", err)
+	}
+	if header.Version == 1 {
+		if data, err = migrateConfigV1(data); err != nil {
+			return Config{}, fmt.Errorf("Failed to read config: %s", err)
+		}
+	}
+	var c Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("Failed to read config: %s", err)
+	}
+	if header.Version == 1 {
+		c.Version = configVersion
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// migrateConfigV1 converts the keys of a version 1 config, which are Go
+// field names like PixelWidth, into the snake_case keys of the current
+// version, like pixel_width.
+func migrateConfigV1(data []byte) ([]byte, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are kept as they were written, so that seeds don't lose
+	// precision as float64s.
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(snakeCaseKeys(v))
+}
+
+// snakeCaseKeys converts the keys of every JSON object in v to snake_case.
+func snakeCaseKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			var b strings.Builder
+			for i, c := range key {
+				if c >= 'A' && c <= 'Z' {
+					if i > 0 {
+						b.WriteByte('_')
+					}
+					c += 'a' - 'A'
+				}
+				b.WriteRune(c)
+			}
+			converted[b.String()] = snakeCaseKeys(value)
+		}
+		return converted
+	case []interface{}:
+		for i := range v {
+			v[i] = snakeCaseKeys(v[i])
+		}
+	}
+	return v
+}
+
+// SaveConfig writes a config as indented JSON.
+func SaveConfig(w io.Writer, c Config) error {
+	if c.Version == 0 {
+		c.Version = configVersion
+	}
+	if c.GeneratorVersion == 0 {
+		c.GeneratorVersion = generatorVersion
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode config: %s", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to write config: %s", err)
+	}
+	return nil
+}
+
+// EncodeConfigCode encodes a config into a code that can be shared, or put
+// in a URL, and decoded back into the same config. Configs that only set
+// the map parameters are encoded as a short map code, like EncodeMapCode.
+// Other configs are encoded in full, as compressed JSON, which is longer.
+func EncodeConfigCode(c Config) (string, error) {
+	var full, simple bytes.Buffer
+	if err := SaveConfig(&full, c); err != nil {
+		return "", err
+	}
+	if err := SaveConfig(&simple, NewConfig(c.Params())); err != nil {
+		return "", err
+	}
+	if bytes.Equal(full.Bytes(), simple.Bytes()) {
+		return EncodeMapCode(c.Params()), nil
+	}
+
+	var compact, compressed bytes.Buffer
+	if err := json.Compact(&compact, full.Bytes()); err != nil {
+		return "", fmt.Errorf("Failed to encode config: %s", err)
+	}
+	w, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	w.Write(compact.Bytes())
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("Failed to compress config: %s", err)
+	}
+	return configCodePrefix + base64.RawURLEncoding.EncodeToString(compressed.Bytes()), nil
+}
+
+// DecodeConfigCode decodes a config from a code created by
+// EncodeConfigCode. It also accepts map codes.
+func DecodeConfigCode(code string) (Config, error) {
+	code = strings.TrimSpace(code)
+	if !strings.HasPrefix(code, configCodePrefix) {
+		p, err := DecodeMapCode(code)
+		if err != nil {
+			return Config{}, err
+		}
+		return NewConfig(p), nil
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(code, configCodePrefix))
+	if err != nil {
+		return Config{}, fmt.Errorf("Invalid config code: %s", err)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), maxConfigCodeSize+1))
+	if err != nil {
+		return Config{}, fmt.Errorf("Invalid config code: %s", err)
+	}
+	if len(data) > maxConfigCodeSize {
+		return Config{}, fmt.Errorf("Invalid config code: the config is larger than %d bytes", maxConfigCodeSize)
+	}
+	return LoadConfig(bytes.NewReader(data))
+}
@@ -0,0 +1,207 @@
+package porygion
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testConfigs returns a config with only map parameters, and one that
+// sets route and render options too.
+func testConfigs() (Config, Config) {
+	simple := NewConfig(MapParams{Seed: 5, PixelWidth: 96, PixelHeight: 64, NumCities: 6})
+	full := simple
+	costs := DefaultRouteCosts()
+	hillshade := DefaultHillshadeOptions()
+	casing := DefaultRouteCasingOptions()
+	palette, _ := ThemePalette("hgss")
+	full.Routes = RouteOptions{Costs: &costs, FollowCoasts: true}
+	full.Theme = "frlg"
+	full.ElevationWorkers = 2
+	full.Render = RenderOptions{
+		Scale:       2,
+		Palette:     &palette,
+		Hillshade:   &hillshade,
+		RouteCasing: &casing,
+		OceanDepth:  true,
+	}
+	return simple, full
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	simple, full := testConfigs()
+	for _, config := range []Config{simple, full} {
+		var buf bytes.Buffer
+		if err := SaveConfig(&buf, config); err != nil {
+			t.Fatalf("SaveConfig: %s", err)
+		}
+		loaded, err := LoadConfig(&buf)
+		if err != nil {
+			t.Fatalf("LoadConfig: %s", err)
+		}
+		if !reflect.DeepEqual(loaded, config) {
+			t.Errorf("LoadConfig = %+v, want %+v", loaded, config)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	simple, _ := testConfigs()
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"missing version", func(c *Config) { c.Version = 0 }, "Unsupported config version 0"},
+		{"future version", func(c *Config) { c.Version = configVersion + 1 }, "Unsupported config version"},
+		{"other generator", func(c *Config) { c.GeneratorVersion++ }, "generator version"},
+		{"too narrow", func(c *Config) { c.PixelWidth = 7 }, "Invalid map size 7x64"},
+		{"too tall", func(c *Config) { c.PixelHeight = maxBinaryDimension + 1 }, "Invalid map size"},
+		{"negative cities", func(c *Config) { c.NumCities = -1 }, "Invalid number of cities"},
+		{"negative workers", func(c *Config) { c.ElevationWorkers = -1 }, "Invalid number of elevation workers"},
+		{"unknown theme", func(c *Config) { c.Theme = "sinnoh" }, "sinnoh"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := simple
+			test.change(&c)
+			err := c.Validate()
+			if test.want == "" {
+				if err != nil {
+					t.Errorf("Validate: %s", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Validate error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSaveConfigKeys(t *testing.T) {
+	_, full := testConfigs()
+	full.Routes.VictoryRoad = &CityPair{Tile{1, 3}, Tile{5, 7}}
+	var buf bytes.Buffer
+	if err := SaveConfig(&buf, full); err != nil {
+		t.Fatalf("SaveConfig: %s", err)
+	}
+	for _, key := range []string{`"pixel_width"`, `"num_cities"`, `"follow_coasts"`, `"victory_road"`, `"step_cost"`, `"route_casing"`, `"deep_water"`, `"x": 1`} {
+		if !strings.Contains(buf.String(), key) {
+			t.Errorf("Saved config doesn't contain %s:\n%s", key, buf.String())
+		}
+	}
+}
+
+func TestLoadConfigV1(t *testing.T) {
+	data := `{
+  "Version": 1,
+  "GeneratorVersion": 1,
+  "Seed": 4611686018427387905,
+  "PixelWidth": 96,
+  "PixelHeight": 64,
+  "NumCities": 6,
+  "Routes": {"Costs": null, "FollowCoasts": true, "VictoryRoad": {"A": {"X": 1, "Y": 3}, "B": {"X": 5, "Y": 7}}},
+  "Theme": "frlg",
+  "Render": {"Scale": 2, "TileGrid": {"Color": {"R": 1, "G": 2, "B": 3, "A": 255}}, "OceanDepth": true}
+}`
+	c, err := LoadConfig(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadConfig: %s", err)
+	}
+	want := NewConfig(MapParams{Seed: 1<<62 + 1, PixelWidth: 96, PixelHeight: 64, NumCities: 6})
+	want.Routes = RouteOptions{FollowCoasts: true, VictoryRoad: &CityPair{Tile{1, 3}, Tile{5, 7}}}
+	want.Theme = "frlg"
+	want.Render = RenderOptions{
+		Scale:      2,
+		TileGrid:   &TileGridOptions{Color: color.RGBA{1, 2, 3, 255}},
+		OceanDepth: true,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("LoadConfig = %+v, want %+v", c, want)
+	}
+}
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	for _, data := range []string{
+		`{"version": 2, "generator_version": 1, "pixel_width": 96, "pixel_height": 64, "num_citys": 4}`,
+		`{"Version": 1, "GeneratorVersion": 1, "PixelWidth": 96, "PixelHeight": 64, "NumCitys": 4}`,
+	} {
+		if _, err := LoadConfig(strings.NewReader(data)); err == nil || !strings.Contains(err.Error(), "num_citys") {
+			t.Errorf("LoadConfig error = %v, want an unknown field error", err)
+		}
+	}
+}
+
+func TestConfigCodeRoundTrip(t *testing.T) {
+	simple, full := testConfigs()
+	tests := []struct {
+		name    string
+		config  Config
+		mapCode bool
+	}{
+		{"map parameters only", simple, true},
+		{"with options", full, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := EncodeConfigCode(test.config)
+			if err != nil {
+				t.Fatalf("EncodeConfigCode: %s", err)
+			}
+			if test.mapCode && code != EncodeMapCode(test.config.Params()) {
+				t.Errorf("EncodeConfigCode = %q, want the map code %q", code, EncodeMapCode(test.config.Params()))
+			}
+			if !test.mapCode && !strings.HasPrefix(code, configCodePrefix) {
+				t.Errorf("EncodeConfigCode = %q, want a full config code", code)
+			}
+			decoded, err := DecodeConfigCode(code)
+			if err != nil {
+				t.Fatalf("DecodeConfigCode: %s", err)
+			}
+			if !reflect.DeepEqual(decoded, test.config) {
+				t.Errorf("DecodeConfigCode = %+v, want %+v", decoded, test.config)
+			}
+
+			want, err := test.config.Generate()
+			if err != nil {
+				t.Fatalf("Generate: %s", err)
+			}
+			got, err := decoded.Generate()
+			if err != nil {
+				t.Fatalf("Generate: %s", err)
+			}
+			if got.Fingerprint() != want.Fingerprint() {
+				t.Errorf("decoded config generates a different map")
+			}
+		})
+	}
+}
+
+func TestDecodeConfigCodeErrors(t *testing.T) {
+	// A huge config compresses into a short code, which must not be
+	// expanded without limit.
+	huge := NewConfig(MapParams{Seed: 1, PixelWidth: 64, PixelHeight: 64})
+	huge.Theme = strings.Repeat("x", maxConfigCodeSize)
+	hugeCode, err := EncodeConfigCode(huge)
+	if err != nil {
+		t.Fatalf("EncodeConfigCode: %s", err)
+	}
+	tests := []struct {
+		name, code, want string
+	}{
+		{"not base64", configCodePrefix + "!!!", "Invalid config code"},
+		{"not compressed", configCodePrefix + "____", "Invalid config code"},
+		{"too large", hugeCode, "larger than"},
+		{"invalid map code", "AAAA-AAAA", "Invalid map code"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeConfigCode(test.code)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("DecodeConfigCode error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
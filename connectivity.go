@@ -0,0 +1,56 @@
+package porygion
+
+// ConnectivityReport describes how the cities in a region map are
+// linked together by its route network.
+type ConnectivityReport struct {
+	// Connected is true when every city can reach every other city.
+	Connected bool
+	// Components lists the groups of cities that can reach each other.
+	// A fully-connected region map has exactly one component.
+	Components [][]Tile
+}
+
+// ValidateConnectivity walks the route network and reports whether every
+// city is reachable from every other city.
+func (r RegionMap) ValidateConnectivity() ConnectivityReport {
+	network := map[Tile]bool{}
+	for _, t := range r.Routes {
+		network[t] = true
+	}
+	isCity := map[Tile]bool{}
+	for _, c := range r.Cities {
+		network[c] = true
+		isCity[c] = true
+	}
+
+	// Flood-fill outward from each unvisited city, collecting every
+	// other city encountered along the way.
+	visited := map[Tile]bool{}
+	components := [][]Tile{}
+	for _, start := range r.Cities {
+		if visited[start] {
+			continue
+		}
+		component := []Tile{}
+		queue := []Tile{start}
+		visited[start] = true
+		for len(queue) > 0 {
+			t := queue[0]
+			queue = queue[1:]
+			if isCity[t] {
+				component = append(component, t)
+			}
+			for _, n := range t.neighbors() {
+				if network[n] && !visited[n] {
+					visited[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	return ConnectivityReport{
+		Connected:  len(components) <= 1,
+		Components: components,
+	}
+}
@@ -0,0 +1,22 @@
+package porygion
+
+import "testing"
+
+func TestValidateConnectivity(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	report := regionMap.ValidateConnectivity()
+	if !report.Connected || len(report.Components) != 1 || len(report.Components[0]) != len(regionMap.Cities) {
+		t.Errorf("ValidateConnectivity() = %+v, want one component of %d cities", report, len(regionMap.Cities))
+	}
+
+	// Cities are never adjacent, so without routes each city is on its
+	// own.
+	regionMap.Routes = nil
+	report = regionMap.ValidateConnectivity()
+	if report.Connected || len(report.Components) != len(regionMap.Cities) {
+		t.Errorf("ValidateConnectivity() without routes = %+v, want %d components", report, len(regionMap.Cities))
+	}
+}
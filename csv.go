@@ -0,0 +1,55 @@
+package porygion
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteCitiesCSV writes the region map's cities as CSV, with a header row
+// followed by one row per city: its tile position, name, kind, and the
+// average elevation of its tile.
+func WriteCitiesCSV(w io.Writer, regionMap RegionMap) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"x", "y", "name", "kind", "elevation"})
+	for _, city := range regionMap.Cities {
+		elevation := getTileElevation(regionMap.Elevations, city)
+		cw.Write([]string{
+			strconv.Itoa(city.X),
+			strconv.Itoa(city.Y),
+			regionMap.CityNames[city],
+			regionMap.CityKinds[city].String(),
+			strconv.FormatFloat(elevation, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("Failed to write cities CSV: %s", err)
+	}
+	return nil
+}
+
+// WriteRoutesCSV writes the region map's route connections as CSV, with a
+// header row followed by one row per connection: the tile positions of its
+// two cities, its length, its class, and whether it is Victory Road.
+func WriteRoutesCSV(w io.Writer, regionMap RegionMap) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"city_a_x", "city_a_y", "city_b_x", "city_b_y", "length", "class", "victory_road"})
+	for _, c := range regionMap.Connections {
+		cw.Write([]string{
+			strconv.Itoa(c.CityA.X),
+			strconv.Itoa(c.CityA.Y),
+			strconv.Itoa(c.CityB.X),
+			strconv.Itoa(c.CityB.Y),
+			strconv.Itoa(c.Length()),
+			c.Class.String(),
+			strconv.FormatBool(c.VictoryRoad),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("Failed to write routes CSV: %s", err)
+	}
+	return nil
+}
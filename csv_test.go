@@ -0,0 +1,53 @@
+package porygion
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	a, b := Tile{1, 3}, Tile{5, 3}
+	regionMap := RegionMap{
+		PixelWidth:  48,
+		PixelHeight: 48,
+		Elevations:  getNewElevationMap(48, 48),
+		Cities:      []Tile{a, b},
+		CityNames:   map[Tile]string{a: "Pallet, Town"},
+		CityKinds:   map[Tile]CityKind{b: CityPort},
+		Connections: []RouteConnection{
+			{CityA: a, CityB: b, Tiles: []Tile{{2, 3}, {3, 3}, {4, 3}}, Class: RouteSpur, VictoryRoad: true},
+		},
+	}
+	for _, column := range regionMap.Elevations {
+		for y := range column {
+			column[y] = 0.25
+		}
+	}
+	tests := []struct {
+		name  string
+		write func(buf *bytes.Buffer) error
+		want  string
+	}{
+		{
+			"cities",
+			func(buf *bytes.Buffer) error { return WriteCitiesCSV(buf, regionMap) },
+			"x,y,name,kind,elevation\n1,3,\"Pallet, Town\",town,0.2500\n5,3,,port,0.2500\n",
+		},
+		{
+			"routes",
+			func(buf *bytes.Buffer) error { return WriteRoutesCSV(buf, regionMap) },
+			"city_a_x,city_a_y,city_b_x,city_b_y,length,class,victory_road\n1,3,5,3,4,spur,true\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.write(&buf); err != nil {
+				t.Fatalf("write: %s", err)
+			}
+			if buf.String() != test.want {
+				t.Errorf("wrote %q, want %q", buf.String(), test.want)
+			}
+		})
+	}
+}
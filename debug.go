@@ -0,0 +1,95 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// DebugInfo records the internal state of the generation steps, which is
+// useful when tuning the city placement parameters.
+type DebugInfo struct {
+	// BaseElevations are the elevations of the base noise layer alone,
+	// before the finer detail layers are added to it.
+	BaseElevations [][]float64
+	// PartitionSize is the width and height, in tiles, of the partitions
+	// that cities are spread across.
+	PartitionSize int
+	// ValidTiles are the tiles with enough land to hold a city.
+	ValidTiles []Tile
+	// Clusters are the groups of cities found by k-means clustering.
+	Clusters [][]Tile
+	// RejectedCandidates are the tiles that were considered for a city, but
+	// failed the placement constraints.
+	RejectedCandidates []Tile
+}
+
+// Colors for the debug overlays.
+var (
+	colorDebugValidTile = color.RGBA{255, 255, 255, 96}
+	colorDebugPartition = color.RGBA{255, 0, 255, 255}
+	colorDebugRejected  = color.RGBA{96, 0, 0, 255}
+	colorDebugClusters  = []color.RGBA{
+		{255, 0, 0, 255},
+		{0, 64, 255, 255},
+		{255, 0, 255, 255},
+		{0, 224, 224, 255},
+	}
+)
+
+// GenerateRegionMapDebug generates a new complete region map, exactly like
+// GenerateRegionMap, and also returns the internal state of each generation
+// step.
+func GenerateRegionMapDebug(seed int64, pixelWidth, pixelHeight int, numCities int) (RegionMap, DebugInfo, error) {
+	var debug DebugInfo
+	regionMap, err := generateRegionMap(seed, pixelWidth, pixelHeight, numCities, RouteOptions{}, 0, &debug, nil)
+	return regionMap, debug, err
+}
+
+// RenderDebugRegionMap renders a full region map with overlays showing the
+// generation internals: the partition grid, the valid landmark tiles, the
+// rejected city candidates, and the cluster that each city belongs to.
+func RenderDebugRegionMap(regionMap RegionMap, debug DebugInfo) image.Image {
+	palette := DefaultPalette()
+	img := newRegionMapImage(regionMap)
+	drawTerrain(img, regionMap, palette, RenderOptions{})
+	for _, t := range debug.ValidTiles {
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				x := t.X*8 + i
+				y := t.Y*8 + j
+				img.SetRGBA(x, y, blendColor(img.RGBAAt(x, y), colorDebugValidTile))
+			}
+		}
+	}
+	drawRoutes(img, regionMap, palette, RenderOptions{})
+
+	// Rejected candidates are marked with an X.
+	for _, t := range debug.RejectedCandidates {
+		for i := 1; i < 7; i++ {
+			img.SetRGBA(t.X*8+i, t.Y*8+i, colorDebugRejected)
+			img.SetRGBA(t.X*8+7-i, t.Y*8+i, colorDebugRejected)
+		}
+	}
+
+	if debug.PartitionSize > 0 {
+		partitionPixels := debug.PartitionSize * 8
+		bounds := img.Bounds()
+		for x := 0; x < bounds.Dx(); x++ {
+			for y := 0; y < bounds.Dy(); y++ {
+				if x%partitionPixels == 0 || y%partitionPixels == 0 || x == bounds.Dx()-1 || y == bounds.Dy()-1 {
+					img.SetRGBA(x, y, colorDebugPartition)
+				}
+			}
+		}
+	}
+
+	// Cities are colored by cluster.
+	clusterPalette := palette
+	for i, cluster := range debug.Clusters {
+		clusterPalette.City = colorDebugClusters[i%len(colorDebugClusters)]
+		for _, city := range cluster {
+			drawCityMarker(img, city, CityTown, clusterPalette)
+		}
+	}
+	return img
+}
@@ -0,0 +1,210 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Corner is a corner of the rendered image, where a decoration is placed.
+type Corner int
+
+// Corners of the rendered image.
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// CompassOptions controls the compass rose decoration.
+type CompassOptions struct {
+	Corner  Corner     `json:"corner"`
+	Color   color.RGBA `json:"color"`
+	Outline color.RGBA `json:"outline"`
+}
+
+// DefaultCompassOptions returns a white compass rose in the top-right
+// corner of the map.
+func DefaultCompassOptions() CompassOptions {
+	return CompassOptions{
+		Corner:  TopRight,
+		Color:   color.RGBA{248, 248, 248, 255},
+		Outline: color.RGBA{40, 40, 40, 255},
+	}
+}
+
+// ScaleBarOptions controls the scale bar decoration, which shows the size
+// of a number of map tiles.
+type ScaleBarOptions struct {
+	Corner Corner `json:"corner"`
+	// Tiles is the number of tiles the bar spans. Each tile is drawn as an
+	// alternating segment of the bar.
+	Tiles   int        `json:"tiles"`
+	Color   color.RGBA `json:"color"`
+	Outline color.RGBA `json:"outline"`
+}
+
+// DefaultScaleBarOptions returns a five-tile scale bar in the bottom-left
+// corner of the map.
+func DefaultScaleBarOptions() ScaleBarOptions {
+	return ScaleBarOptions{
+		Corner:  BottomLeft,
+		Tiles:   5,
+		Color:   color.RGBA{248, 248, 248, 255},
+		Outline: color.RGBA{40, 40, 40, 255},
+	}
+}
+
+// decorationPlacer positions decorations in the corners of an image.
+// Decorations that share a corner are stacked, rather than drawn over
+// each other.
+type decorationPlacer struct {
+	bounds image.Rectangle
+	margin int
+	used   map[Corner]int
+}
+
+func newDecorationPlacer(bounds image.Rectangle, scale int) *decorationPlacer {
+	return &decorationPlacer{
+		bounds: bounds,
+		margin: 4 * scale,
+		used:   map[Corner]int{},
+	}
+}
+
+// place returns the top-left position for a decoration of the given size.
+func (p *decorationPlacer) place(corner Corner, size image.Point) image.Point {
+	offset := p.used[corner]
+	p.used[corner] += size.Y + p.margin
+	x := p.bounds.Min.X + p.margin
+	if corner == TopRight || corner == BottomRight {
+		x = p.bounds.Max.X - p.margin - size.X
+	}
+	y := p.bounds.Min.Y + p.margin + offset
+	if corner == BottomLeft || corner == BottomRight {
+		y = p.bounds.Max.Y - p.margin - offset - size.Y
+	}
+	return image.Point{x, y}
+}
+
+// drawDecorations draws the compass rose and scale bar onto a rendered map
+// that was enlarged by the given scale.
+func drawDecorations(img *image.RGBA, opts RenderOptions, scale int) {
+	if scale < 1 {
+		scale = 1
+	}
+	placer := newDecorationPlacer(img.Bounds(), scale)
+	if opts.Compass != nil {
+		drawCompass(img, *opts.Compass, scale, placer)
+	}
+	if opts.ScaleBar != nil {
+		drawScaleBar(img, *opts.ScaleBar, scale, placer)
+	}
+}
+
+// drawCompass draws a four-pointed star, with an "N" above its northern
+// point.
+func drawCompass(img *image.RGBA, opts CompassOptions, scale int, placer *decorationPlacer) {
+	face := basicfont.Face7x13
+	ascent := face.Metrics().Ascent.Ceil()
+	textHeight := ascent + face.Metrics().Descent.Ceil()
+	radius := 6 * scale
+	starSize := 2*radius + 1
+	size := image.Point{maxInt(starSize, 7), textHeight + starSize}
+	origin := placer.place(opts.Corner, size)
+
+	inStar := func(dx, dy int) bool {
+		major, minor := absInt(dx), absInt(dy)
+		if minor > major {
+			major, minor = minor, major
+		}
+		return major <= radius && minor*3 <= radius-major
+	}
+	centerX := origin.X + size.X/2
+	centerY := origin.Y + textHeight + radius
+	for dx := -radius - 1; dx <= radius+1; dx++ {
+		for dy := -radius - 1; dy <= radius+1; dy++ {
+			p := image.Point{centerX + dx, centerY + dy}
+			if !p.In(img.Bounds()) {
+				continue
+			}
+			if inStar(dx, dy) {
+				img.SetRGBA(p.X, p.Y, opts.Color)
+			} else if inStar(dx-1, dy) || inStar(dx+1, dy) || inStar(dx, dy-1) || inStar(dx, dy+1) {
+				img.SetRGBA(p.X, p.Y, opts.Outline)
+			}
+		}
+	}
+	drawOutlinedText(img, "N", image.Point{centerX - 3, origin.Y + ascent}, opts.Color, opts.Outline)
+}
+
+// drawScaleBar draws a bar of alternating segments, one per tile, with the
+// number of tiles it spans beside it.
+func drawScaleBar(img *image.RGBA, opts ScaleBarOptions, scale int, placer *decorationPlacer) {
+	tiles := opts.Tiles
+	if tiles < 1 {
+		tiles = 1
+	}
+	label := fmt.Sprintf("%d tiles", tiles)
+	if tiles == 1 {
+		label = "1 tile"
+	}
+	face := basicfont.Face7x13
+	ascent := face.Metrics().Ascent.Ceil()
+	textHeight := ascent + face.Metrics().Descent.Ceil()
+	textWidth := font.MeasureString(face, label).Ceil()
+	tileSize := 8 * scale
+	barWidth := tiles * tileSize
+	barHeight := 2*scale + 2
+	gap := 2 * scale
+	size := image.Point{barWidth + 2 + gap + textWidth, maxInt(barHeight, textHeight)}
+	origin := placer.place(opts.Corner, size)
+
+	barTop := origin.Y + (size.Y-barHeight)/2
+	for x := 0; x < barWidth+2; x++ {
+		for y := 0; y < barHeight; y++ {
+			p := image.Point{origin.X + x, barTop + y}
+			if !p.In(img.Bounds()) {
+				continue
+			}
+			c := opts.Outline
+			onBorder := x == 0 || x == barWidth+1 || y == 0 || y == barHeight-1
+			if !onBorder && ((x-1)/tileSize)%2 == 0 {
+				c = opts.Color
+			}
+			img.SetRGBA(p.X, p.Y, c)
+		}
+	}
+	textTop := origin.Y + (size.Y-textHeight)/2
+	drawOutlinedText(img, label, image.Point{origin.X + barWidth + 2 + gap, textTop + ascent}, opts.Color, opts.Outline)
+}
+
+// drawOutlinedText draws text in the built-in font, with an outline around
+// it so that it stays legible over any terrain. dot is the position of the
+// text's baseline.
+func drawOutlinedText(img *image.RGBA, text string, dot image.Point, c, outline color.RGBA) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(outline),
+		Face: basicfont.Face7x13,
+	}
+	for _, d := range [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		drawer.Dot = fixed.P(dot.X+d.X, dot.Y+d.Y)
+		drawer.DrawString(text)
+	}
+	drawer.Src = image.NewUniform(c)
+	drawer.Dot = fixed.P(dot.X, dot.Y)
+	drawer.DrawString(text)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// RegionMapDiff is the structural difference between two region maps of
+// the same size.
+type RegionMapDiff struct {
+	// ChangedElevations are the pixels whose elevations differ by more
+	// than the tolerance.
+	ChangedElevations []image.Point
+	AddedCities       []Tile
+	RemovedCities     []Tile
+	AddedRoutes       []Tile
+	RemovedRoutes     []Tile
+}
+
+// Empty reports whether the diff found no differences.
+func (d RegionMapDiff) Empty() bool {
+	return len(d.ChangedElevations) == 0 &&
+		len(d.AddedCities) == 0 && len(d.RemovedCities) == 0 &&
+		len(d.AddedRoutes) == 0 && len(d.RemovedRoutes) == 0
+}
+
+// Colors used to draw a region map diff.
+var (
+	diffElevationColor = color.RGBA{255, 200, 0, 255}
+	diffAddedColor     = color.RGBA{0, 200, 0, 255}
+	diffRemovedColor   = color.RGBA{220, 0, 0, 255}
+)
+
+// DiffRegionMaps compares region map b against region map a. Elevations
+// that differ by no more than tolerance are considered unchanged. Cities
+// and route tiles are compared as sets, so their order doesn't matter.
+func DiffRegionMaps(a, b RegionMap, tolerance float64) (RegionMapDiff, error) {
+	if a.PixelWidth != b.PixelWidth || a.PixelHeight != b.PixelHeight {
+		return RegionMapDiff{}, fmt.Errorf("Region map sizes differ: %dx%d and %dx%d", a.PixelWidth, a.PixelHeight, b.PixelWidth, b.PixelHeight)
+	}
+	if err := validateElevations(a.Elevations, a.PixelWidth, a.PixelHeight); err != nil {
+		return RegionMapDiff{}, err
+	}
+	if err := validateElevations(b.Elevations, b.PixelWidth, b.PixelHeight); err != nil {
+		return RegionMapDiff{}, err
+	}
+	var diff RegionMapDiff
+	for y := 0; y < a.PixelHeight; y++ {
+		for x := 0; x < a.PixelWidth; x++ {
+			if math.Abs(a.Elevations[x][y]-b.Elevations[x][y]) > tolerance {
+				diff.ChangedElevations = append(diff.ChangedElevations, image.Point{x, y})
+			}
+		}
+	}
+	diff.AddedCities, diff.RemovedCities = diffTiles(a.Cities, b.Cities)
+	diff.AddedRoutes, diff.RemovedRoutes = diffTiles(a.Routes, b.Routes)
+	return diff, nil
+}
+
+// diffTiles returns the tiles that are only in b, and the tiles that are
+// only in a, each sorted from top to bottom, then left to right.
+func diffTiles(a, b []Tile) ([]Tile, []Tile) {
+	inA := map[Tile]bool{}
+	for _, t := range a {
+		inA[t] = true
+	}
+	inB := map[Tile]bool{}
+	for _, t := range b {
+		inB[t] = true
+	}
+	added := map[Tile]bool{}
+	for t := range inB {
+		if !inA[t] {
+			added[t] = true
+		}
+	}
+	removed := map[Tile]bool{}
+	for t := range inA {
+		if !inB[t] {
+			removed[t] = true
+		}
+	}
+	return getSortedTiles(added), getSortedTiles(removed)
+}
+
+// RenderRegionMapDiff renders region map b in grayscale, and highlights
+// the differences from the diff on top of it. Changed elevations are
+// yellow, added cities and routes are green, and removed cities and
+// routes are red.
+func RenderRegionMapDiff(b RegionMap, diff RegionMapDiff) image.Image {
+	img := newRegionMapImage(b)
+	drawRegionMap(img, b, getRenderPalette(RenderOptions{}), RenderOptions{})
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			img.SetRGBA(x, y, getGrayscaleColor(img.RGBAAt(x, y)))
+		}
+	}
+	for _, p := range diff.ChangedElevations {
+		img.SetRGBA(p.X, p.Y, diffElevationColor)
+	}
+	fillDiffTiles(img, diff.AddedRoutes, diffAddedColor)
+	fillDiffTiles(img, diff.RemovedRoutes, diffRemovedColor)
+	fillDiffTiles(img, diff.AddedCities, diffAddedColor)
+	fillDiffTiles(img, diff.RemovedCities, diffRemovedColor)
+	return img
+}
+
+// fillDiffTiles fills the given tiles with a solid color.
+func fillDiffTiles(img *image.RGBA, tiles []Tile, c color.RGBA) {
+	for _, t := range tiles {
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				img.SetRGBA(t.X*8+i, t.Y*8+j, c)
+			}
+		}
+	}
+}
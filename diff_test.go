@@ -0,0 +1,75 @@
+package porygion
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestDiffRegionMaps(t *testing.T) {
+	base := RegionMap{
+		PixelWidth:  2,
+		PixelHeight: 2,
+		Elevations:  [][]float64{{0, 0.1}, {0.2, 0.3}},
+		Cities:      []Tile{{0, 0}, {1, 1}},
+		Routes:      []Tile{{0, 1}, {1, 0}},
+	}
+	tests := []struct {
+		name   string
+		change func(r *RegionMap)
+		want   RegionMapDiff
+	}{
+		{"identical", func(r *RegionMap) {}, RegionMapDiff{}},
+		{"reordered", func(r *RegionMap) {
+			r.Cities = []Tile{{1, 1}, {0, 0}}
+			r.Routes = []Tile{{1, 0}, {0, 1}, {1, 0}}
+		}, RegionMapDiff{}},
+		{"elevation within tolerance", func(r *RegionMap) { r.Elevations[1][0] += 0.005 }, RegionMapDiff{}},
+		{"elevation beyond tolerance", func(r *RegionMap) { r.Elevations[1][0] += 0.02 }, RegionMapDiff{
+			ChangedElevations: []image.Point{{1, 0}},
+		}},
+		{"moved city", func(r *RegionMap) { r.Cities = []Tile{{0, 0}, {2, 2}} }, RegionMapDiff{
+			AddedCities:   []Tile{{2, 2}},
+			RemovedCities: []Tile{{1, 1}},
+		}},
+		{"changed routes", func(r *RegionMap) { r.Routes = []Tile{{3, 1}, {2, 1}, {0, 1}} }, RegionMapDiff{
+			AddedRoutes:   []Tile{{2, 1}, {3, 1}},
+			RemovedRoutes: []Tile{{1, 0}},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := base
+			b.Elevations = [][]float64{append([]float64{}, base.Elevations[0]...), append([]float64{}, base.Elevations[1]...)}
+			test.change(&b)
+			diff, err := DiffRegionMaps(base, b, 0.01)
+			if err != nil {
+				t.Fatalf("DiffRegionMaps: %s", err)
+			}
+			if diff.Empty() != reflect.DeepEqual(test.want, RegionMapDiff{}) {
+				t.Errorf("Empty() = %t", diff.Empty())
+			}
+			if !equalDiffs(diff, test.want) {
+				t.Errorf("DiffRegionMaps = %+v, want %+v", diff, test.want)
+			}
+		})
+	}
+
+	other := base
+	other.PixelWidth = 3
+	if _, err := DiffRegionMaps(base, other, 0); err == nil {
+		t.Errorf("DiffRegionMaps of different sizes succeeded, want an error")
+	}
+}
+
+// equalDiffs reports whether two diffs are the same, treating nil and empty
+// lists alike.
+func equalDiffs(a, b RegionMapDiff) bool {
+	equal := func(x, y interface{}) bool {
+		vx, vy := reflect.ValueOf(x), reflect.ValueOf(y)
+		return vx.Len() == 0 && vy.Len() == 0 || reflect.DeepEqual(x, y)
+	}
+	return equal(a.ChangedElevations, b.ChangedElevations) &&
+		equal(a.AddedCities, b.AddedCities) && equal(a.RemovedCities, b.RemovedCities) &&
+		equal(a.AddedRoutes, b.AddedRoutes) && equal(a.RemovedRoutes, b.RemovedRoutes)
+}
@@ -0,0 +1,43 @@
+package porygion
+
+import "image/color"
+
+// bayerMatrix is the 4x4 ordered dithering threshold pattern.
+var bayerMatrix = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// getDitherThreshold returns the ordered dithering threshold for a pixel,
+// between 0 and 1.
+func getDitherThreshold(x, y int) float64 {
+	return (bayerMatrix[y%4][x%4] + 0.5) / 16
+}
+
+// dither returns the land color for the given elevation, choosing between
+// the stops on either side of it with an ordered dither pattern.
+func (s elevationStops) dither(elevation float64, x, y int) color.RGBA {
+	lower, upper, t := s.span(elevation)
+	if t > getDitherThreshold(x, y) {
+		return upper
+	}
+	return lower
+}
+
+// getDitherStops returns stops for the palette's land shades, placed at
+// the middle of each standard elevation band. Dithering between them
+// keeps each band's own shade at its middle, and blends the shades
+// across the boundaries between bands.
+func getDitherStops(palette Palette) elevationStops {
+	stops := make(elevationStops, len(elevationBandLevels))
+	for i, level := range elevationBandLevels {
+		next := level + 0.25
+		if i+1 < len(elevationBandLevels) {
+			next = elevationBandLevels[i+1]
+		}
+		stops[i] = ElevationStop{Elevation: (level + next) / 2, Color: palette.Land[i]}
+	}
+	return stops
+}
@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the region map's city graph in the Graphviz DOT format.
+// Each city is a node labeled with its name, and each route is an edge
+// labeled and weighted by its length. Spur routes are drawn dashed, and
+// Victory Road is drawn in red.
+func WriteDOT(w io.Writer, regionMap RegionMap) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "graph region {\n")
+	for _, city := range regionMap.Cities {
+		label := regionMap.CityNames[city]
+		if label == "" {
+			label = fmt.Sprintf("(%d, %d)", city.X, city.Y)
+		}
+		fmt.Fprintf(bw, "  %s [label=%s];\n", getDOTNodeID(city), dotQuote(label))
+	}
+	for _, c := range regionMap.Connections {
+		attrs := []string{
+			fmt.Sprintf("label=%d", c.Length()),
+			fmt.Sprintf("weight=%d", c.Length()),
+		}
+		if c.Class == RouteSpur {
+			attrs = append(attrs, "style=dashed")
+		}
+		if c.VictoryRoad {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(bw, "  %s -- %s [%s];\n", getDOTNodeID(c.CityA), getDOTNodeID(c.CityB), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintf(bw, "}\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write DOT graph: %s", err)
+	}
+	return nil
+}
+
+func getDOTNodeID(city Tile) string {
+	return fmt.Sprintf("city_%d_%d", city.X, city.Y)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
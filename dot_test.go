@@ -0,0 +1,33 @@
+package porygion
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	a, b, c := Tile{1, 3}, Tile{5, 3}, Tile{5, 7}
+	regionMap := RegionMap{
+		Cities:    []Tile{a, b, c},
+		CityNames: map[Tile]string{a: `Pallet "Town"`},
+		Connections: []RouteConnection{
+			{CityA: a, CityB: b, Tiles: []Tile{{2, 3}, {3, 3}, {4, 3}}, Class: RouteTrunk},
+			{CityA: b, CityB: c, Tiles: []Tile{{5, 4}, {5, 5}, {5, 6}}, Class: RouteSpur, VictoryRoad: true},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, regionMap); err != nil {
+		t.Fatalf("WriteDOT: %s", err)
+	}
+	want := `graph region {
+  city_1_3 [label="Pallet \"Town\""];
+  city_5_3 [label="(5, 3)"];
+  city_5_7 [label="(5, 7)"];
+  city_1_3 -- city_5_3 [label=4, weight=4];
+  city_5_3 -- city_5_7 [label=4, weight=4, style=dashed, color=red];
+}
+`
+	if buf.String() != want {
+		t.Errorf("WriteDOT wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
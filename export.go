@@ -0,0 +1,67 @@
+package porygion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// exportFormats are the formats supported by Export, and their media
+// types. They are the exporters that don't need any options.
+var exportFormats = map[string]struct {
+	mediaType string
+	export    func(regionMap RegionMap) ([]byte, error)
+}{
+	"json":       {"application/json", RegionMap.MarshalJSON},
+	"map":        {"application/octet-stream", exportMapFile},
+	"geojson":    {"application/geo+json", ExportGeoJSON},
+	"stats":      {"application/json", ExportStats},
+	"porymap":    {"application/json", ExportPorymapSections},
+	"city_graph": {"application/json", ExportCityGraph},
+	"dot":        {"text/vnd.graphviz", exportWriter(WriteDOT)},
+	"lua":        {"text/x-lua", exportWriter(WriteLua)},
+}
+
+// ExportFormatNames returns the names of the formats supported by Export,
+// sorted.
+func ExportFormatNames() []string {
+	names := []string{}
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export converts a region map into the named format, for bindings and
+// services that choose the format at runtime. It returns the exported
+// data and its media type.
+func Export(regionMap RegionMap, format string) ([]byte, string, error) {
+	f, ok := exportFormats[format]
+	if !ok {
+		return nil, "", fmt.Errorf("Unknown export format '%s'. Valid formats are: %s", format, strings.Join(ExportFormatNames(), ", "))
+	}
+	data, err := f.export(regionMap)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, f.mediaType, nil
+}
+
+func exportMapFile(regionMap RegionMap) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WriteMapFile(&buf, regionMap)
+	return buf.Bytes(), err
+}
+
+// exportWriter adapts an exporter that writes to an io.Writer into one
+// that returns the exported bytes.
+func exportWriter(write func(w io.Writer, regionMap RegionMap) error) func(RegionMap) ([]byte, error) {
+	return func(regionMap RegionMap) ([]byte, error) {
+		var buf bytes.Buffer
+		err := write(&buf, regionMap)
+		return buf.Bytes(), err
+	}
+}
@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+)
+
+// Fingerprint returns a stable hash of the region map's size, elevations,
+// cities, and route tiles, as a hex string. Maps with the same fingerprint
+// have exactly the same terrain, cities, and routes. The order of the
+// cities and route tiles doesn't affect the fingerprint, and neither do
+// city names, city kinds, or the seed.
+func (r RegionMap) Fingerprint() string {
+	h := sha256.New()
+	var buf [8]byte
+	writeInt := func(v int64) {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:])
+	}
+	writeTiles := func(tiles []Tile) {
+		set := map[Tile]bool{}
+		for _, t := range tiles {
+			set[t] = true
+		}
+		sorted := getSortedTiles(set)
+		writeInt(int64(len(sorted)))
+		for _, t := range sorted {
+			writeInt(int64(t.X))
+			writeInt(int64(t.Y))
+		}
+	}
+
+	writeInt(int64(len(r.Elevations)))
+	for _, column := range r.Elevations {
+		writeInt(int64(len(column)))
+		for _, elevation := range column {
+			// Both zeros are the same elevation.
+			if elevation == 0 {
+				elevation = 0
+			}
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(elevation))
+			h.Write(buf[:])
+		}
+	}
+	writeTiles(r.Cities)
+	writeTiles(r.Routes)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,47 @@
+package porygion
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *RegionMap)
+		same   bool
+	}{
+		{"reordered cities", func(r *RegionMap) {
+			r.Cities = append([]Tile{r.Cities[len(r.Cities)-1]}, r.Cities[:len(r.Cities)-1]...)
+		}, true},
+		{"reordered route tiles", func(r *RegionMap) {
+			r.Routes = append([]Tile{r.Routes[len(r.Routes)-1]}, r.Routes[:len(r.Routes)-1]...)
+		}, true},
+		{"duplicate route tile", func(r *RegionMap) { r.Routes = append(r.Routes, r.Routes[0]) }, true},
+		{"renamed cities", func(r *RegionMap) { r.CityNames = nil }, true},
+		{"different seed", func(r *RegionMap) { r.Seed++ }, true},
+		{"changed elevation", func(r *RegionMap) { r.Elevations[1][2] += 1e-9 }, false},
+		{"moved city", func(r *RegionMap) { r.Cities[0].X++ }, false},
+		{"removed route tile", func(r *RegionMap) { r.Routes = r.Routes[1:] }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := testRegionMap(t)
+			before := r.Fingerprint()
+			test.change(&r)
+			if got := r.Fingerprint(); (got == before) != test.same {
+				t.Errorf("Fingerprint() changed = %t, want %t", got != before, !test.same)
+			}
+		})
+	}
+}
+
+func TestFingerprintTreatsBothZerosAlike(t *testing.T) {
+	a := testRegionMap(t)
+	b := testRegionMap(t)
+	a.Elevations[3][4] = 0
+	b.Elevations[3][4] = math.Copysign(0, -1)
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("Fingerprint() differs between 0 and -0")
+	}
+}
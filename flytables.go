@@ -0,0 +1,83 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// firstVisitedFlag is the offset from SYSTEM_FLAGS of the first city's
+// visited flag, following pokeemerald's FLAG_VISITED_LITTLEROOT_TOWN.
+const firstVisitedFlag = 0xF
+
+// WriteHealLocationConstants writes the C constants for flying to the
+// region map's cities, in the style of pokeemerald's constants headers: a
+// HEAL_LOCATION constant and a FLAG_VISITED flag for each city. The names
+// follow the cities' MAPSEC constants, so they stay the same as long as the
+// cities do.
+func WriteHealLocationConstants(w io.Writer, regionMap RegionMap) error {
+	names := getFlyCityNames(regionMap)
+	bw := bufio.NewWriter(w)
+	for i, name := range names {
+		fmt.Fprintf(bw, "#define HEAL_LOCATION_%s %d\n", name, i+1)
+	}
+	fmt.Fprintf(bw, "\n#define NUM_HEAL_LOCATIONS %d\n\n", len(names)+1)
+	for i, name := range names {
+		fmt.Fprintf(bw, "#define FLAG_VISITED_%s (SYSTEM_FLAGS + 0x%X)\n", name, firstVisitedFlag+i)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write heal location constants: %s", err)
+	}
+	return nil
+}
+
+// WriteFlyTables writes the C data that wires the region map's cities into
+// the Town Map's fly menu, in the style of pokeemerald: the sHealLocations
+// table, the sMapHealLocations table that maps each city's MAPSEC to its
+// heal location, and the GetMapsecType function that only allows flying
+// to visited cities. Each city is expected to have a map named
+// MAP_<CITY>. The cities' maps aren't generated, so every heal location
+// is at (0, 0), and should be moved to where the player appears after
+// flying there.
+func WriteFlyTables(w io.Writer, regionMap RegionMap) error {
+	names := getFlyCityNames(regionMap)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "static const struct HealLocation sHealLocations[NUM_HEAL_LOCATIONS - 1] =\n{\n")
+	for _, name := range names {
+		fmt.Fprintf(bw, "    [HEAL_LOCATION_%s - 1] = {MAP_GROUP(%s), MAP_NUM(%s), 0, 0},\n", name, name, name)
+	}
+	fmt.Fprintf(bw, "};\n\n")
+
+	fmt.Fprintf(bw, "static const u8 sMapHealLocations[][3] =\n{\n")
+	for _, name := range names {
+		fmt.Fprintf(bw, "    [MAPSEC_%s] = {MAP_GROUP(%s), MAP_NUM(%s), HEAL_LOCATION_%s},\n", name, name, name, name)
+	}
+	fmt.Fprintf(bw, "};\n\n")
+
+	fmt.Fprintf(bw, "static u8 GetMapsecType(u16 mapSecId)\n{\n")
+	fmt.Fprintf(bw, "    switch (mapSecId)\n    {\n")
+	fmt.Fprintf(bw, "    case MAPSEC_NONE:\n        return MAPSECTYPE_NONE;\n")
+	for _, name := range names {
+		fmt.Fprintf(bw, "    case MAPSEC_%s:\n", name)
+		fmt.Fprintf(bw, "        return FlagGet(FLAG_VISITED_%s) ? MAPSECTYPE_CITY_CANFLY : MAPSECTYPE_CITY_CANTFLY;\n", name)
+	}
+	fmt.Fprintf(bw, "    default:\n        return MAPSECTYPE_ROUTE;\n")
+	fmt.Fprintf(bw, "    }\n}\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write fly tables: %s", err)
+	}
+	return nil
+}
+
+// getFlyCityNames returns the names of the cities' MAPSEC constants,
+// without the MAPSEC_ prefix, in the same order as the region map's
+// cities.
+func getFlyCityNames(regionMap RegionMap) []string {
+	sections := regionMap.MapSections()[:len(regionMap.Cities)]
+	names := make([]string, len(sections))
+	for i, s := range sections {
+		names[i] = strings.TrimPrefix(s.ID, "MAPSEC_")
+	}
+	return names
+}
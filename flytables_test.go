@@ -0,0 +1,49 @@
+package porygion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHealLocationConstants(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHealLocationConstants(&buf, testSectionsMap()); err != nil {
+		t.Fatalf("WriteHealLocationConstants: %s", err)
+	}
+	want := `#define HEAL_LOCATION_LITTLEROOT_TOWN 1
+#define HEAL_LOCATION_LITTLEROOT_TOWN_2 2
+#define HEAL_LOCATION_PORT_3 3
+
+#define NUM_HEAL_LOCATIONS 4
+
+#define FLAG_VISITED_LITTLEROOT_TOWN (SYSTEM_FLAGS + 0xF)
+#define FLAG_VISITED_LITTLEROOT_TOWN_2 (SYSTEM_FLAGS + 0x10)
+#define FLAG_VISITED_PORT_3 (SYSTEM_FLAGS + 0x11)
+`
+	if buf.String() != want {
+		t.Errorf("WriteHealLocationConstants wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteFlyTables(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFlyTables(&buf, testSectionsMap()); err != nil {
+		t.Fatalf("WriteFlyTables: %s", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"    [HEAL_LOCATION_PORT_3 - 1] = {MAP_GROUP(PORT_3), MAP_NUM(PORT_3), 0, 0},\n",
+		"    [MAPSEC_LITTLEROOT_TOWN_2] = {MAP_GROUP(LITTLEROOT_TOWN_2), MAP_NUM(LITTLEROOT_TOWN_2), HEAL_LOCATION_LITTLEROOT_TOWN_2},\n",
+		"    case MAPSEC_LITTLEROOT_TOWN:\n        return FlagGet(FLAG_VISITED_LITTLEROOT_TOWN) ? MAPSECTYPE_CITY_CANFLY : MAPSECTYPE_CITY_CANTFLY;\n",
+		"    default:\n        return MAPSECTYPE_ROUTE;\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteFlyTables output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	// Routes aren't fly destinations.
+	if strings.Contains(out, "ROUTE_101") {
+		t.Errorf("WriteFlyTables output includes a route:\n%s", out)
+	}
+}
@@ -0,0 +1,63 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// FogStyle is how unvisited tiles are drawn under the fog of war.
+type FogStyle int
+
+// Styles for drawing unvisited tiles.
+const (
+	FogDarken FogStyle = iota
+	FogGrayscale
+)
+
+// FogOfWarOptions controls the fog of war, which hides the parts of the
+// map that the player hasn't visited yet.
+type FogOfWarOptions struct {
+	// Visited is the set of tiles that are drawn normally. Every other
+	// tile is covered by the fog.
+	Visited map[Tile]bool
+	Style   FogStyle
+	// Shade is the factor that unvisited tiles are darkened by, with the
+	// FogDarken style.
+	Shade float64
+}
+
+// DefaultFogOfWarOptions returns a fog of war that darkens every tile
+// except the visited ones.
+func DefaultFogOfWarOptions(visited map[Tile]bool) FogOfWarOptions {
+	return FogOfWarOptions{
+		Visited: visited,
+		Style:   FogDarken,
+		Shade:   0.35,
+	}
+}
+
+// drawFogOfWar darkens or desaturates every pixel that isn't within a
+// visited tile.
+func drawFogOfWar(img *image.RGBA, opts FogOfWarOptions) {
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			if opts.Visited[Tile{x / 8, y / 8}] {
+				continue
+			}
+			c := img.RGBAAt(x, y)
+			switch opts.Style {
+			case FogGrayscale:
+				img.SetRGBA(x, y, getGrayscaleColor(c))
+			default:
+				img.SetRGBA(x, y, shadeColor(c, opts.Shade))
+			}
+		}
+	}
+}
+
+// getGrayscaleColor returns the luminance of a color, as a gray color.
+func getGrayscaleColor(c color.RGBA) color.RGBA {
+	y := uint8((299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B)) / 1000)
+	return color.RGBA{y, y, y, c.A}
+}
@@ -0,0 +1,44 @@
+package porygion
+
+// CityGate is the route tile where a route meets a city. Exporters can use
+// gates to place map connections and gatehouses.
+type CityGate struct {
+	City Tile
+	Tile Tile
+	// Side is the side of the city that the gate is on.
+	Side Direction
+}
+
+// Gates returns the gates at both ends of the connection. The first gate
+// belongs to CityA, and the second gate belongs to CityB. ok is false when
+// the connection has no route tiles.
+func (c RouteConnection) Gates() (gateA CityGate, gateB CityGate, ok bool) {
+	if len(c.Tiles) == 0 {
+		return CityGate{}, CityGate{}, false
+	}
+	first := c.Tiles[0]
+	last := c.Tiles[len(c.Tiles)-1]
+	gateA = CityGate{City: c.CityA, Tile: first, Side: c.CityA.directionTo(first)}
+	gateB = CityGate{City: c.CityB, Tile: last, Side: c.CityB.directionTo(last)}
+	return gateA, gateB, true
+}
+
+// CityGates returns every distinct gate in the region map, in the order
+// of the region map's connections.
+func (r RegionMap) CityGates() []CityGate {
+	seen := map[CityGate]bool{}
+	gates := []CityGate{}
+	for _, c := range r.Connections {
+		gateA, gateB, ok := c.Gates()
+		if !ok {
+			continue
+		}
+		for _, g := range []CityGate{gateA, gateB} {
+			if !seen[g] {
+				seen[g] = true
+				gates = append(gates, g)
+			}
+		}
+	}
+	return gates
+}
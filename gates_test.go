@@ -0,0 +1,45 @@
+package porygion
+
+import "testing"
+
+func TestRouteConnectionGates(t *testing.T) {
+	c := RouteConnection{
+		CityA: Tile{1, 1},
+		CityB: Tile{3, 3},
+		Tiles: []Tile{{2, 1}, {3, 1}, {3, 2}},
+	}
+	gateA, gateB, ok := c.Gates()
+	if !ok {
+		t.Fatalf("Gates() found no gates")
+	}
+	if want := (CityGate{City: Tile{1, 1}, Tile: Tile{2, 1}, Side: East}); gateA != want {
+		t.Errorf("gate A = %+v, want %+v", gateA, want)
+	}
+	if want := (CityGate{City: Tile{3, 3}, Tile: Tile{3, 2}, Side: North}); gateB != want {
+		t.Errorf("gate B = %+v, want %+v", gateB, want)
+	}
+	if _, _, ok := (RouteConnection{CityA: Tile{1, 1}, CityB: Tile{1, 3}}).Gates(); ok {
+		t.Errorf("Gates() of a connection without tiles succeeded")
+	}
+}
+
+func TestCityGates(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	gates := regionMap.CityGates()
+	if len(gates) == 0 {
+		t.Fatalf("CityGates() is empty")
+	}
+	seen := map[CityGate]bool{}
+	for _, g := range gates {
+		if seen[g] {
+			t.Errorf("gate %+v is listed twice", g)
+		}
+		seen[g] = true
+		if g.City.Distance(g.Tile) != 1 || g.City.directionTo(g.Tile) != g.Side {
+			t.Errorf("gate %+v isn't on the %s side of its city", g, g.Side)
+		}
+	}
+}
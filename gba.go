@@ -0,0 +1,117 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Bits of a GBA text background tilemap entry.
+const (
+	gbaTileIndexMask = 0x3FF
+	gbaHFlip         = 1 << 10
+	gbaVFlip         = 1 << 11
+	gbaPaletteShift  = 12
+)
+
+// GBATiles is an image converted into the tile graphics and tilemap that
+// GBA text backgrounds load.
+type GBATiles struct {
+	// Tiles holds the unique tile graphics, in 4bpp format.
+	Tiles []byte
+	// Tilemap holds one little-endian 16-bit entry per tile of the image,
+	// from left to right, then top to bottom. Each entry has the tile's
+	// index in its low 10 bits, followed by the horizontal and vertical
+	// flip bits, and the palette number in its top 4 bits.
+	Tilemap []byte
+	// Width and Height are the size of the tilemap, in tiles.
+	Width, Height int
+	Palette       color.Palette
+}
+
+// ExportGBATiles renders a region map and converts it into GBA tile
+// graphics and a tilemap. Every tilemap entry uses the given palette
+// number. The render options must produce at most 16 colors.
+func ExportGBATiles(regionMap RegionMap, opts RenderOptions, paletteNum int) (GBATiles, error) {
+	img, err := RenderPalettedRegionMap(regionMap, opts)
+	if err != nil {
+		return GBATiles{}, err
+	}
+	return EncodeGBATiles(img, paletteNum)
+}
+
+// EncodeGBATiles splits an indexed-color image into 8x8 tiles, and encodes
+// them as GBA tile graphics and a tilemap. Tiles that are identical, or
+// that are flipped copies of each other, are stored only once. The image's
+// size must be a multiple of 8, and it must use at most 16 colors and 1024
+// unique tiles.
+func EncodeGBATiles(img *image.Paletted, paletteNum int) (GBATiles, error) {
+	bounds := img.Bounds()
+	if bounds.Dx()%8 != 0 || bounds.Dy()%8 != 0 {
+		return GBATiles{}, fmt.Errorf("Image size %dx%d is not a multiple of 8", bounds.Dx(), bounds.Dy())
+	}
+	if len(img.Palette) > maxPalettedColors {
+		return GBATiles{}, fmt.Errorf("Image has %d colors, but 4bpp tiles support at most %d colors", len(img.Palette), maxPalettedColors)
+	}
+	if paletteNum < 0 || paletteNum > 15 {
+		return GBATiles{}, fmt.Errorf("Invalid palette number %d", paletteNum)
+	}
+	width := bounds.Dx() / 8
+	height := bounds.Dy() / 8
+	tiles := []byte{}
+	tilemap := make([]byte, 0, width*height*2)
+	indexes := map[string]int{}
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			entry, ok := 0, false
+			for _, flip := range []int{0, gbaHFlip, gbaVFlip, gbaHFlip | gbaVFlip} {
+				variant := encodeFlippedTile4bpp(img, bounds.Min.X+i*8, bounds.Min.Y+j*8, flip)
+				if index, found := indexes[string(variant)]; found {
+					entry, ok = index|flip, true
+					break
+				}
+			}
+			if !ok {
+				tile := encodeFlippedTile4bpp(img, bounds.Min.X+i*8, bounds.Min.Y+j*8, 0)
+				entry = len(indexes)
+				if entry > gbaTileIndexMask {
+					return GBATiles{}, fmt.Errorf("Image has more than %d unique tiles", gbaTileIndexMask+1)
+				}
+				indexes[string(tile)] = entry
+				tiles = append(tiles, tile...)
+			}
+			entry |= paletteNum << gbaPaletteShift
+			tilemap = append(tilemap, byte(entry), byte(entry>>8))
+		}
+	}
+	return GBATiles{
+		Tiles:   tiles,
+		Tilemap: tilemap,
+		Width:   width,
+		Height:  height,
+		Palette: img.Palette,
+	}, nil
+}
+
+// encodeFlippedTile4bpp encodes the 8x8 tile at (x, y) in 4bpp format,
+// after flipping it with the given tilemap flip bits.
+func encodeFlippedTile4bpp(img *image.Paletted, x, y int, flip int) []byte {
+	tile := make([]byte, 32)
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 8; i++ {
+			srcX, srcY := i, j
+			if flip&gbaHFlip != 0 {
+				srcX = 7 - i
+			}
+			if flip&gbaVFlip != 0 {
+				srcY = 7 - j
+			}
+			p := img.ColorIndexAt(x+srcX, y+srcY) & 0xF
+			if i%2 == 1 {
+				p <<= 4
+			}
+			tile[j*4+i/2] |= p
+		}
+	}
+	return tile
+}
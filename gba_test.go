@@ -0,0 +1,74 @@
+package porygion
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeGBATiles(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	// The second tile is the first one flipped horizontally, and the third
+	// is the first one flipped both ways.
+	img := image.NewPaletted(image.Rect(0, 0, 24, 8), palette)
+	img.SetColorIndex(0, 0, 1)
+	img.SetColorIndex(1, 1, 1)
+	img.SetColorIndex(15, 0, 1)
+	img.SetColorIndex(14, 1, 1)
+	img.SetColorIndex(23, 7, 1)
+	img.SetColorIndex(22, 6, 1)
+	tiles, err := EncodeGBATiles(img, 3)
+	if err != nil {
+		t.Fatalf("EncodeGBATiles: %s", err)
+	}
+	if tiles.Width != 3 || tiles.Height != 1 || len(tiles.Tiles) != 32 {
+		t.Fatalf("EncodeGBATiles made a %dx%d tilemap with %d bytes of tiles, want 3x1 with 1 tile", tiles.Width, tiles.Height, len(tiles.Tiles))
+	}
+	if tiles.Tiles[0] != 0x01 || tiles.Tiles[4] != 0x10 {
+		t.Errorf("tile starts with rows % x and % x, want 01 and 10", tiles.Tiles[:4], tiles.Tiles[4:8])
+	}
+	want := []int{3 << gbaPaletteShift, 3<<gbaPaletteShift | gbaHFlip, 3<<gbaPaletteShift | gbaHFlip | gbaVFlip}
+	for i, w := range want {
+		if entry := int(binary.LittleEndian.Uint16(tiles.Tilemap[i*2:])); entry != w {
+			t.Errorf("tilemap entry %d is %#04x, want %#04x", i, entry, w)
+		}
+	}
+}
+
+func TestEncodeGBATilesErrors(t *testing.T) {
+	tooManyColors := make(color.Palette, 17)
+	for i := range tooManyColors {
+		tooManyColors[i] = color.Gray{uint8(i)}
+	}
+	tests := []struct {
+		name       string
+		img        *image.Paletted
+		paletteNum int
+	}{
+		{"partial tiles", image.NewPaletted(image.Rect(0, 0, 12, 8), color.Palette{color.Black}), 0},
+		{"too many colors", image.NewPaletted(image.Rect(0, 0, 8, 8), tooManyColors), 0},
+		{"invalid palette", image.NewPaletted(image.Rect(0, 0, 8, 8), color.Palette{color.Black}), 16},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := EncodeGBATiles(test.img, test.paletteNum); err == nil {
+				t.Errorf("EncodeGBATiles succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestExportGBATiles(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	tiles, err := ExportGBATiles(regionMap, RenderOptions{}, 0)
+	if err != nil {
+		t.Fatalf("ExportGBATiles: %s", err)
+	}
+	if tiles.Width != 30 || tiles.Height != 20 || len(tiles.Tilemap) != 30*20*2 {
+		t.Errorf("ExportGBATiles made a %dx%d tilemap with %d bytes, want 30x20", tiles.Width, tiles.Height, len(tiles.Tilemap))
+	}
+}
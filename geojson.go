@@ -0,0 +1,173 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// GeoJSON coordinates are in pixels, with the y axis negated so that north
+// is up, which is the convention used by flat, non-geographic maps in web
+// map libraries like Leaflet.
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONPosition [2]float64
+
+// ExportGeoJSON encodes the region map as a GeoJSON feature collection.
+// The land is a MultiPolygon traced along the coastline, each route is a
+// LineString through the centers of its tiles, and each city is a Point.
+func ExportGeoJSON(regionMap RegionMap) ([]byte, error) {
+	features := []geoJSONFeature{}
+	land := getLandPolygons(traceContours(regionMap.Elevations, 0))
+	features = append(features, geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geoJSONGeometry{Type: "MultiPolygon", Coordinates: land},
+		Properties: map[string]interface{}{"kind": "land"},
+	})
+	for _, c := range regionMap.Connections {
+		line := []geoJSONPosition{}
+		for _, t := range c.Vertices() {
+			line = append(line, getTileCenterPosition(t))
+		}
+		features = append(features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]interface{}{
+				"kind":         "route",
+				"class":        c.Class.String(),
+				"length":       c.Length(),
+				"victory_road": c.VictoryRoad,
+			},
+		})
+	}
+	for _, city := range regionMap.Cities {
+		properties := map[string]interface{}{
+			"kind":      "city",
+			"city_kind": regionMap.CityKinds[city].String(),
+			"tile_x":    city.X,
+			"tile_y":    city.Y,
+		}
+		if name, ok := regionMap.CityNames[city]; ok {
+			properties["name"] = name
+		}
+		features = append(features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: getTileCenterPosition(city)},
+			Properties: properties,
+		})
+	}
+	data, err := json.Marshal(geoJSONFeatureCollection{Type: "FeatureCollection", Features: features})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode GeoJSON: %s", err)
+	}
+	return data, nil
+}
+
+func getTileCenterPosition(t Tile) geoJSONPosition {
+	return geoJSONPosition{float64(t.X*8 + 4), -float64(t.Y*8 + 4)}
+}
+
+// getLandPolygons groups the coastline loops into polygons. Loops that are
+// nested inside an odd number of other loops are holes, like lakes, and
+// belong to the loop that directly contains them. Outer rings wind
+// counterclockwise and holes wind clockwise, as GeoJSON requires.
+func getLandPolygons(loops [][]contourPoint) [][][]geoJSONPosition {
+	rings := make([][]geoJSONPosition, len(loops))
+	areas := make([]float64, len(loops))
+	for i, loop := range loops {
+		ring := make([]geoJSONPosition, 0, len(loop)+1)
+		for _, p := range loop {
+			ring = append(ring, geoJSONPosition{p.X, -p.Y})
+		}
+		// GeoJSON rings repeat their first position at the end.
+		ring = append(ring, ring[0])
+		rings[i] = ring
+		areas[i] = math.Abs(getRingArea(ring))
+	}
+
+	// parents holds the smallest loop that contains each loop, or -1.
+	parents := make([]int, len(rings))
+	depths := make([]int, len(rings))
+	for i := range rings {
+		parents[i] = -1
+		for j := range rings {
+			if i == j || areas[j] <= areas[i] || !isInsideRing(rings[i][0], rings[j]) {
+				continue
+			}
+			depths[i]++
+			if parents[i] == -1 || areas[j] < areas[parents[i]] {
+				parents[i] = j
+			}
+		}
+	}
+
+	polygons := [][][]geoJSONPosition{}
+	polygonIndexes := map[int]int{}
+	for i, ring := range rings {
+		if depths[i]%2 == 0 {
+			polygonIndexes[i] = len(polygons)
+			polygons = append(polygons, [][]geoJSONPosition{setRingWinding(ring, true)})
+		}
+	}
+	for i, ring := range rings {
+		if depths[i]%2 == 1 {
+			p := polygonIndexes[parents[i]]
+			polygons[p] = append(polygons[p], setRingWinding(ring, false))
+		}
+	}
+	return polygons
+}
+
+// getRingArea returns the signed area of a closed ring, which is positive
+// when the ring winds counterclockwise.
+func getRingArea(ring []geoJSONPosition) float64 {
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area / 2
+}
+
+// setRingWinding returns the ring, reversed if needed so that it winds
+// counterclockwise or clockwise.
+func setRingWinding(ring []geoJSONPosition, counterclockwise bool) []geoJSONPosition {
+	if (getRingArea(ring) > 0) == counterclockwise {
+		return ring
+	}
+	reversed := make([]geoJSONPosition, len(ring))
+	for i, p := range ring {
+		reversed[len(ring)-1-i] = p
+	}
+	return reversed
+}
+
+// isInsideRing uses ray casting to check whether a point is inside a
+// closed ring.
+func isInsideRing(p geoJSONPosition, ring []geoJSONPosition) bool {
+	inside := false
+	for i := 0; i+1 < len(ring); i++ {
+		a, b := ring[i], ring[i+1]
+		if (a[1] > p[1]) != (b[1] > p[1]) {
+			x := a[0] + (p[1]-a[1])/(b[1]-a[1])*(b[0]-a[0])
+			if p[0] < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
@@ -0,0 +1,65 @@
+package porygion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportGeoJSON(t *testing.T) {
+	regionMap := testRegionMap(t)
+	data, err := ExportGeoJSON(regionMap)
+	if err != nil {
+		t.Fatalf("ExportGeoJSON: %s", err)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("Failed to decode GeoJSON: %s", err)
+	}
+	if want := 1 + len(regionMap.Connections) + len(regionMap.Cities); collection.Type != "FeatureCollection" || len(collection.Features) != want {
+		t.Fatalf("GeoJSON is a %s of %d features, want a FeatureCollection of %d", collection.Type, len(collection.Features), want)
+	}
+	counts := map[string]int{}
+	for _, f := range collection.Features {
+		counts[f.Geometry.Type+" "+f.Properties["kind"].(string)]++
+		if f.Geometry.Type == "LineString" {
+			var line []geoJSONPosition
+			if err := json.Unmarshal(f.Geometry.Coordinates, &line); err != nil || len(line) < 2 {
+				t.Errorf("route has coordinates %s", f.Geometry.Coordinates)
+			}
+		}
+	}
+	if counts["MultiPolygon land"] != 1 || counts["LineString route"] != len(regionMap.Connections) || counts["Point city"] != len(regionMap.Cities) {
+		t.Errorf("GeoJSON has features %v", counts)
+	}
+	var point geoJSONPosition
+	city := regionMap.Cities[0]
+	json.Unmarshal(collection.Features[1+len(regionMap.Connections)].Geometry.Coordinates, &point)
+	if want := (geoJSONPosition{float64(city.X*8 + 4), -float64(city.Y*8 + 4)}); point != want {
+		t.Errorf("first city is at %v, want %v", point, want)
+	}
+}
+
+func TestGetLandPolygons(t *testing.T) {
+	square := func(min, max float64) []contourPoint {
+		return []contourPoint{{min, min}, {max, min}, {max, max}, {min, max}}
+	}
+	polygons := getLandPolygons([][]contourPoint{square(3, 6), square(0, 10), square(20, 30)})
+	if len(polygons) != 2 || len(polygons[0]) != 2 || len(polygons[1]) != 1 {
+		t.Fatalf("getLandPolygons made polygons with rings %v, want an island with a lake, and an island", polygons)
+	}
+	if outer, hole := polygons[0][0], polygons[0][1]; getRingArea(outer) <= 0 || getRingArea(hole) >= 0 {
+		t.Errorf("island rings have areas %g and %g, want a counterclockwise outer ring and a clockwise hole", getRingArea(outer), getRingArea(hole))
+	}
+	if ring := polygons[0][0]; ring[0] != ring[len(ring)-1] {
+		t.Errorf("ring %v isn't closed", ring)
+	}
+}
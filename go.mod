@@ -3,7 +3,7 @@ module github.com/huderlem/porygion
 go 1.13
 
 require (
-	github.com/muesli/clusters v0.0.0-20180605185049-a07a36e67d36
-	github.com/muesli/kmeans v0.0.0-20200718051629-66f1657148c0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/ojrac/opensimplex-go v1.0.1
+	golang.org/x/image v0.0.0-20190501045829-6d32002ffd75
 )
@@ -0,0 +1,65 @@
+package porygion
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ExportGodotScene converts a region map into a Godot 3 scene (.tscn) with
+// a TileMap node for each of the terrain, route, and city layers, along
+// with a tileset image generated from the palette. tilesetImage is the
+// resource path of the tileset image, like "res://region_tiles.png".
+func ExportGodotScene(regionMap RegionMap, palette Palette, tilesetImage string) (TilemapExport, error) {
+	tilesWidth, _, err := getExportTileSize(regionMap)
+	if err != nil {
+		return TilemapExport{}, err
+	}
+	layers := getTileLayers(regionMap)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "[gd_scene load_steps=3 format=2]\n\n")
+	fmt.Fprintf(&b, "[ext_resource path=%q type=\"Texture\" id=1]\n\n", tilesetImage)
+	fmt.Fprintf(&b, "[sub_resource type=\"TileSet\" id=1]\n")
+	for i, name := range tiledTileNames {
+		fmt.Fprintf(&b, "%d/name = %q\n", i, name)
+		fmt.Fprintf(&b, "%d/texture = ExtResource( 1 )\n", i)
+		fmt.Fprintf(&b, "%d/tex_offset = Vector2( 0, 0 )\n", i)
+		fmt.Fprintf(&b, "%d/modulate = Color( 1, 1, 1, 1 )\n", i)
+		fmt.Fprintf(&b, "%d/region = Rect2( %d, 0, 8, 8 )\n", i, i*8)
+		fmt.Fprintf(&b, "%d/tile_mode = 0\n", i)
+		fmt.Fprintf(&b, "%d/z_index = 0\n", i)
+	}
+	fmt.Fprintf(&b, "\n[node name=\"RegionMap\" type=\"Node2D\"]\n")
+	for _, layer := range []struct {
+		name  string
+		tiles []int
+	}{
+		{"Terrain", layers.terrain},
+		{"Routes", layers.routes},
+		{"Cities", layers.cities},
+	} {
+		fmt.Fprintf(&b, "\n[node name=%q type=\"TileMap\" parent=\".\"]\n", layer.name)
+		fmt.Fprintf(&b, "tile_set = SubResource( 1 )\n")
+		fmt.Fprintf(&b, "cell_size = Vector2( 8, 8 )\n")
+		fmt.Fprintf(&b, "format = 1\n")
+		fmt.Fprintf(&b, "tile_data = PoolIntArray( %s )\n", getGodotTileData(layer.tiles, tilesWidth))
+	}
+	return TilemapExport{Map: b.Bytes(), Tileset: renderTiledTileset(palette)}, nil
+}
+
+// getGodotTileData encodes a layer's tiles as a Godot 3 TileMap's cell
+// data. Each cell is stored as three integers: its position, with y in the
+// upper 16 bits and x in the lower 16 bits, its tile ID, and its flip and
+// autotile flags. Empty cells are left out.
+func getGodotTileData(tiles []int, tilesWidth int) string {
+	cells := []string{}
+	for i, tile := range tiles {
+		if tile < 0 {
+			continue
+		}
+		x := i % tilesWidth
+		y := i / tilesWidth
+		cells = append(cells, fmt.Sprintf("%d, %d, 0", y<<16|x, tile))
+	}
+	return strings.Join(cells, ", ")
+}
@@ -0,0 +1,29 @@
+package porygion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetGodotTileData(t *testing.T) {
+	if data := getGodotTileData([]int{3, -1, 5, -1}, 2); data != "0, 3, 0, 65536, 5, 0" {
+		t.Errorf("getGodotTileData = %q", data)
+	}
+}
+
+func TestExportGodotScene(t *testing.T) {
+	export, err := ExportGodotScene(testRegionMap(t), DefaultPalette(), "res://region_tiles.png")
+	if err != nil {
+		t.Fatalf("ExportGodotScene: %s", err)
+	}
+	scene := string(export.Map)
+	if !strings.HasPrefix(scene, "[gd_scene") || !strings.Contains(scene, `[ext_resource path="res://region_tiles.png" type="Texture" id=1]`) {
+		t.Errorf("scene doesn't start with its header and tileset:\n%s", scene)
+	}
+	if n := strings.Count(scene, `type="TileMap"`); n != 3 {
+		t.Errorf("scene has %d TileMap nodes, want 3", n)
+	}
+	if export.Tileset.Bounds().Dx() != tiledNumTiles*8 {
+		t.Errorf("tileset is %v, want %d tiles wide", export.Tileset.Bounds(), tiledNumTiles)
+	}
+}
@@ -0,0 +1,230 @@
+package porygion
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gRPC status codes.
+const (
+	grpcOK              = 0
+	grpcInvalidArgument = 3
+	grpcUnimplemented   = 12
+	grpcInternal        = 13
+)
+
+// grpcServicePath is the path prefix of the MapService methods.
+const grpcServicePath = "/porygion.MapService/"
+
+// maxGRPCMessageSize is the largest request message that the gRPC handler
+// accepts.
+const maxGRPCMessageSize = 64 << 20
+
+// maxGRPCDimension is the largest width or height, in pixels, of the maps
+// that the GenerateMap method generates, and the most cities it places, so
+// that a single request can't tie up the server for long.
+const maxGRPCDimension = 4096
+
+// maxGRPCScale is the largest scale that the RenderMap method renders maps
+// at, so that a single request can't exhaust the server's memory.
+const maxGRPCScale = 8
+
+// grpcError is an error with a gRPC status code.
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e grpcError) Error() string {
+	return e.message
+}
+
+// grpcMethods are the methods of the MapService, described by
+// mapservice.proto. Each one takes an encoded request message, and
+// returns an encoded response message.
+var grpcMethods = map[string]func(request []byte) ([]byte, error){
+	"GenerateMap": grpcGenerateMap,
+	"RenderMap":   grpcRenderMap,
+	"ExportMap":   grpcExportMap,
+}
+
+// NewGRPCHandler returns an HTTP handler that serves the MapService
+// described by mapservice.proto over gRPC. gRPC requires HTTP/2, which Go's
+// HTTP server only enables over TLS, so the handler must be served with
+// http.ListenAndServeTLS or similar. Messages must not be compressed.
+func NewGRPCHandler() http.Handler {
+	return http.HandlerFunc(serveGRPC)
+}
+
+func serveGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "Expected a gRPC request over HTTP/2", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Accept-Encoding", "identity")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+	response, err := handleGRPCRequest(r)
+	if err == nil {
+		var frame [5]byte
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(response)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(frame[:])
+		w.Write(response)
+	}
+	code := grpcOK
+	message := ""
+	if err != nil {
+		code = grpcInternal
+		if e, ok := err.(grpcError); ok {
+			code = e.code
+		}
+		message = err.Error()
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	w.Header().Set("Grpc-Message", grpcPercentEncode(message))
+}
+
+// handleGRPCRequest reads the request message, and calls its method.
+func handleGRPCRequest(r *http.Request) ([]byte, error) {
+	method, ok := grpcMethods[strings.TrimPrefix(r.URL.Path, grpcServicePath)]
+	if !ok || !strings.HasPrefix(r.URL.Path, grpcServicePath) {
+		return nil, grpcError{grpcUnimplemented, fmt.Sprintf("Unknown method %s", r.URL.Path)}
+	}
+	var frame [5]byte
+	if _, err := io.ReadFull(r.Body, frame[:]); err != nil {
+		return nil, grpcError{grpcInvalidArgument, "Missing request message"}
+	}
+	if frame[0] != 0 {
+		return nil, grpcError{grpcUnimplemented, "Compressed messages aren't supported"}
+	}
+	size := binary.BigEndian.Uint32(frame[1:])
+	if size > maxGRPCMessageSize {
+		return nil, grpcError{grpcInvalidArgument, fmt.Sprintf("Request message is larger than %d bytes", maxGRPCMessageSize)}
+	}
+	request, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(size)))
+	if err != nil || len(request) != int(size) {
+		return nil, grpcError{grpcInvalidArgument, "Truncated request message"}
+	}
+	return method(request)
+}
+
+func grpcGenerateMap(request []byte) ([]byte, error) {
+	var p MapParams
+	err := decodeProtoMessage(request, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			p.Seed = int64(v.varint)
+		case 2:
+			p.PixelWidth = int(int32(v.varint))
+		case 3:
+			p.PixelHeight = int(int32(v.varint))
+		case 4:
+			p.NumCities = int(int32(v.varint))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, grpcError{grpcInvalidArgument, err.Error()}
+	}
+	if p.PixelWidth > maxGRPCDimension || p.PixelHeight > maxGRPCDimension || p.NumCities > maxGRPCDimension {
+		return nil, grpcError{grpcInvalidArgument, fmt.Sprintf("Maps larger than %dx%d can't be generated", maxGRPCDimension, maxGRPCDimension)}
+	}
+	config := NewConfig(p)
+	if err := config.Validate(); err != nil {
+		return nil, grpcError{grpcInvalidArgument, err.Error()}
+	}
+	regionMap, err := config.Generate()
+	if err != nil {
+		return nil, grpcError{grpcInvalidArgument, err.Error()}
+	}
+	return regionMap.MarshalProto()
+}
+
+func grpcRenderMap(request []byte) ([]byte, error) {
+	var regionMap RegionMap
+	var opts RenderOptions
+	err := decodeProtoMessage(request, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			return regionMap.UnmarshalProto(v.bytes)
+		case 2:
+			palette, err := ThemePalette(string(v.bytes))
+			opts.Palette = &palette
+			return err
+		case 3:
+			opts.Scale = int(int32(v.varint))
+		}
+		return nil
+	})
+	if err == nil && regionMap.Elevations == nil {
+		err = fmt.Errorf("Missing region map")
+	}
+	if err == nil && (opts.Scale < 0 || opts.Scale > maxGRPCScale) {
+		err = fmt.Errorf("Invalid scale %d. The scale must be between 1 and %d", opts.Scale, maxGRPCScale)
+	}
+	if err != nil {
+		return nil, grpcError{grpcInvalidArgument, err.Error()}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, RenderRegionMap(regionMap, opts)); err != nil {
+		return nil, err
+	}
+	e := protoEncoder{}
+	e.bytes(1, buf.Bytes())
+	return e.buf, nil
+}
+
+func grpcExportMap(request []byte) ([]byte, error) {
+	var regionMap RegionMap
+	var formatName string
+	err := decodeProtoMessage(request, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			return regionMap.UnmarshalProto(v.bytes)
+		case 2:
+			formatName = string(v.bytes)
+		}
+		return nil
+	})
+	if err == nil && regionMap.Elevations == nil {
+		err = fmt.Errorf("Missing region map")
+	}
+	if err != nil {
+		return nil, grpcError{grpcInvalidArgument, err.Error()}
+	}
+	data, mediaType, err := Export(regionMap, formatName)
+	if err != nil {
+		if _, ok := exportFormats[formatName]; !ok {
+			return nil, grpcError{grpcInvalidArgument, err.Error()}
+		}
+		return nil, err
+	}
+	e := protoEncoder{}
+	e.bytes(1, data)
+	e.string(2, mediaType)
+	return e.buf, nil
+}
+
+// grpcPercentEncode encodes a status message for the Grpc-Message header,
+// which only allows printable ASCII.
+func grpcPercentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
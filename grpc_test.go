@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGRPCGenerateMapLimits(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height, cities uint64
+		want                  string
+	}{
+		{"too wide", maxGRPCDimension + 8, 64, 4, "can't be generated"},
+		{"too tall", 64, maxGRPCDimension + 8, 4, "can't be generated"},
+		{"too many cities", 64, 64, maxGRPCDimension + 1, "can't be generated"},
+		{"invalid size", 4, 64, 4, "Invalid map size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var e protoEncoder
+			e.varint(1, 7)
+			e.varint(2, test.width)
+			e.varint(3, test.height)
+			e.varint(4, test.cities)
+			_, err := grpcGenerateMap(e.buf)
+			gerr, ok := err.(grpcError)
+			if !ok || gerr.code != grpcInvalidArgument || !strings.Contains(gerr.message, test.want) {
+				t.Errorf("grpcGenerateMap error = %#v, want an invalid argument containing %q", err, test.want)
+			}
+		})
+	}
+
+	var e protoEncoder
+	e.varint(1, 7)
+	e.varint(2, 96)
+	e.varint(3, 64)
+	e.varint(4, 6)
+	response, err := grpcGenerateMap(e.buf)
+	if err != nil {
+		t.Fatalf("grpcGenerateMap: %s", err)
+	}
+	var regionMap RegionMap
+	if err := regionMap.UnmarshalProto(response); err != nil {
+		t.Fatalf("UnmarshalProto: %s", err)
+	}
+	if len(regionMap.Cities) != 6 {
+		t.Errorf("Generated %d cities, want 6", len(regionMap.Cities))
+	}
+}
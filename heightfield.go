@@ -0,0 +1,86 @@
+package porygion
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// HeightfieldFormat is the bit depth of a raw heightfield file.
+type HeightfieldFormat int
+
+// Raw heightfield formats.
+const (
+	// HeightfieldR16 stores each height as an unsigned 16-bit integer.
+	// It is the format expected by the Unreal and Unity terrain importers.
+	HeightfieldR16 HeightfieldFormat = iota
+	// HeightfieldRaw8 stores each height as an unsigned byte.
+	HeightfieldRaw8
+	// HeightfieldR32 stores each height as a 32-bit float between 0 and 1.
+	HeightfieldR32
+)
+
+// HeightfieldOptions controls how a raw heightfield file is written.
+type HeightfieldOptions struct {
+	Format HeightfieldFormat
+	// ByteOrder is the byte order of 16-bit and 32-bit heights. Unreal and
+	// Unity on Windows expect little endian.
+	ByteOrder binary.ByteOrder
+}
+
+// DefaultHeightfieldOptions returns options for a little endian, 16-bit
+// heightfield.
+func DefaultHeightfieldOptions() HeightfieldOptions {
+	return HeightfieldOptions{
+		Format:    HeightfieldR16,
+		ByteOrder: binary.LittleEndian,
+	}
+}
+
+// WriteHeightfield writes the elevations of a region map as a headerless
+// raw heightfield, one row at a time from top to bottom. The lowest
+// elevation is mapped to 0, and the highest to the largest value of the
+// format. The file has no header, so the importer must be given the
+// region map's pixel width and height.
+func WriteHeightfield(w io.Writer, regionMap RegionMap, opts HeightfieldOptions) error {
+	elevations := regionMap.Elevations
+	if err := validateElevations(elevations, regionMap.PixelWidth, regionMap.PixelHeight); err != nil {
+		return err
+	}
+	order := opts.ByteOrder
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	min, max := getElevationRange(elevations)
+	bw := bufio.NewWriter(w)
+	var buf [4]byte
+	var err error
+	for y := 0; y < regionMap.PixelHeight && err == nil; y++ {
+		for x := 0; x < regionMap.PixelWidth && err == nil; x++ {
+			elevation := elevations[x][y]
+			switch opts.Format {
+			case HeightfieldRaw8:
+				err = bw.WriteByte(byte(getHeightmapValue(elevation, min, max) >> 8))
+			case HeightfieldR32:
+				t := float32(0)
+				if max > min {
+					t = float32((elevation - min) / (max - min))
+				}
+				order.PutUint32(buf[:], math.Float32bits(t))
+				_, err = bw.Write(buf[:4])
+			default:
+				order.PutUint16(buf[:], getHeightmapValue(elevation, min, max))
+				_, err = bw.Write(buf[:2])
+			}
+		}
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write heightfield: %s", err)
+	}
+	return nil
+}
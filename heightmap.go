@@ -0,0 +1,52 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// RenderHeightmap renders the elevations of a region map as a 16-bit
+// grayscale image. The lowest elevation is black, and the highest
+// elevation is white.
+func RenderHeightmap(regionMap RegionMap) *image.Gray16 {
+	min, max := getElevationRange(regionMap.Elevations)
+	return RenderHeightmapRange(regionMap, min, max)
+}
+
+// RenderHeightmapRange renders the elevations of a region map as a 16-bit
+// grayscale image, where the min elevation is black and the max elevation
+// is white. Elevations outside of the range are clamped.
+func RenderHeightmapRange(regionMap RegionMap, min, max float64) *image.Gray16 {
+	elevations := regionMap.Elevations
+	img := image.NewGray16(image.Rect(0, 0, len(elevations), len(elevations[0])))
+	for i := range elevations {
+		for j := range elevations[i] {
+			img.SetGray16(i, j, color.Gray16{Y: getHeightmapValue(elevations[i][j], min, max)})
+		}
+	}
+	return img
+}
+
+// getHeightmapValue maps an elevation onto the full range of a uint16.
+func getHeightmapValue(elevation, min, max float64) uint16 {
+	if max <= min {
+		return 0
+	}
+	t := (elevation - min) / (max - min)
+	t = math.Max(0, math.Min(1, t))
+	return uint16(math.Round(t * math.MaxUint16))
+}
+
+// getElevationRange returns the lowest and highest elevations.
+func getElevationRange(elevations [][]float64) (float64, float64) {
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for i := range elevations {
+		for _, e := range elevations[i] {
+			min = math.Min(min, e)
+			max = math.Max(max, e)
+		}
+	}
+	return min, max
+}
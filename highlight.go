@@ -0,0 +1,51 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// HighlightOptions controls the selection overlay, which outlines tiles
+// with a cursor frame, like the cursor on the in-game Town Map.
+type HighlightOptions struct {
+	// Tiles are the tiles to highlight, such as a selected city.
+	Tiles []Tile     `json:"tiles"`
+	Color color.RGBA `json:"color"`
+	// Frame is the frame of the cursor's blinking animation. Even frames
+	// draw a full frame around each tile, and odd frames draw only its
+	// corners, so alternating frames makes the cursor blink.
+	Frame int `json:"frame"`
+}
+
+// DefaultHighlightOptions returns a white cursor frame around the given
+// tiles.
+func DefaultHighlightOptions(tiles ...Tile) HighlightOptions {
+	return HighlightOptions{
+		Tiles: tiles,
+		Color: color.RGBA{248, 248, 248, 255},
+	}
+}
+
+// drawHighlights draws the cursor frame around each highlighted tile.
+func drawHighlights(img *image.RGBA, opts HighlightOptions) {
+	cornersOnly := opts.Frame%2 != 0
+	for _, t := range opts.Tiles {
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				onEdge := i == 0 || i == 7 || j == 0 || j == 7
+				if !onEdge {
+					continue
+				}
+				nearCorner := (i < 2 || i > 5) && (j < 2 || j > 5)
+				if cornersOnly && !nearCorner {
+					continue
+				}
+				x := t.X*8 + i
+				y := t.Y*8 + j
+				if (image.Point{x, y}).In(img.Bounds()) {
+					img.SetRGBA(x, y, opts.Color)
+				}
+			}
+		}
+	}
+}
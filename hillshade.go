@@ -0,0 +1,99 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// HillshadeOptions controls the hillshading pass, which lightens and darkens
+// land based on the slope of the terrain, as if lit by a distant light.
+type HillshadeOptions struct {
+	// Azimuth is the compass direction of the light, in degrees clockwise
+	// from north.
+	Azimuth float64 `json:"azimuth"`
+	// Altitude is the angle of the light above the horizon, in degrees.
+	Altitude float64 `json:"altitude"`
+	// Exaggeration multiplies the terrain's slopes, since the elevations
+	// are very flat relative to their pixel size. When zero, a default
+	// exaggeration is used.
+	Exaggeration float64 `json:"exaggeration"`
+}
+
+// DefaultHillshadeOptions returns hillshading lit from the northwest.
+func DefaultHillshadeOptions() HillshadeOptions {
+	return HillshadeOptions{
+		Azimuth:      315,
+		Altitude:     45,
+		Exaggeration: defaultHillshadeExaggeration,
+	}
+}
+
+const defaultHillshadeExaggeration = 20.0
+
+// applyHillshade shades the land pixels of the image, in place.
+func applyHillshade(img *image.RGBA, elevations [][]float64, opts HillshadeOptions) {
+	exaggeration := opts.Exaggeration
+	if exaggeration == 0 {
+		exaggeration = defaultHillshadeExaggeration
+	}
+	zenith := (90 - opts.Altitude) * math.Pi / 180
+	azimuth := opts.Azimuth * math.Pi / 180
+	flat := math.Cos(zenith)
+	width := len(elevations)
+	height := len(elevations[0])
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			if elevations[i][j] <= 0 {
+				continue
+			}
+			dzdx, dzdy := getElevationGradient(elevations, i, j)
+			dzdx *= exaggeration
+			dzdy *= exaggeration
+			slope := math.Atan(math.Hypot(dzdx, dzdy))
+			// The aspect is the compass direction that the slope faces.
+			// Image rows increase southward, so north is negative y.
+			aspect := math.Atan2(-dzdx, dzdy)
+			shade := flat*math.Cos(slope) + math.Sin(zenith)*math.Sin(slope)*math.Cos(azimuth-aspect)
+			factor := 1.0
+			if flat > 0 {
+				factor = shade / flat
+			}
+			img.SetRGBA(i, j, shadeColor(img.RGBAAt(i, j), factor))
+		}
+	}
+}
+
+// getElevationGradient returns the rate of change of elevation at a pixel,
+// along the x and y axes, using central differences.
+func getElevationGradient(elevations [][]float64, i, j int) (float64, float64) {
+	width := len(elevations)
+	height := len(elevations[0])
+	left := elevations[maxInt(i-1, 0)][j]
+	right := elevations[minInt(i+1, width-1)][j]
+	up := elevations[i][maxInt(j-1, 0)]
+	down := elevations[i][minInt(j+1, height-1)]
+	return (right - left) / 2, (down - up) / 2
+}
+
+// shadeColor multiplies a color's channels by a factor.
+func shadeColor(c color.RGBA, factor float64) color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8(math.Max(0, math.Min(255, float64(v)*factor)))
+	}
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), c.A}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,206 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Reconstructed elevations for water pixels, which don't record how deep
+// the water was.
+const (
+	importWaterElevation     = -0.2
+	importDeepWaterElevation = -0.5
+)
+
+// minImportRoutePixels is the number of route pixels that a tile needs to
+// be imported as a route. It is low enough to catch thin autotiled routes.
+const minImportRoutePixels = 12
+
+// importPixel is what a single pixel of a rendered map was drawn as.
+type importPixel struct {
+	band  terrainBand
+	route bool
+	city  layerPixel
+}
+
+// ImportRegionMap reconstructs a region map from an image that was rendered
+// with the given palette, like an old export. scale is the factor the image
+// was enlarged by, or 1 for an image at its original size.
+//
+// Rendering loses detail, so the result is approximate. Each pixel's
+// elevation is set to the middle of the elevation band its color belongs
+// to, and colors that aren't in the palette use the closest palette color.
+// Cities and routes are found by tile, but the route connections between
+// cities are not restored.
+func ImportRegionMap(img image.Image, palette Palette, scale int) (RegionMap, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	bounds := img.Bounds()
+	width := bounds.Dx() / scale
+	height := bounds.Dy() / scale
+	if width < 8 || height < 8 {
+		return RegionMap{}, fmt.Errorf("Image is too small to import, at %dx%d pixels", width, height)
+	}
+
+	candidates := getImportCandidates(palette)
+	pixels := make([][]importPixel, width)
+	elevations := getNewElevationMap(width, height)
+	for i := range pixels {
+		pixels[i] = make([]importPixel, height)
+		for j := range pixels[i] {
+			c := color.RGBAModel.Convert(img.At(bounds.Min.X+i*scale, bounds.Min.Y+j*scale)).(color.RGBA)
+			p := getClosestImportPixel(c, candidates)
+			pixels[i][j] = p
+			elevations[i][j] = getImportElevation(p.band)
+		}
+	}
+
+	regionMap := RegionMap{
+		PixelWidth:  width,
+		PixelHeight: height,
+		Elevations:  elevations,
+		Cities:      []Tile{},
+		Routes:      []Tile{},
+	}
+	for j := 0; j < height/8; j++ {
+		for i := 0; i < width/8; i++ {
+			t := Tile{i, j}
+			routePixels := 0
+			isCity := false
+			for x := 0; x < 8; x++ {
+				for y := 0; y < 8; y++ {
+					p := pixels[i*8+x][j*8+y]
+					if p.route {
+						routePixels++
+					}
+					if p.city == layerCityFill {
+						isCity = true
+					}
+				}
+			}
+			if isCity {
+				regionMap.Cities = append(regionMap.Cities, t)
+				if kind := getImportCityKind(pixels, t); kind != CityTown {
+					if regionMap.CityKinds == nil {
+						regionMap.CityKinds = map[Tile]CityKind{}
+					}
+					regionMap.CityKinds[t] = kind
+				}
+				fillImportCityElevations(elevations, pixels, t)
+			} else if routePixels >= minImportRoutePixels {
+				regionMap.Routes = append(regionMap.Routes, t)
+			}
+		}
+	}
+	return regionMap, nil
+}
+
+// importCandidate is a palette color, and what it is drawn for.
+type importCandidate struct {
+	color color.RGBA
+	pixel importPixel
+}
+
+// getImportCandidates lists every color of the palette. Earlier colors
+// take priority when a palette uses the same color for several things.
+func getImportCandidates(p Palette) []importCandidate {
+	candidates := []importCandidate{
+		{p.City, importPixel{city: layerCityFill}},
+		{colorCityOutline, importPixel{city: layerCityOutline}},
+	}
+	for i, c := range p.Land {
+		candidates = append(candidates, importCandidate{c, importPixel{band: terrainBand{index: i}}})
+	}
+	for i := range p.Water {
+		band := terrainBand{water: true, index: i}
+		candidates = append(candidates, importCandidate{p.Water[i], importPixel{band: band}})
+		band.deep = true
+		candidates = append(candidates, importCandidate{p.terrainColor(band), importPixel{band: band}})
+	}
+	for i, c := range p.RouteLand {
+		candidates = append(candidates, importCandidate{c, importPixel{band: terrainBand{index: i}, route: true}})
+	}
+	for i, c := range p.RouteWater {
+		candidates = append(candidates, importCandidate{c, importPixel{band: terrainBand{water: true, index: i}, route: true}})
+	}
+	return candidates
+}
+
+func getClosestImportPixel(c color.RGBA, candidates []importCandidate) importPixel {
+	best := candidates[0].pixel
+	bestDistance := -1
+	for _, candidate := range candidates {
+		dr := int(c.R) - int(candidate.color.R)
+		dg := int(c.G) - int(candidate.color.G)
+		db := int(c.B) - int(candidate.color.B)
+		distance := dr*dr + dg*dg + db*db
+		if bestDistance < 0 || distance < bestDistance {
+			best = candidate.pixel
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// getImportElevation returns the elevation in the middle of a terrain band.
+func getImportElevation(band terrainBand) float64 {
+	if band.water && band.deep {
+		return importDeepWaterElevation
+	}
+	if band.water {
+		return importWaterElevation
+	}
+	level := elevationBandLevels[band.index]
+	next := level + 0.25
+	if band.index+1 < len(elevationBandLevels) {
+		next = elevationBandLevels[band.index+1]
+	}
+	return (level + next) / 2
+}
+
+// getImportCityKind finds the kind of city whose marker matches a city
+// tile's pixels. Tiles that don't match any marker are towns.
+func getImportCityKind(pixels [][]importPixel, t Tile) CityKind {
+	for kind := CityTown; kind <= CityLeague; kind++ {
+		matches := true
+		for x := 0; x < 8 && matches; x++ {
+			for y := 0; y < 8 && matches; y++ {
+				matches = pixels[t.X*8+x][t.Y*8+y].city == getCityMarkerPixel(kind, x, y)
+			}
+		}
+		if matches {
+			return kind
+		}
+	}
+	return CityTown
+}
+
+// fillImportCityElevations sets the elevation of the pixels covered by a
+// city marker, whose terrain is hidden, to the average elevation of the
+// tile's visible terrain. When the marker covers the whole tile, the
+// lowest land band is used.
+func fillImportCityElevations(elevations [][]float64, pixels [][]importPixel, t Tile) {
+	total := 0.0
+	count := 0
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if pixels[t.X*8+x][t.Y*8+y].city == layerEmpty {
+				total += elevations[t.X*8+x][t.Y*8+y]
+				count++
+			}
+		}
+	}
+	elevation := getImportElevation(terrainBand{index: 0})
+	if count > 0 && total/float64(count) > 0 {
+		elevation = total / float64(count)
+	}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if pixels[t.X*8+x][t.Y*8+y].city != layerEmpty {
+				elevations[t.X*8+x][t.Y*8+y] = elevation
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package porygion
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestImportRegionMap(t *testing.T) {
+	regionMap := testRegionMap(t)
+	regionMap.CityNames = nil
+	img := RenderRegionMap(regionMap, RenderOptions{Scale: 2})
+	imported, err := ImportRegionMap(img, DefaultPalette(), 2)
+	if err != nil {
+		t.Fatalf("ImportRegionMap: %s", err)
+	}
+	if imported.PixelWidth != regionMap.PixelWidth || imported.PixelHeight != regionMap.PixelHeight {
+		t.Errorf("imported map is %dx%d, want %dx%d", imported.PixelWidth, imported.PixelHeight, regionMap.PixelWidth, regionMap.PixelHeight)
+	}
+	tileSet := func(tiles []Tile) map[Tile]bool {
+		set := map[Tile]bool{}
+		for _, t := range tiles {
+			set[t] = true
+		}
+		return set
+	}
+	if cities := tileSet(imported.Cities); !reflect.DeepEqual(cities, tileSet(regionMap.Cities)) {
+		t.Errorf("imported cities %v, want %v", imported.Cities, regionMap.Cities)
+	}
+	if !reflect.DeepEqual(imported.CityKinds, regionMap.CityKinds) {
+		t.Errorf("imported city kinds %v, want %v", imported.CityKinds, regionMap.CityKinds)
+	}
+	// Routes run into the cities, but city tiles are only imported as
+	// cities.
+	routes := tileSet(regionMap.Routes)
+	for _, city := range regionMap.Cities {
+		delete(routes, city)
+	}
+	if imported := tileSet(imported.Routes); !reflect.DeepEqual(imported, routes) {
+		t.Errorf("imported routes %v, want %v", imported, routes)
+	}
+
+	// Away from the cities, the terrain renders the same as the original,
+	// since every pixel keeps its elevation band.
+	cities := tileSet(regionMap.Cities)
+	want := RenderTerrainLayer(regionMap, RenderOptions{})
+	got := RenderTerrainLayer(imported, RenderOptions{})
+	for x := 0; x < regionMap.PixelWidth; x++ {
+		for y := 0; y < regionMap.PixelHeight; y++ {
+			if !cities[Tile{x / 8, y / 8}] && got.At(x, y) != want.At(x, y) {
+				t.Fatalf("imported terrain at (%d, %d) is %v, want %v", x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+
+	if _, err := ImportRegionMap(image.NewRGBA(image.Rect(0, 0, 14, 14)), DefaultPalette(), 2); err == nil {
+		t.Errorf("ImportRegionMap of a tiny image succeeded")
+	}
+}
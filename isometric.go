@@ -0,0 +1,102 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+)
+
+// Isometric tile dimensions, in pixels.
+const (
+	isoTileWidth  = 16
+	isoTileHeight = 8
+	// isoStepHeight is the height that each elevation band is extruded by.
+	isoStepHeight = 4
+)
+
+// Shading factors for the two visible sides of each isometric block.
+const (
+	isoLeftShade  = 0.7
+	isoRightShade = 0.85
+)
+
+// RenderIsometricRegionMap renders a region map in an isometric projection.
+// Each tile is drawn as a block, extruded by its elevation band, with routes
+// and cities drawn on top of the blocks.
+func RenderIsometricRegionMap(regionMap RegionMap, opts RenderOptions) image.Image {
+	palette := getRenderPalette(opts)
+	elevations := regionMap.Elevations
+	tilesWidth := len(elevations) / 8
+	tilesHeight := len(elevations[0]) / 8
+	maxLevel := len(palette.Land)
+	width := (tilesWidth + tilesHeight) * isoTileWidth / 2
+	height := (tilesWidth+tilesHeight)*isoTileHeight/2 + (maxLevel+1)*isoStepHeight
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	routes := map[Tile]bool{}
+	for _, t := range regionMap.Routes {
+		routes[t] = true
+	}
+	cities := map[Tile]bool{}
+	for _, t := range regionMap.Cities {
+		cities[t] = true
+	}
+
+	// Draw the blocks from back to front, so that nearer blocks cover the
+	// ones behind them.
+	for sum := 0; sum < tilesWidth+tilesHeight-1; sum++ {
+		for tx := 0; tx < tilesWidth; tx++ {
+			ty := sum - tx
+			if ty < 0 || ty >= tilesHeight {
+				continue
+			}
+			t := Tile{tx, ty}
+			band := getDominantTerrainBand(elevations, t)
+			level := 0
+			if !band.water {
+				level = band.index + 1
+			}
+			var top color.RGBA
+			switch {
+			case cities[t]:
+				top = palette.City
+			case routes[t]:
+				top = palette.routeColor(band)
+			default:
+				top = palette.terrainColor(band)
+			}
+			cx := (tx-ty)*isoTileWidth/2 + tilesHeight*isoTileWidth/2
+			cy := (tx+ty)*isoTileHeight/2 + isoTileHeight/2 + (maxLevel-level)*isoStepHeight
+			drawIsometricBlock(img, cx, cy, (level+1)*isoStepHeight, top)
+		}
+	}
+	return img
+}
+
+// drawIsometricBlock draws a block whose top face is a diamond centered at
+// (cx, cy), with sides extending downward by the given height.
+func drawIsometricBlock(img *image.RGBA, cx, cy, sideHeight int, top color.RGBA) {
+	left := shadeColor(top, isoLeftShade)
+	right := shadeColor(top, isoRightShade)
+	halfWidth := isoTileWidth / 2
+	halfHeight := isoTileHeight / 2
+	for dx := -halfWidth; dx < halfWidth; dx++ {
+		// Distance from the diamond's vertical center line, measured at
+		// the center of the pixel column.
+		offset := dx
+		if dx < 0 {
+			offset = -dx - 1
+		}
+		faceHalf := halfHeight - offset*halfHeight/halfWidth
+		x := cx + dx
+		for y := cy - faceHalf; y < cy+faceHalf; y++ {
+			img.SetRGBA(x, y, top)
+		}
+		side := right
+		if dx < 0 {
+			side = left
+		}
+		for y := cy + faceHalf; y < cy+faceHalf+sideHeight; y++ {
+			img.SetRGBA(x, y, side)
+		}
+	}
+}
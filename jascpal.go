@@ -0,0 +1,44 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// WriteJASCPalette writes colors as a JASC-PAL palette file, which is the
+// text palette format used by decomp projects. Lines end with CRLF, like
+// the files written by Paint Shop Pro.
+func WriteJASCPalette(w io.Writer, colors color.Palette) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "JASC-PAL\r\n0100\r\n%d\r\n", len(colors))
+	for _, c := range colors {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		fmt.Fprintf(bw, "%d %d %d\r\n", rgba.R, rgba.G, rgba.B)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write JASC palette: %s", err)
+	}
+	return nil
+}
+
+// WriteGBAPalette writes colors in the GBA's binary palette format, with
+// one little-endian 15-bit BGR color per entry. Each channel keeps only
+// its top five bits, as on the GBA.
+func WriteGBAPalette(w io.Writer, colors color.Palette) error {
+	data := make([]byte, 0, len(colors)*2)
+	for _, c := range colors {
+		v := getBGR555(color.RGBAModel.Convert(c).(color.RGBA))
+		data = append(data, byte(v), byte(v>>8))
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("Failed to write GBA palette: %s", err)
+	}
+	return nil
+}
+
+// getBGR555 converts a color to the GBA's 15-bit BGR format.
+func getBGR555(c color.RGBA) uint16 {
+	return uint16(c.R>>3) | uint16(c.G>>3)<<5 | uint16(c.B>>3)<<10
+}
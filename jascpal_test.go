@@ -0,0 +1,29 @@
+package porygion
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestWriteJASCPalette(t *testing.T) {
+	var buf bytes.Buffer
+	colors := color.Palette{color.RGBA{255, 0, 0, 255}, color.Gray{128}}
+	if err := WriteJASCPalette(&buf, colors); err != nil {
+		t.Fatalf("WriteJASCPalette: %s", err)
+	}
+	if want := "JASC-PAL\r\n0100\r\n2\r\n255 0 0\r\n128 128 128\r\n"; buf.String() != want {
+		t.Errorf("WriteJASCPalette wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteGBAPalette(t *testing.T) {
+	var buf bytes.Buffer
+	colors := color.Palette{color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 8, 255}, color.RGBA{7, 7, 7, 255}}
+	if err := WriteGBAPalette(&buf, colors); err != nil {
+		t.Fatalf("WriteGBAPalette: %s", err)
+	}
+	if want := []byte{0x1F, 0x00, 0xE0, 0x07, 0x00, 0x00}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteGBAPalette wrote % x, want % x", buf.Bytes(), want)
+	}
+}
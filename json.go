@@ -0,0 +1,265 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// regionMapJSONVersion is the version of the JSON format written by
+// MarshalJSON. It is increased whenever the format changes in a way that
+// older versions can't read.
+const regionMapJSONVersion = 2
+
+// jsonRegionMap is the JSON representation of a region map. Its keys are
+// snake_case, like the keys of the other JSON exports.
+type jsonRegionMap struct {
+	Version     int                   `json:"version"`
+	Seed        int64                 `json:"seed"`
+	PixelWidth  int                   `json:"pixel_width"`
+	PixelHeight int                   `json:"pixel_height"`
+	Elevations  [][]float64           `json:"elevations"`
+	Cities      []jsonCity            `json:"cities"`
+	Routes      []jsonTile            `json:"routes"`
+	Connections []jsonRouteConnection `json:"connections"`
+}
+
+// jsonTile is the JSON representation of a tile.
+type jsonTile struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// jsonCity is a city, along with its optional name and kind.
+type jsonCity struct {
+	X    int      `json:"x"`
+	Y    int      `json:"y"`
+	Name string   `json:"name,omitempty"`
+	Kind CityKind `json:"kind,omitempty"`
+}
+
+// jsonRouteConnection is the JSON representation of a route connection.
+type jsonRouteConnection struct {
+	CityA       jsonTile   `json:"city_a"`
+	CityB       jsonTile   `json:"city_b"`
+	Tiles       []jsonTile `json:"tiles"`
+	Class       RouteClass `json:"class"`
+	VictoryRoad bool       `json:"victory_road"`
+}
+
+// legacyJSONRegionMap is the layout of versions 0 and 1 of the JSON
+// format, which used the Go field names as keys. Version 0 is the layout
+// that encoding/json wrote before the format was versioned, which has
+// no version or seed.
+type legacyJSONRegionMap struct {
+	Seed        int64
+	PixelWidth  int
+	PixelHeight int
+	Elevations  [][]float64
+	Cities      []struct {
+		X, Y int
+		Name string
+		Kind CityKind
+	}
+	Routes      []Tile
+	Connections []RouteConnection
+}
+
+// MarshalJSON encodes the region map as JSON, including its elevations,
+// cities, routes, and the seed it was generated from.
+func (r RegionMap) MarshalJSON() ([]byte, error) {
+	cities := make([]jsonCity, len(r.Cities))
+	for i, city := range r.Cities {
+		cities[i] = jsonCity{
+			X:    city.X,
+			Y:    city.Y,
+			Name: r.CityNames[city],
+			Kind: r.CityKinds[city],
+		}
+	}
+	var connections []jsonRouteConnection
+	if r.Connections != nil {
+		connections = make([]jsonRouteConnection, len(r.Connections))
+		for i, c := range r.Connections {
+			connections[i] = jsonRouteConnection{
+				CityA:       jsonTile(c.CityA),
+				CityB:       jsonTile(c.CityB),
+				Tiles:       toJSONTiles(c.Tiles),
+				Class:       c.Class,
+				VictoryRoad: c.VictoryRoad,
+			}
+		}
+	}
+	return json.Marshal(jsonRegionMap{
+		Version:     regionMapJSONVersion,
+		Seed:        r.Seed,
+		PixelWidth:  r.PixelWidth,
+		PixelHeight: r.PixelHeight,
+		Elevations:  r.Elevations,
+		Cities:      cities,
+		Routes:      toJSONTiles(r.Routes),
+		Connections: connections,
+	})
+}
+
+// UnmarshalJSON decodes a region map that was encoded by MarshalJSON, by
+// an older version of it, or by encoding/json before the format was
+// versioned.
+func (r *RegionMap) UnmarshalJSON(data []byte) error {
+	// The version's key is matched case-insensitively, so this reads the
+	// version of both layouts.
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Version < 0 || header.Version > regionMapJSONVersion {
+		return fmt.Errorf("Unsupported region map JSON version %d", header.Version)
+	}
+	var j jsonRegionMap
+	if header.Version < 2 {
+		var legacy legacyJSONRegionMap
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		j = jsonRegionMap{
+			Seed:        legacy.Seed,
+			PixelWidth:  legacy.PixelWidth,
+			PixelHeight: legacy.PixelHeight,
+			Elevations:  legacy.Elevations,
+			Cities:      make([]jsonCity, len(legacy.Cities)),
+			Routes:      toJSONTiles(legacy.Routes),
+		}
+		for i, c := range legacy.Cities {
+			j.Cities[i] = jsonCity{c.X, c.Y, c.Name, c.Kind}
+		}
+		if legacy.Connections != nil {
+			j.Connections = make([]jsonRouteConnection, len(legacy.Connections))
+			for i, c := range legacy.Connections {
+				j.Connections[i] = jsonRouteConnection{jsonTile(c.CityA), jsonTile(c.CityB), toJSONTiles(c.Tiles), c.Class, c.VictoryRoad}
+			}
+		}
+	} else if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if err := validateElevations(j.Elevations, j.PixelWidth, j.PixelHeight); err != nil {
+		return err
+	}
+	regionMap := RegionMap{
+		Seed:        j.Seed,
+		PixelWidth:  j.PixelWidth,
+		PixelHeight: j.PixelHeight,
+		Elevations:  j.Elevations,
+		Cities:      make([]Tile, len(j.Cities)),
+		Routes:      fromJSONTiles(j.Routes),
+	}
+	if j.Connections != nil {
+		regionMap.Connections = make([]RouteConnection, len(j.Connections))
+		for i, c := range j.Connections {
+			regionMap.Connections[i] = RouteConnection{
+				CityA:       Tile(c.CityA),
+				CityB:       Tile(c.CityB),
+				Tiles:       fromJSONTiles(c.Tiles),
+				Class:       c.Class,
+				VictoryRoad: c.VictoryRoad,
+			}
+		}
+	}
+	for i, c := range j.Cities {
+		city := Tile{c.X, c.Y}
+		regionMap.Cities[i] = city
+		if c.Name != "" {
+			if regionMap.CityNames == nil {
+				regionMap.CityNames = map[Tile]string{}
+			}
+			regionMap.CityNames[city] = c.Name
+		}
+		if c.Kind != CityTown {
+			if regionMap.CityKinds == nil {
+				regionMap.CityKinds = map[Tile]CityKind{}
+			}
+			regionMap.CityKinds[city] = c.Kind
+		}
+	}
+	*r = regionMap
+	return nil
+}
+
+// toJSONTiles converts tiles to their JSON representation. A nil slice
+// stays nil, so that it round-trips as null.
+func toJSONTiles(tiles []Tile) []jsonTile {
+	if tiles == nil {
+		return nil
+	}
+	out := make([]jsonTile, len(tiles))
+	for i, t := range tiles {
+		out[i] = jsonTile(t)
+	}
+	return out
+}
+
+// fromJSONTiles converts tiles from their JSON representation.
+func fromJSONTiles(tiles []jsonTile) []Tile {
+	if tiles == nil {
+		return nil
+	}
+	out := make([]Tile, len(tiles))
+	for i, t := range tiles {
+		out[i] = Tile(t)
+	}
+	return out
+}
+
+// validateElevations checks that an elevation map has the given size.
+func validateElevations(elevations [][]float64, pixelWidth, pixelHeight int) error {
+	if pixelWidth <= 0 || pixelHeight <= 0 {
+		return fmt.Errorf("Invalid region map size %dx%d", pixelWidth, pixelHeight)
+	}
+	if len(elevations) != pixelWidth {
+		return fmt.Errorf("Region map has %d elevation columns, but its width is %d", len(elevations), pixelWidth)
+	}
+	for i, column := range elevations {
+		if len(column) != pixelHeight {
+			return fmt.Errorf("Elevation column %d has %d values, but the region map's height is %d", i, len(column), pixelHeight)
+		}
+	}
+	return nil
+}
+
+// MarshalText encodes the city kind as its name.
+func (k CityKind) MarshalText() ([]byte, error) {
+	if k < CityTown || k > CityLeague {
+		return nil, fmt.Errorf("Invalid city kind %d", int(k))
+	}
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a city kind from its name.
+func (k *CityKind) UnmarshalText(text []byte) error {
+	for kind := CityTown; kind <= CityLeague; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown city kind %q", text)
+}
+
+// MarshalText encodes the route class as its name.
+func (c RouteClass) MarshalText() ([]byte, error) {
+	if c < RouteTrunk || c > RouteSpur {
+		return nil, fmt.Errorf("Invalid route class %d", int(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a route class from its name.
+func (c *RouteClass) UnmarshalText(text []byte) error {
+	for class := RouteTrunk; class <= RouteSpur; class++ {
+		if class.String() == string(text) {
+			*c = class
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown route class %q", text)
+}
@@ -0,0 +1,121 @@
+package porygion
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRegionMapJSONRoundTrip(t *testing.T) {
+	regionMap := testRegionMap(t)
+	data, err := regionMap.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %s", err)
+	}
+	var decoded RegionMap
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, regionMap) {
+		t.Errorf("decoded region map differs from the original")
+	}
+	if !bytes.Contains(data, []byte(`"pixel_width":96`)) {
+		t.Errorf("MarshalJSON doesn't use snake_case keys: %.80s", data)
+	}
+	if decoded.Fingerprint() != regionMap.Fingerprint() {
+		t.Errorf("Fingerprint() = %s, want %s", decoded.Fingerprint(), regionMap.Fingerprint())
+	}
+}
+
+func TestRegionMapUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    RegionMap
+		wantErr bool
+	}{
+		{
+			name: "legacy unversioned layout",
+			data: `{"PixelWidth":2,"PixelHeight":1,"Elevations":[[0.5],[-0.25]],` +
+				`"Cities":[{"X":0,"Y":0},{"X":1,"Y":0}],"Routes":[],` +
+				`"Connections":[{"CityA":{"X":0,"Y":0},"CityB":{"X":1,"Y":0},"Tiles":[]}],` +
+				`"CityNames":null,"CityKinds":null}`,
+			want: RegionMap{
+				PixelWidth:  2,
+				PixelHeight: 1,
+				Elevations:  [][]float64{{0.5}, {-0.25}},
+				Cities:      []Tile{{0, 0}, {1, 0}},
+				Routes:      []Tile{},
+				Connections: []RouteConnection{{CityA: Tile{0, 0}, CityB: Tile{1, 0}, Tiles: []Tile{}}},
+			},
+		},
+		{
+			name: "version 1",
+			data: `{"Version":1,"Seed":42,"PixelWidth":1,"PixelHeight":2,"Elevations":[[0,1]],` +
+				`"Cities":[{"X":0,"Y":1,"Name":"Pallet","Kind":"port"}],"Routes":null,"Connections":null}`,
+			want: RegionMap{
+				Seed:        42,
+				PixelWidth:  1,
+				PixelHeight: 2,
+				Elevations:  [][]float64{{0, 1}},
+				Cities:      []Tile{{0, 1}},
+				CityNames:   map[Tile]string{{0, 1}: "Pallet"},
+				CityKinds:   map[Tile]CityKind{{0, 1}: CityPort},
+			},
+		},
+		{
+			name: "version 2",
+			data: `{"version":2,"seed":-5,"pixel_width":2,"pixel_height":1,"elevations":[[0.5],[-0.25]],` +
+				`"cities":[{"x":0,"y":0,"kind":"league"},{"x":1,"y":0,"name":"Route's End"}],"routes":null,` +
+				`"connections":[{"city_a":{"x":0,"y":0},"city_b":{"x":1,"y":0},"tiles":[],"class":"spur","victory_road":true}]}`,
+			want: RegionMap{
+				Seed:        -5,
+				PixelWidth:  2,
+				PixelHeight: 1,
+				Elevations:  [][]float64{{0.5}, {-0.25}},
+				Cities:      []Tile{{0, 0}, {1, 0}},
+				Connections: []RouteConnection{{CityA: Tile{0, 0}, CityB: Tile{1, 0}, Tiles: []Tile{}, Class: RouteSpur, VictoryRoad: true}},
+				CityNames:   map[Tile]string{{1, 0}: "Route's End"},
+				CityKinds:   map[Tile]CityKind{{0, 0}: CityLeague},
+			},
+		},
+		{
+			name:    "version 2 with the legacy keys",
+			data:    `{"version":2,"PixelWidth":1,"PixelHeight":1,"Elevations":[[0]]}`,
+			wantErr: true,
+		},
+		{
+			name:    "future version",
+			data:    `{"Version":99,"PixelWidth":1,"PixelHeight":1,"Elevations":[[0]]}`,
+			wantErr: true,
+		},
+		{
+			name:    "elevations don't match the size",
+			data:    `{"Version":1,"PixelWidth":2,"PixelHeight":1,"Elevations":[[0]]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown city kind",
+			data:    `{"Version":1,"PixelWidth":1,"PixelHeight":1,"Elevations":[[0]],"Cities":[{"X":0,"Y":0,"Kind":"castle"}]}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got RegionMap
+			err := got.UnmarshalJSON([]byte(test.data))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("UnmarshalJSON succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalJSON: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("UnmarshalJSON = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// LabelOptions controls how city names are drawn onto the rendered map.
+type LabelOptions struct {
+	// Face is the font used to draw the labels. When nil, a small built-in
+	// bitmap font is used.
+	Face  font.Face  `json:"-"`
+	Color color.RGBA `json:"color"`
+}
+
+// DefaultLabelOptions returns white labels drawn in the built-in font.
+func DefaultLabelOptions() LabelOptions {
+	return LabelOptions{
+		Face:  basicfont.Face7x13,
+		Color: color.RGBA{255, 255, 255, 255},
+	}
+}
+
+// LoadLabelFont parses a TrueType font, so that it can be used to draw
+// labels at the given point size.
+func LoadLabelFont(ttf []byte, size float64) (font.Face, error) {
+	f, err := truetype.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse label font: %s", err)
+	}
+	return truetype.NewFace(f, &truetype.Options{Size: size}), nil
+}
+
+// drawCityLabels draws each named city's name next to its square. Each
+// label tries several positions around its city, and uses the first one
+// that stays on the image without overlapping another label or city.
+func drawCityLabels(img *image.RGBA, regionMap RegionMap, opts LabelOptions, scale int) {
+	face := opts.Face
+	if face == nil {
+		face = basicfont.Face7x13
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	metrics := face.Metrics()
+	ascent := metrics.Ascent.Ceil()
+	textHeight := ascent + metrics.Descent.Ceil()
+	tileSize := 8 * scale
+	gap := scale
+
+	occupied := []image.Rectangle{}
+	for _, city := range regionMap.Cities {
+		occupied = append(occupied, image.Rect(city.X*tileSize, city.Y*tileSize, (city.X+1)*tileSize, (city.Y+1)*tileSize))
+	}
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(opts.Color),
+		Face: face,
+	}
+	for i, city := range regionMap.Cities {
+		name, ok := regionMap.CityNames[city]
+		if !ok || name == "" {
+			continue
+		}
+		textWidth := drawer.MeasureString(name).Ceil()
+		square := occupied[i]
+		middleY := square.Min.Y + (tileSize-textHeight)/2
+		middleX := square.Min.X + (tileSize-textWidth)/2
+		candidates := []image.Point{
+			{square.Max.X + gap, middleY},
+			{square.Min.X - gap - textWidth, middleY},
+			{middleX, square.Min.Y - gap - textHeight},
+			{middleX, square.Max.Y + gap},
+		}
+		var rect image.Rectangle
+		placed := false
+		for _, p := range candidates {
+			rect = image.Rect(p.X, p.Y, p.X+textWidth, p.Y+textHeight)
+			if rect.In(img.Bounds()) && !overlapsAny(rect, occupied) {
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			continue
+		}
+		occupied = append(occupied, rect)
+		drawer.Dot = fixed.P(rect.Min.X, rect.Min.Y+ascent)
+		drawer.DrawString(name)
+	}
+}
+
+func overlapsAny(rect image.Rectangle, others []image.Rectangle) bool {
+	for _, o := range others {
+		if rect.Overlaps(o) {
+			return true
+		}
+	}
+	return false
+}
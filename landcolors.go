@@ -0,0 +1,79 @@
+package porygion
+
+import (
+	"image/color"
+	"sort"
+)
+
+// elevationBandLevels are the elevations above which each land shade is
+// used, from the lowest to the highest.
+var elevationBandLevels = [5]float64{0, 0.35, 0.60, 0.85, 1.10}
+
+// ElevationStop is a land color that is used above a given elevation.
+type ElevationStop struct {
+	Elevation float64    `json:"elevation"`
+	Color     color.RGBA `json:"color"`
+}
+
+// LandColorOptions controls how land is colored by its elevation. Routes
+// keep using the palette's route shades, which follow the standard bands.
+type LandColorOptions struct {
+	// Stops are the land colors and the elevations they start at. They
+	// may be given in any order. Land below the lowest stop uses the
+	// lowest stop's color.
+	Stops []ElevationStop `json:"stops"`
+	// Gradient blends the colors between neighboring stops, instead of
+	// drawing hard bands.
+	Gradient bool `json:"gradient"`
+}
+
+// DefaultLandColorOptions returns stops that match the standard elevation
+// bands, using the land shades from the given palette.
+func DefaultLandColorOptions(palette Palette) LandColorOptions {
+	stops := make([]ElevationStop, len(elevationBandLevels))
+	for i, level := range elevationBandLevels {
+		stops[i] = ElevationStop{Elevation: level, Color: palette.Land[i]}
+	}
+	return LandColorOptions{Stops: stops}
+}
+
+// elevationStops is a list of stops sorted by ascending elevation.
+type elevationStops []ElevationStop
+
+func sortElevationStops(stops []ElevationStop) elevationStops {
+	sorted := make(elevationStops, len(stops))
+	copy(sorted, stops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Elevation < sorted[j].Elevation
+	})
+	return sorted
+}
+
+// color returns the land color for the given elevation.
+func (s elevationStops) color(elevation float64, gradient bool) color.RGBA {
+	lower, upper, t := s.span(elevation)
+	if !gradient {
+		return lower
+	}
+	return lerpColor(lower, upper, t)
+}
+
+// span returns the colors of the stops on either side of the given
+// elevation, and how far the elevation is between them, from 0 to 1.
+// Elevations outside of the stops use the nearest stop's color on both
+// sides.
+func (s elevationStops) span(elevation float64) (color.RGBA, color.RGBA, float64) {
+	upper := sort.Search(len(s), func(i int) bool {
+		return s[i].Elevation >= elevation
+	})
+	if upper == 0 {
+		return s[0].Color, s[0].Color, 0
+	}
+	if upper == len(s) {
+		last := s[len(s)-1].Color
+		return last, last, 0
+	}
+	lower := s[upper-1]
+	t := (elevation - lower.Elevation) / (s[upper].Elevation - lower.Elevation)
+	return lower.Color, s[upper].Color, t
+}
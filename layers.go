@@ -0,0 +1,103 @@
+package porygion
+
+import "image"
+
+// layerPixel describes how a feature layer covers a single pixel.
+type layerPixel uint8
+
+const (
+	layerEmpty layerPixel = iota
+	layerRoute
+	layerRouteCasing
+	layerCityFill
+	layerCityOutline
+)
+
+// getRouteMask returns the route layer of a region map, which marks every
+// pixel covered by a route, and its casing when enabled. Each pixel is
+// marked at most once, no matter how many routes pass over it.
+func getRouteMask(regionMap RegionMap, opts RenderOptions) [][]layerPixel {
+	mask := newLayerMask(regionMap)
+	narrowRoutes := map[Tile]bool{}
+	if opts.NarrowSpurRoutes {
+		narrowRoutes = getSpurOnlyRouteTiles(regionMap.Connections)
+	}
+	network := map[Tile]bool{}
+	for _, route := range regionMap.Routes {
+		network[route] = true
+	}
+	for _, city := range regionMap.Cities {
+		network[city] = true
+	}
+	for _, route := range regionMap.Routes {
+		thin := opts.AutotileRoutes || narrowRoutes[route]
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				if thin && !isAutotileRoutePixel(route, i, j, network) {
+					continue
+				}
+				setLayerPixel(mask, route.X*8+i, route.Y*8+j, layerRoute)
+			}
+		}
+	}
+	if opts.RouteCasing != nil {
+		applyRouteCasing(mask, regionMap.Cities)
+	}
+	return mask
+}
+
+// getCityMask returns the city layer of a region map, which marks every
+// pixel covered by a city marker.
+func getCityMask(regionMap RegionMap) [][]layerPixel {
+	mask := newLayerMask(regionMap)
+	for _, city := range regionMap.Cities {
+		kind := regionMap.CityKinds[city]
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				if p := getCityMarkerPixel(kind, i, j); p != layerEmpty {
+					setLayerPixel(mask, city.X*8+i, city.Y*8+j, p)
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// compositeLayers draws the route and city layers over the terrain that is
+// already in the image. Cities take priority over routes, and routes take
+// priority over terrain. Route colors are chosen from the elevation beneath
+// them, rather than from the image, so the result never depends on what
+// was previously drawn. Either layer may be nil.
+func compositeLayers(img *image.RGBA, elevations [][]float64, routes, cities [][]layerPixel, palette Palette, opts RenderOptions) {
+	for i := range elevations {
+		for j := range elevations[i] {
+			switch {
+			case cities != nil && cities[i][j] == layerCityFill:
+				img.SetRGBA(i, j, palette.City)
+			case cities != nil && cities[i][j] == layerCityOutline:
+				img.SetRGBA(i, j, colorCityOutline)
+			case routes != nil && routes[i][j] == layerRoute:
+				img.SetRGBA(i, j, palette.routeColor(getTerrainBand(elevations[i][j], j+opts.waterPhase)))
+			case routes != nil && routes[i][j] == layerRouteCasing:
+				c := palette.routeColor(getTerrainBand(elevations[i][j], j+opts.waterPhase))
+				img.SetRGBA(i, j, shadeColor(c, opts.RouteCasing.Shade))
+			}
+		}
+	}
+}
+
+func newLayerMask(regionMap RegionMap) [][]layerPixel {
+	mask := make([][]layerPixel, len(regionMap.Elevations))
+	for i := range mask {
+		mask[i] = make([]layerPixel, len(regionMap.Elevations[i]))
+	}
+	return mask
+}
+
+// setLayerPixel marks a pixel in a layer mask, ignoring pixels that are
+// outside of the map.
+func setLayerPixel(mask [][]layerPixel, x, y int, p layerPixel) {
+	if x >= 0 && x < len(mask) && y >= 0 && y < len(mask[x]) {
+		mask[x][y] = p
+	}
+}
@@ -0,0 +1,110 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Legend layout, in pixels.
+const (
+	legendPadding    = 4
+	legendSwatchSize = 10
+	legendRowHeight  = 16
+	legendEntryGap   = 12
+)
+
+var (
+	legendBackground = color.RGBA{32, 32, 32, 255}
+	legendTextColor  = color.RGBA{255, 255, 255, 255}
+)
+
+// legendEntry is a single swatch in the legend. Swatches with two colors
+// alternate between them each row, like the water does.
+type legendEntry struct {
+	name   string
+	colors []color.RGBA
+}
+
+func getLegendEntries(palette Palette) []legendEntry {
+	return []legendEntry{
+		{"Water", palette.Water[:]},
+		{"Lowland", []color.RGBA{palette.Land[0]}},
+		{"Hills", []color.RGBA{palette.Land[1]}},
+		{"Highland", []color.RGBA{palette.Land[2]}},
+		{"Mountain", []color.RGBA{palette.Land[3]}},
+		{"Peak", []color.RGBA{palette.Land[4]}},
+		{"Route", []color.RGBA{palette.RouteLand[0]}},
+		{"Sea route", palette.RouteWater[:]},
+		{"City", []color.RGBA{palette.City}},
+	}
+}
+
+// RenderLegend renders a legend of the terrain, route, and city colors in
+// the given palette, laid out in a single row.
+func RenderLegend(palette Palette) image.Image {
+	return renderLegend(palette, 0)
+}
+
+// renderLegend renders a legend that wraps onto multiple rows, so that it
+// fits within the given width. A width of zero never wraps.
+func renderLegend(palette Palette, width int) *image.RGBA {
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{Face: face}
+	entries := getLegendEntries(palette)
+
+	// Lay out the entries first, so that the image size is known.
+	positions := make([]image.Point, len(entries))
+	x, y := legendPadding, legendPadding
+	maxX := 0
+	for i, e := range entries {
+		entryWidth := legendSwatchSize + legendPadding + drawer.MeasureString(e.name).Ceil()
+		if width > 0 && x > legendPadding && x+entryWidth+legendPadding > width {
+			x = legendPadding
+			y += legendRowHeight
+		}
+		positions[i] = image.Point{x, y}
+		x += entryWidth
+		if x > maxX {
+			maxX = x
+		}
+		x += legendEntryGap
+	}
+	if width <= 0 {
+		width = maxX + legendPadding
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, y+legendRowHeight+legendPadding))
+	draw.Draw(img, img.Bounds(), image.NewUniform(legendBackground), image.Point{}, draw.Src)
+
+	drawer.Dst = img
+	drawer.Src = image.NewUniform(legendTextColor)
+	ascent := face.Metrics().Ascent.Ceil()
+	for i, e := range entries {
+		p := positions[i]
+		swatchY := p.Y + (legendRowHeight-legendSwatchSize)/2
+		for sy := 0; sy < legendSwatchSize; sy++ {
+			c := e.colors[sy%len(e.colors)]
+			for sx := 0; sx < legendSwatchSize; sx++ {
+				img.SetRGBA(p.X+sx, swatchY+sy, c)
+			}
+		}
+		drawer.Dot = fixed.P(p.X+legendSwatchSize+legendPadding, p.Y+(legendRowHeight-face.Height)/2+ascent)
+		drawer.DrawString(e.name)
+	}
+	return img
+}
+
+// appendLegend returns a copy of the map image with a legend strip
+// added beneath it.
+func appendLegend(img *image.RGBA, palette Palette) *image.RGBA {
+	bounds := img.Bounds()
+	legend := renderLegend(palette, bounds.Dx())
+	result := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+legend.Bounds().Dy()))
+	draw.Draw(result, bounds.Sub(bounds.Min), img, bounds.Min, draw.Src)
+	draw.Draw(result, legend.Bounds().Add(image.Point{0, bounds.Dy()}), legend, image.Point{}, draw.Src)
+	return result
+}
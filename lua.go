@@ -0,0 +1,107 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteLua writes the region map as a Lua table literal, in a chunk that
+// returns the table, so that it can be loaded with require or dofile.
+// The terrain is a list of rows of indexes into tileKinds, from top to
+// bottom. Cities and routes are lists, and routes refer to their cities
+// by their index in the cities list. Tile positions are 0-based, like
+// the region map's, but the list indexes are 1-based, like Lua's.
+func WriteLua(w io.Writer, regionMap RegionMap) error {
+	tilesWidth, tilesHeight, err := getExportTileSize(regionMap)
+	if err != nil {
+		return err
+	}
+	layers := getTileLayers(regionMap)
+	sections := regionMap.MapSections()
+	cityIndexes := map[Tile]int{}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "return {\n")
+	fmt.Fprintf(bw, "  seed = %d,\n", regionMap.Seed)
+	fmt.Fprintf(bw, "  width = %d,\n", tilesWidth)
+	fmt.Fprintf(bw, "  height = %d,\n", tilesHeight)
+
+	names := make([]string, len(tiledTileNames))
+	for i, name := range tiledTileNames {
+		names[i] = luaQuote(name)
+	}
+	fmt.Fprintf(bw, "  tileKinds = {%s},\n", strings.Join(names, ", "))
+
+	fmt.Fprintf(bw, "  terrain = {\n")
+	row := make([]string, tilesWidth)
+	for j := 0; j < tilesHeight; j++ {
+		for i := range row {
+			row[i] = fmt.Sprint(layers.terrain[j*tilesWidth+i] + 1)
+		}
+		fmt.Fprintf(bw, "    {%s},\n", strings.Join(row, ", "))
+	}
+	fmt.Fprintf(bw, "  },\n")
+
+	fmt.Fprintf(bw, "  cities = {\n")
+	for i, city := range regionMap.Cities {
+		cityIndexes[city] = i + 1
+		s := sections[i]
+		fmt.Fprintf(bw, "    {id = %s, name = %s, kind = %s, x = %d, y = %d},\n",
+			luaQuote(s.ID), luaQuote(s.Name), luaQuote(regionMap.CityKinds[city].String()), city.X, city.Y)
+	}
+	fmt.Fprintf(bw, "  },\n")
+
+	fmt.Fprintf(bw, "  routes = {\n")
+	routeSections := sections[len(regionMap.Cities):]
+	for _, c := range regionMap.Connections {
+		if len(c.Tiles) == 0 {
+			continue
+		}
+		s := routeSections[0]
+		routeSections = routeSections[1:]
+		tiles := make([]string, len(c.Tiles))
+		for i, t := range c.Tiles {
+			tiles[i] = fmt.Sprintf("{x = %d, y = %d}", t.X, t.Y)
+		}
+		fmt.Fprintf(bw, "    {\n")
+		fmt.Fprintf(bw, "      id = %s,\n", luaQuote(s.ID))
+		fmt.Fprintf(bw, "      name = %s,\n", luaQuote(s.Name))
+		fmt.Fprintf(bw, "      from = %d,\n", cityIndexes[c.CityA])
+		fmt.Fprintf(bw, "      to = %d,\n", cityIndexes[c.CityB])
+		fmt.Fprintf(bw, "      class = %s,\n", luaQuote(c.Class.String()))
+		fmt.Fprintf(bw, "      victoryRoad = %t,\n", c.VictoryRoad)
+		fmt.Fprintf(bw, "      tiles = {%s},\n", strings.Join(tiles, ", "))
+		fmt.Fprintf(bw, "    },\n")
+	}
+	fmt.Fprintf(bw, "  },\n")
+	fmt.Fprintf(bw, "}\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write Lua table: %s", err)
+	}
+	return nil
+}
+
+// luaQuote returns a Lua string literal for a string. Control characters
+// are written as decimal escapes, which every version of Lua supports.
+func luaQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c < 0x20 || c == 0x7F:
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
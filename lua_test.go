@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLuaQuote(t *testing.T) {
+	tests := map[string]string{
+		"Pallet Town": `"Pallet Town"`,
+		`Say "Hi"\`:   `"Say \"Hi\"\\"`,
+		"a\nb\tc\x7F": `"a\nb\009c\127"`,
+	}
+	for s, want := range tests {
+		if got := luaQuote(s); got != want {
+			t.Errorf("luaQuote(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestWriteLua(t *testing.T) {
+	regionMap := testSectionsMap()
+	regionMap.Seed = 42
+	regionMap.PixelWidth, regionMap.PixelHeight = 96, 64
+	regionMap.Elevations = getNewElevationMap(96, 64)
+	var buf bytes.Buffer
+	if err := WriteLua(&buf, regionMap); err != nil {
+		t.Fatalf("WriteLua: %s", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"return {\n  seed = 42,\n  width = 12,\n  height = 8,\n",
+		`    {id = "MAPSEC_PORT_3", name = "PORT 3", kind = "port", x = 9, y = 5},`,
+		"      id = \"MAPSEC_ROUTE_102\",\n      name = \"ROUTE 102\",\n      from = 2,\n      to = 3,\n",
+		"      tiles = {{x = 2, y = 3}, {x = 3, y = 3}, {x = 4, y = 3}},\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteLua output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	// Every elevation is 0, which is water, the first tile kind.
+	if n := strings.Count(out, "    {1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},\n"); n != 8 {
+		t.Errorf("WriteLua wrote %d rows of water terrain, want 8:\n%s", n, out)
+	}
+	// The connection without tiles isn't a route.
+	if n := strings.Count(out, "victoryRoad"); n != 2 {
+		t.Errorf("WriteLua wrote %d routes, want 2", n)
+	}
+}
@@ -0,0 +1,107 @@
+package porygion
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// generatorVersion identifies the generation algorithm. It is increased
+// whenever a change to the generator makes the same parameters produce a
+// different map, so that old map codes are rejected rather than silently
+// producing the wrong map.
+const generatorVersion = 1
+
+// mapCodeGroupSize is the number of characters between the dashes of a map
+// code, which make it easier to read out and type.
+const mapCodeGroupSize = 4
+
+var mapCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MapParams are the parameters that a region map is generated from.
+// Generating with the same parameters always produces the same map. They
+// don't include route or render options; a Config holds those, and
+// EncodeConfigCode shares them.
+type MapParams struct {
+	Seed        int64
+	PixelWidth  int
+	PixelHeight int
+	NumCities   int
+}
+
+// Generate generates the region map described by the parameters.
+func (p MapParams) Generate() (RegionMap, error) {
+	return GenerateRegionMap(p.Seed, p.PixelWidth, p.PixelHeight, p.NumCities)
+}
+
+// EncodeMapCode encodes generation parameters into a short map code, like
+// "AEBP-AANA-AEFM-M", which can be shared instead of the map itself. The
+// code records the version of the generator, and a checksum to catch
+// typos. Map codes only hold the MapParams, so maps generated with custom
+// route options, or rendered with custom options, should be shared with
+// EncodeConfigCode instead, which falls back to a map code when there are
+// no options to record.
+func EncodeMapCode(p MapParams) string {
+	buf := make([]byte, 0, 4*binary.MaxVarintLen64+4)
+	var scratch [binary.MaxVarintLen64]byte
+	buf = append(buf, scratch[:binary.PutUvarint(scratch[:], generatorVersion)]...)
+	buf = append(buf, scratch[:binary.PutVarint(scratch[:], p.Seed)]...)
+	for _, v := range []int{p.PixelWidth, p.PixelHeight, p.NumCities} {
+		buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(v))]...)
+	}
+	// A single checksum byte is enough to catch most typos, without
+	// making the code much longer.
+	buf = append(buf, byte(crc32.ChecksumIEEE(buf)))
+
+	code := mapCodeEncoding.EncodeToString(buf)
+	groups := []string{}
+	for len(code) > mapCodeGroupSize {
+		groups = append(groups, code[:mapCodeGroupSize])
+		code = code[mapCodeGroupSize:]
+	}
+	groups = append(groups, code)
+	return strings.Join(groups, "-")
+}
+
+// DecodeMapCode decodes the generation parameters from a map code created
+// by EncodeMapCode. Codes are case-insensitive, and dashes and spaces are
+// ignored.
+func DecodeMapCode(code string) (MapParams, error) {
+	cleaned := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+	buf, err := mapCodeEncoding.DecodeString(cleaned)
+	if err != nil || len(buf) < 2 {
+		return MapParams{}, fmt.Errorf("Invalid map code %q", code)
+	}
+	data, checksum := buf[:len(buf)-1], buf[len(buf)-1]
+	if byte(crc32.ChecksumIEEE(data)) != checksum {
+		return MapParams{}, fmt.Errorf("Invalid map code %q: the checksum doesn't match", code)
+	}
+
+	version, n := binary.Uvarint(data)
+	if n <= 0 {
+		return MapParams{}, fmt.Errorf("Invalid map code %q", code)
+	}
+	if version != generatorVersion {
+		return MapParams{}, fmt.Errorf("Map code %q was made with generator version %d, but this is version %d", code, version, generatorVersion)
+	}
+	data = data[n:]
+	var p MapParams
+	if p.Seed, n = binary.Varint(data); n <= 0 {
+		return MapParams{}, fmt.Errorf("Invalid map code %q", code)
+	}
+	data = data[n:]
+	for _, v := range []*int{&p.PixelWidth, &p.PixelHeight, &p.NumCities} {
+		value, n := binary.Uvarint(data)
+		if n <= 0 || value > maxBinaryDimension {
+			return MapParams{}, fmt.Errorf("Invalid map code %q", code)
+		}
+		*v = int(value)
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return MapParams{}, fmt.Errorf("Invalid map code %q", code)
+	}
+	return p, nil
+}
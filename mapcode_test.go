@@ -0,0 +1,56 @@
+package porygion
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMapCodeRoundTrip(t *testing.T) {
+	tests := []MapParams{
+		{Seed: 0, PixelWidth: 8, PixelHeight: 8, NumCities: 0},
+		{Seed: 42, PixelWidth: 240, PixelHeight: 160, NumCities: 12},
+		{Seed: -1, PixelWidth: 480, PixelHeight: 320, NumCities: 30},
+		{Seed: math.MaxInt64, PixelWidth: maxBinaryDimension, PixelHeight: 8, NumCities: 1},
+		{Seed: math.MinInt64, PixelWidth: 8, PixelHeight: maxBinaryDimension, NumCities: 2},
+	}
+	for _, p := range tests {
+		code := EncodeMapCode(p)
+		for _, variant := range []string{code, strings.ToLower(code), strings.Replace(code, "-", " ", -1), " " + code + "\n"} {
+			got, err := DecodeMapCode(variant)
+			if err != nil {
+				t.Errorf("DecodeMapCode(%q): %s", variant, err)
+				continue
+			}
+			if got != p {
+				t.Errorf("DecodeMapCode(%q) = %+v, want %+v", variant, got, p)
+			}
+		}
+	}
+}
+
+func TestDecodeMapCodeErrors(t *testing.T) {
+	code := EncodeMapCode(MapParams{Seed: 42, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	typo := []byte(code)
+	if typo[0] == 'A' {
+		typo[0] = 'B'
+	} else {
+		typo[0] = 'A'
+	}
+	tests := []struct {
+		name, code, want string
+	}{
+		{"empty", "", "Invalid map code"},
+		{"not base32", "!!!!-!!!!", "Invalid map code"},
+		{"typo", string(typo), "checksum"},
+		{"truncated", code[:len(code)-4], "Invalid map code"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeMapCode(test.code)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("DecodeMapCode(%q) error = %v, want %q", test.code, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package porygion
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// mapFileMagic identifies the region map file container.
+const mapFileMagic = "PRGF"
+
+// mapFileVersion is the version of the container written by WriteMapFile.
+// Files with older versions are migrated when they are read.
+const mapFileVersion = 1
+
+// legacyMapFileVersion is the version given to bare binary region maps,
+// written by RegionMap.WriteTo before the container existed.
+const legacyMapFileVersion = 0
+
+// Tags of the sections in a region map file. Each tag is four bytes.
+const (
+	// mapSectionRegionMap holds the region map in the binary format
+	// written by RegionMap.WriteTo.
+	mapSectionRegionMap = "RMAP"
+)
+
+// mapFile is the decoded contents of a region map file container.
+// Sections are kept in file order, and sections with unknown tags are
+// kept too, so that files written by newer versions can still be read.
+type mapFile struct {
+	version  uint64
+	tags     []string
+	sections map[string][]byte
+}
+
+// mapFileMigration upgrades a map file from one version to the next.
+type mapFileMigration func(f *mapFile) error
+
+// mapFileMigrations are the migrations from each older version of the
+// map file to the version after it. When new data is added to region
+// maps, it gets a new section and the version is bumped, and a migration
+// is added here to fill in the section for older files.
+var mapFileMigrations = map[uint64]mapFileMigration{
+	legacyMapFileVersion: migrateLegacyMapFile,
+}
+
+// WriteMapFile writes the region map in the region map file container.
+// The container starts with a magic string and version, followed by
+// tagged sections that each have a length and checksum. Use ReadMapFile
+// to read it back.
+func WriteMapFile(w io.Writer, r RegionMap) error {
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return err
+	}
+	f := mapFile{version: mapFileVersion}
+	f.setSection(mapSectionRegionMap, buf.Bytes())
+
+	bw := bufio.NewWriter(w)
+	e := binaryEncoder{w: bw}
+	e.bytes([]byte(mapFileMagic))
+	e.uvarint(f.version)
+	e.uvarint(uint64(len(f.tags)))
+	for _, tag := range f.tags {
+		data := f.sections[tag]
+		e.bytes([]byte(tag))
+		e.uvarint(uint64(len(data)))
+		binary.LittleEndian.PutUint32(e.buf[:], crc32.ChecksumIEEE(data))
+		e.bytes(e.buf[:4])
+		e.bytes(data)
+	}
+	if e.err == nil {
+		e.err = bw.Flush()
+	}
+	if e.err != nil {
+		return fmt.Errorf("Failed to write region map file: %s", e.err)
+	}
+	return nil
+}
+
+// ReadMapFile reads a region map file written by WriteMapFile. Files
+// written by older versions, including bare binary region maps written
+// by RegionMap.WriteTo, are migrated to the current version. Sections
+// that were added by newer versions are ignored.
+func ReadMapFile(reader io.Reader) (RegionMap, error) {
+	f, err := readMapFile(bufio.NewReader(reader))
+	if err != nil {
+		return RegionMap{}, fmt.Errorf("Failed to read region map file: %s", err)
+	}
+	if err := f.migrate(); err != nil {
+		return RegionMap{}, fmt.Errorf("Failed to migrate region map file from version %d: %s", f.version, err)
+	}
+	data, ok := f.sections[mapSectionRegionMap]
+	if !ok {
+		return RegionMap{}, fmt.Errorf("Region map file has no %s section", mapSectionRegionMap)
+	}
+	var regionMap RegionMap
+	if _, err := regionMap.ReadFrom(bytes.NewReader(data)); err != nil {
+		return RegionMap{}, err
+	}
+	return regionMap, nil
+}
+
+// readMapFile reads the sections of a map file, without migrating it.
+func readMapFile(r *bufio.Reader) (mapFile, error) {
+	magic, err := r.Peek(len(mapFileMagic))
+	if err != nil {
+		return mapFile{}, err
+	}
+	if string(magic) == regionMapMagic {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return mapFile{}, err
+		}
+		f := mapFile{version: legacyMapFileVersion}
+		f.setSection(mapSectionRegionMap, data)
+		return f, nil
+	}
+	if string(magic) != mapFileMagic {
+		return mapFile{}, fmt.Errorf("Not a region map file")
+	}
+	r.Discard(len(mapFileMagic))
+
+	d := binaryDecoder{r: r}
+	f := mapFile{}
+	if f.version, err = d.uvarint(math.MaxUint32); err != nil {
+		return f, err
+	}
+	if f.version < 1 || f.version > mapFileVersion {
+		return f, fmt.Errorf("Unsupported region map file version %d", f.version)
+	}
+	numSections, err := d.uvarint(math.MaxUint16)
+	if err != nil {
+		return f, err
+	}
+	for i := uint64(0); i < numSections; i++ {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return f, err
+		}
+		tag := string(header[:])
+		size, err := d.uvarint(math.MaxInt32)
+		if err != nil {
+			return f, err
+		}
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return f, err
+		}
+		checksum := binary.LittleEndian.Uint32(header[:])
+		// Copy rather than allocating the whole section up front, so that
+		// a corrupt size can't allocate a huge buffer.
+		var data bytes.Buffer
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return f, err
+		}
+		if crc32.ChecksumIEEE(data.Bytes()) != checksum {
+			return f, fmt.Errorf("Checksum mismatch in %s section", tag)
+		}
+		f.setSection(tag, data.Bytes())
+	}
+	return f, nil
+}
+
+// setSection adds or replaces a section of the map file.
+func (f *mapFile) setSection(tag string, data []byte) {
+	if f.sections == nil {
+		f.sections = map[string][]byte{}
+	}
+	if _, ok := f.sections[tag]; !ok {
+		f.tags = append(f.tags, tag)
+	}
+	f.sections[tag] = data
+}
+
+// migrate applies migrations until the map file is the current version.
+func (f *mapFile) migrate() error {
+	for f.version < mapFileVersion {
+		migration, ok := mapFileMigrations[f.version]
+		if !ok {
+			return fmt.Errorf("No migration from version %d", f.version)
+		}
+		if err := migration(f); err != nil {
+			return err
+		}
+		f.version++
+	}
+	return nil
+}
+
+// migrateLegacyMapFile upgrades a bare binary region map. Its data is
+// already in the region map section, so it only needs to be checked.
+func migrateLegacyMapFile(f *mapFile) error {
+	var regionMap RegionMap
+	_, err := regionMap.ReadFrom(bytes.NewReader(f.sections[mapSectionRegionMap]))
+	return err
+}
@@ -0,0 +1,100 @@
+package porygion
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+func TestMapFileRoundTrip(t *testing.T) {
+	regionMap := testRegionMap(t)
+	var file, bare bytes.Buffer
+	if err := WriteMapFile(&file, regionMap); err != nil {
+		t.Fatalf("WriteMapFile: %s", err)
+	}
+	if _, err := regionMap.WriteTo(&bare); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	var want RegionMap
+	if _, err := want.ReadFrom(bytes.NewReader(bare.Bytes())); err != nil {
+		t.Fatalf("ReadFrom: %s", err)
+	}
+
+	// Bare binary region maps, written before the container existed, are
+	// migrated to the same map.
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"container", file.Bytes()},
+		{"legacy bare map", bare.Bytes()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadMapFile(bytes.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("ReadMapFile: %s", err)
+			}
+			if got.Fingerprint() != want.Fingerprint() {
+				t.Errorf("Fingerprint() = %s, want %s", got.Fingerprint(), want.Fingerprint())
+			}
+		})
+	}
+}
+
+func TestMapFileKeepsUnknownSections(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := testRegionMap(t).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %s", err)
+	}
+	f := mapFile{version: mapFileVersion}
+	f.setSection("NEWS", []byte("from the future"))
+	f.setSection(mapSectionRegionMap, buf.Bytes())
+	var file bytes.Buffer
+	e := binaryEncoder{w: &file}
+	e.bytes([]byte(mapFileMagic))
+	e.uvarint(f.version)
+	e.uvarint(uint64(len(f.tags)))
+	for _, tag := range f.tags {
+		e.bytes([]byte(tag))
+		e.uvarint(uint64(len(f.sections[tag])))
+		var checksum [4]byte
+		binary.LittleEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(f.sections[tag]))
+		e.bytes(checksum[:])
+		e.bytes(f.sections[tag])
+	}
+	if _, err := ReadMapFile(&file); err != nil {
+		t.Errorf("ReadMapFile: %s", err)
+	}
+}
+
+func TestReadMapFileErrors(t *testing.T) {
+	var valid bytes.Buffer
+	if err := WriteMapFile(&valid, testRegionMap(t)); err != nil {
+		t.Fatalf("WriteMapFile: %s", err)
+	}
+	corrupt := append([]byte{}, valid.Bytes()...)
+	corrupt[len(corrupt)-1] ^= 0xFF
+	future := append([]byte{}, valid.Bytes()...)
+	future[len(mapFileMagic)] = mapFileVersion + 1
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not a map file", []byte("GIF89a"), "Not a region map file"},
+		{"corrupt section", corrupt, "Checksum mismatch in RMAP section"},
+		{"future version", future, "Unsupported region map file version 2"},
+		{"truncated", valid.Bytes()[:valid.Len()-10], "EOF"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadMapFile(bytes.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ReadMapFile error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMapSectionConstants writes a C enum of the region map's MAPSEC
+// constants, in the style of pokeemerald's region_map_sections.h.
+func WriteMapSectionConstants(w io.Writer, regionMap RegionMap) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "enum {\n")
+	for _, s := range regionMap.MapSections() {
+		fmt.Fprintf(bw, "    %s,\n", s.ID)
+	}
+	fmt.Fprintf(bw, "    MAPSEC_COUNT\n")
+	fmt.Fprintf(bw, "};\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write map section constants: %s", err)
+	}
+	return nil
+}
+
+// WriteMapSectionEntries writes the C display-name strings and location
+// table for the region map's map sections, in the style of pokeemerald's
+// region_map_entries.h.
+func WriteMapSectionEntries(w io.Writer, regionMap RegionMap) error {
+	sections := regionMap.MapSections()
+	bw := bufio.NewWriter(w)
+	for _, s := range sections {
+		fmt.Fprintf(bw, "static const u8 %s[] = _(\"%s\");\n", getMapNameSymbol(s.ID), cStringEscape(strings.ToUpper(s.Name)))
+	}
+	fmt.Fprintf(bw, "\nconst struct RegionMapLocation gRegionMapEntries[] = {\n")
+	for _, s := range sections {
+		fmt.Fprintf(bw, "    [%s] = {%d, %d, %d, %d, %s},\n", s.ID, s.X, s.Y, s.Width, s.Height, getMapNameSymbol(s.ID))
+	}
+	fmt.Fprintf(bw, "};\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write map section entries: %s", err)
+	}
+	return nil
+}
+
+// getMapNameSymbol returns the name of the string constant for a map
+// section, like sMapName_LittlerootTown for MAPSEC_LITTLEROOT_TOWN.
+func getMapNameSymbol(id string) string {
+	var b strings.Builder
+	b.WriteString("sMapName_")
+	for _, word := range strings.Split(strings.TrimPrefix(id, "MAPSEC_"), "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(word[:1])
+		b.WriteString(strings.ToLower(word[1:]))
+	}
+	return b.String()
+}
+
+// cStringEscape escapes a string for use in a C string literal.
+func cStringEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
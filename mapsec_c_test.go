@@ -0,0 +1,46 @@
+package porygion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteMapSectionConstants(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMapSectionConstants(&buf, testSectionsMap()); err != nil {
+		t.Fatalf("WriteMapSectionConstants: %s", err)
+	}
+	want := `enum {
+    MAPSEC_LITTLEROOT_TOWN,
+    MAPSEC_LITTLEROOT_TOWN_2,
+    MAPSEC_PORT_3,
+    MAPSEC_ROUTE_101,
+    MAPSEC_ROUTE_102,
+    MAPSEC_COUNT
+};
+`
+	if buf.String() != want {
+		t.Errorf("WriteMapSectionConstants wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteMapSectionEntries(t *testing.T) {
+	regionMap := testSectionsMap()
+	regionMap.CityNames[regionMap.Cities[2]] = `Say "Hi"`
+	var buf bytes.Buffer
+	if err := WriteMapSectionEntries(&buf, regionMap); err != nil {
+		t.Fatalf("WriteMapSectionEntries: %s", err)
+	}
+	for _, want := range []string{
+		`static const u8 sMapName_LittlerootTown[] = _("LITTLEROOT TOWN");`,
+		`static const u8 sMapName_LittlerootTown2[] = _("LITTLEROOT TOWN");`,
+		`static const u8 sMapName_SayHi[] = _("SAY \"HI\"");`,
+		`const struct RegionMapLocation gRegionMapEntries[] = {`,
+		`    [MAPSEC_ROUTE_102] = {6, 3, 3, 3, sMapName_Route102},`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteMapSectionEntries output doesn't contain %s:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// firstRouteNumber is the number of the first route, following the
+// numbering of the routes in Hoenn.
+const firstRouteNumber = 101
+
+// MapSection is a named area of the region map, like a city or a route,
+// as it is defined in a decomp project. Its position and size are in
+// tiles.
+type MapSection struct {
+	// ID is the MAPSEC constant that identifies the section.
+	ID   string
+	Name string
+	X, Y int
+	// Width and Height are the size of the section's bounding rectangle.
+	Width, Height int
+}
+
+// MapSections returns a map section for each city and route. Cities are
+// named from CityNames, and unnamed cities and routes are given numbered
+// names. Cities come first, in the same order as the region map's cities,
+// followed by the routes, in the same order as its connections.
+func (r RegionMap) MapSections() []MapSection {
+	sections := []MapSection{}
+	usedIDs := map[string]bool{}
+	addSection := func(name string, x, y, width, height int) {
+		id := "MAPSEC_" + getConstantName(name)
+		unique := id
+		for n := 2; usedIDs[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", id, n)
+		}
+		usedIDs[unique] = true
+		sections = append(sections, MapSection{
+			ID:     unique,
+			Name:   name,
+			X:      x,
+			Y:      y,
+			Width:  width,
+			Height: height,
+		})
+	}
+	for i, city := range r.Cities {
+		name := r.CityNames[city]
+		if name == "" {
+			name = fmt.Sprintf("%s %d", strings.ToUpper(r.CityKinds[city].String()), i+1)
+		}
+		addSection(name, city.X, city.Y, 1, 1)
+	}
+	routeNumber := firstRouteNumber
+	for _, c := range r.Connections {
+		if len(c.Tiles) == 0 {
+			continue
+		}
+		min, max := c.Tiles[0], c.Tiles[0]
+		for _, t := range c.Tiles {
+			min = Tile{minInt(min.X, t.X), minInt(min.Y, t.Y)}
+			max = Tile{maxInt(max.X, t.X), maxInt(max.Y, t.Y)}
+		}
+		addSection(fmt.Sprintf("ROUTE %d", routeNumber), min.X, min.Y, max.X-min.X+1, max.Y-min.Y+1)
+		routeNumber++
+	}
+	return sections
+}
+
+// getConstantName converts a display name into the upper snake case used
+// for C constants, like "Littleroot Town" to "LITTLEROOT_TOWN".
+func getConstantName(name string) string {
+	var b strings.Builder
+	pendingUnderscore := false
+	for _, c := range name {
+		if c > unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			pendingUnderscore = b.Len() > 0
+			continue
+		}
+		if pendingUnderscore {
+			b.WriteByte('_')
+			pendingUnderscore = false
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	if b.Len() == 0 {
+		return "UNNAMED"
+	}
+	return b.String()
+}
+
+type porymapSection struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ExportPorymapSections encodes the region map's map sections in the
+// region_map_sections.json format that porymap reads.
+func ExportPorymapSections(regionMap RegionMap) ([]byte, error) {
+	sections := []porymapSection{}
+	for _, s := range regionMap.MapSections() {
+		sections = append(sections, porymapSection{
+			ID:     s.ID,
+			Name:   s.Name,
+			X:      s.X,
+			Y:      s.Y,
+			Width:  s.Width,
+			Height: s.Height,
+		})
+	}
+	data, err := json.MarshalIndent(struct {
+		MapSections []porymapSection `json:"map_sections"`
+	}{sections}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode map sections: %s", err)
+	}
+	return append(data, '\n'), nil
+}
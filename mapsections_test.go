@@ -0,0 +1,68 @@
+package porygion
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testSectionsMap returns a hand-made region map with two cities of the
+// same name, an unnamed city, and two routes.
+func testSectionsMap() RegionMap {
+	a, b, c := Tile{1, 3}, Tile{5, 3}, Tile{9, 5}
+	return RegionMap{
+		Cities:    []Tile{a, b, c},
+		CityNames: map[Tile]string{a: "Littleroot Town", b: "Littleroot Town"},
+		CityKinds: map[Tile]CityKind{c: CityPort},
+		Connections: []RouteConnection{
+			{CityA: a, CityB: b, Tiles: []Tile{{2, 3}, {3, 3}, {4, 3}}},
+			{CityA: b, CityB: c, Tiles: []Tile{{6, 3}, {7, 3}, {8, 3}, {8, 4}, {8, 5}}},
+			{CityA: a, CityB: c},
+		},
+	}
+}
+
+func TestMapSections(t *testing.T) {
+	want := []MapSection{
+		{ID: "MAPSEC_LITTLEROOT_TOWN", Name: "Littleroot Town", X: 1, Y: 3, Width: 1, Height: 1},
+		{ID: "MAPSEC_LITTLEROOT_TOWN_2", Name: "Littleroot Town", X: 5, Y: 3, Width: 1, Height: 1},
+		{ID: "MAPSEC_PORT_3", Name: "PORT 3", X: 9, Y: 5, Width: 1, Height: 1},
+		{ID: "MAPSEC_ROUTE_101", Name: "ROUTE 101", X: 2, Y: 3, Width: 3, Height: 1},
+		{ID: "MAPSEC_ROUTE_102", Name: "ROUTE 102", X: 6, Y: 3, Width: 3, Height: 3},
+	}
+	if sections := testSectionsMap().MapSections(); !reflect.DeepEqual(sections, want) {
+		t.Errorf("MapSections() = %+v, want %+v", sections, want)
+	}
+}
+
+func TestGetConstantName(t *testing.T) {
+	tests := map[string]string{
+		"Littleroot Town": "LITTLEROOT_TOWN",
+		"  Mt. Chimney  ": "MT_CHIMNEY",
+		"Pokémon League":  "POK_MON_LEAGUE",
+		"Route 101":       "ROUTE_101",
+		"!!!":             "UNNAMED",
+	}
+	for name, want := range tests {
+		if got := getConstantName(name); got != want {
+			t.Errorf("getConstantName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExportPorymapSections(t *testing.T) {
+	data, err := ExportPorymapSections(testSectionsMap())
+	if err != nil {
+		t.Fatalf("ExportPorymapSections: %s", err)
+	}
+	var decoded struct {
+		MapSections []porymapSection `json:"map_sections"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode the map sections: %s", err)
+	}
+	want := porymapSection{ID: "MAPSEC_ROUTE_102", Name: "ROUTE 102", X: 6, Y: 3, Width: 3, Height: 3}
+	if len(decoded.MapSections) != 5 || decoded.MapSections[4] != want {
+		t.Errorf("map sections = %+v, want 5 ending with %+v", decoded.MapSections, want)
+	}
+}
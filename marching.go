@@ -0,0 +1,147 @@
+package porygion
+
+// contourPoint is a point on a contour line, in pixel coordinates.
+type contourPoint struct {
+	X, Y float64
+}
+
+// contourEdge identifies the edge between two neighboring elevation
+// samples. Horizontal edges connect (i, j) to (i+1, j), and vertical edges
+// connect (i, j) to (i, j+1).
+type contourEdge struct {
+	i, j       int
+	horizontal bool
+}
+
+// contourSegments lists the pairs of cell edges that are joined by contour
+// segments, for each marching squares case. The cell edges are numbered
+// top, right, bottom, left. The two saddle cases, 5 and 10, are resolved
+// separately.
+var contourSegments = [16][][2]int{
+	1:  {{3, 2}},
+	2:  {{2, 1}},
+	3:  {{3, 1}},
+	4:  {{0, 1}},
+	6:  {{0, 2}},
+	7:  {{3, 0}},
+	8:  {{3, 0}},
+	9:  {{0, 2}},
+	11: {{0, 1}},
+	12: {{3, 1}},
+	13: {{2, 1}},
+	14: {{3, 2}},
+}
+
+// traceContours uses marching squares to find the closed loops along which
+// the elevation crosses the given level. Everything outside of the map is
+// treated as being below the level, so every loop is closed.
+func traceContours(elevations [][]float64, level float64) [][]contourPoint {
+	width := len(elevations)
+	height := len(elevations[0])
+	sample := func(i, j int) float64 {
+		if i < 0 || j < 0 || i >= width || j >= height {
+			return level - 1
+		}
+		return elevations[i][j]
+	}
+	points := map[contourEdge]contourPoint{}
+	edgePoint := func(e contourEdge) contourEdge {
+		if _, ok := points[e]; ok {
+			return e
+		}
+		a := sample(e.i, e.j)
+		var b float64
+		if e.horizontal {
+			b = sample(e.i+1, e.j)
+		} else {
+			b = sample(e.i, e.j+1)
+		}
+		t := 0.5
+		if a != b {
+			t = (level - a) / (b - a)
+		}
+		p := contourPoint{float64(e.i) + 0.5, float64(e.j) + 0.5}
+		if e.horizontal {
+			p.X += t
+		} else {
+			p.Y += t
+		}
+		points[e] = p
+		return e
+	}
+
+	segments := [][2]contourEdge{}
+	for i := -1; i < width; i++ {
+		for j := -1; j < height; j++ {
+			tl := sample(i, j) > level
+			tr := sample(i+1, j) > level
+			br := sample(i+1, j+1) > level
+			bl := sample(i, j+1) > level
+			index := 0
+			for _, inside := range []bool{tl, tr, br, bl} {
+				index <<= 1
+				if inside {
+					index |= 1
+				}
+			}
+			edges := [4]contourEdge{
+				{i, j, true},
+				{i + 1, j, false},
+				{i, j + 1, true},
+				{i, j, false},
+			}
+			pairs := contourSegments[index]
+			if index == 5 || index == 10 {
+				center := (sample(i, j)+sample(i+1, j)+sample(i+1, j+1)+sample(i, j+1))/4 > level
+				if (index == 5) == center {
+					pairs = [][2]int{{3, 0}, {2, 1}}
+				} else {
+					pairs = [][2]int{{0, 1}, {3, 2}}
+				}
+			}
+			for _, pair := range pairs {
+				segments = append(segments, [2]contourEdge{edgePoint(edges[pair[0]]), edgePoint(edges[pair[1]])})
+			}
+		}
+	}
+
+	// Chain the segments together into loops, using the edges that they
+	// share.
+	byEdge := map[contourEdge][]int{}
+	for s, seg := range segments {
+		byEdge[seg[0]] = append(byEdge[seg[0]], s)
+		byEdge[seg[1]] = append(byEdge[seg[1]], s)
+	}
+	used := make([]bool, len(segments))
+	loops := [][]contourPoint{}
+	for s := range segments {
+		if used[s] {
+			continue
+		}
+		used[s] = true
+		start := segments[s][0]
+		current := segments[s][1]
+		loop := []contourPoint{points[start]}
+		for current != start {
+			loop = append(loop, points[current])
+			next := -1
+			for _, candidate := range byEdge[current] {
+				if !used[candidate] {
+					next = candidate
+					break
+				}
+			}
+			if next == -1 {
+				break
+			}
+			used[next] = true
+			if segments[next][0] == current {
+				current = segments[next][1]
+			} else {
+				current = segments[next][0]
+			}
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
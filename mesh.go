@@ -0,0 +1,39 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// ExportMeshOBJ writes a region map's elevations as a triangulated
+// heightfield in the Wavefront OBJ format. There is one vertex per pixel,
+// colored with the full rendered map, and water is flattened to sea level.
+// The y axis points up, and elevations are multiplied by verticalScale.
+func ExportMeshOBJ(regionMap RegionMap, verticalScale float64, w io.Writer) error {
+	elevations := regionMap.Elevations
+	width := len(elevations)
+	height := len(elevations[0])
+	img := renderRegionMapImage(regionMap, RenderOptions{}).(*image.RGBA)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Porygion region map heightfield, %dx%d\n", width, height)
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			y := math.Max(elevations[i][j], 0) * verticalScale
+			c := img.RGBAAt(i, j)
+			fmt.Fprintf(bw, "v %d %.4f %d %.3f %.3f %.3f\n", i, y, j, float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+		}
+	}
+	vertex := func(i, j int) int {
+		return j*width + i + 1
+	}
+	for j := 0; j+1 < height; j++ {
+		for i := 0; i+1 < width; i++ {
+			fmt.Fprintf(bw, "f %d %d %d\n", vertex(i, j), vertex(i, j+1), vertex(i+1, j))
+			fmt.Fprintf(bw, "f %d %d %d\n", vertex(i+1, j), vertex(i, j+1), vertex(i+1, j+1))
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,26 @@
+package porygion
+
+import "image"
+
+// RenderMinimap renders a region map at one pixel per tile. Each pixel is
+// the color of the tile's most common terrain, or the route or city color
+// when the tile has one.
+func RenderMinimap(regionMap RegionMap, opts RenderOptions) image.Image {
+	palette := getRenderPalette(opts)
+	elevations := regionMap.Elevations
+	tilesWidth := len(elevations) / 8
+	tilesHeight := len(elevations[0]) / 8
+	img := image.NewRGBA(image.Rect(0, 0, tilesWidth, tilesHeight))
+	for i := 0; i < tilesWidth; i++ {
+		for j := 0; j < tilesHeight; j++ {
+			img.SetRGBA(i, j, palette.terrainColor(getDominantTerrainBand(elevations, Tile{i, j})))
+		}
+	}
+	for _, t := range regionMap.Routes {
+		img.SetRGBA(t.X, t.Y, palette.routeColor(getDominantTerrainBand(elevations, t)))
+	}
+	for _, t := range regionMap.Cities {
+		img.SetRGBA(t.X, t.Y, palette.City)
+	}
+	return img
+}
@@ -0,0 +1,47 @@
+package porygion
+
+import "image/color"
+
+// colorNightCity is the color of city lights at night.
+var colorNightCity = color.RGBA{248, 216, 96, 255}
+
+// NightPalette returns a darker, blue-tinted variant of a palette, for
+// showing the region map at nighttime. Cities are lit up, so that they are
+// still easy to see.
+func NightPalette(p Palette) Palette {
+	night := Palette{City: colorNightCity}
+	for i, c := range p.Water {
+		night.Water[i] = getNightColor(c)
+	}
+	if p.DeepWater != ([2]color.RGBA{}) {
+		for i, c := range p.DeepWater {
+			night.DeepWater[i] = getNightColor(c)
+		}
+	}
+	for i, c := range p.Land {
+		night.Land[i] = getNightColor(c)
+	}
+	for i, c := range p.RouteWater {
+		night.RouteWater[i] = getNightColor(c)
+	}
+	for i, c := range p.RouteLand {
+		night.RouteLand[i] = getNightColor(c)
+	}
+	return night
+}
+
+func getNightColor(c color.RGBA) color.RGBA {
+	scale := func(v uint8, factor float64, offset float64) uint8 {
+		result := float64(v)*factor + offset
+		if result > 255 {
+			result = 255
+		}
+		return uint8(result)
+	}
+	return color.RGBA{
+		R: scale(c.R, 0.35, 8),
+		G: scale(c.G, 0.40, 16),
+		B: scale(c.B, 0.55, 48),
+		A: c.A,
+	}
+}
@@ -0,0 +1,36 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// RenderNormalMap renders the slopes of a region map's terrain as a
+// tangent-space normal map, using the same slope exaggeration as the
+// hillshading pass.
+func RenderNormalMap(regionMap RegionMap) image.Image {
+	return RenderNormalMapWithStrength(regionMap, defaultHillshadeExaggeration)
+}
+
+// RenderNormalMapWithStrength renders a tangent-space normal map, where
+// strength multiplies the terrain's slopes. The normals follow the OpenGL
+// convention, where green points toward the top of the image.
+func RenderNormalMapWithStrength(regionMap RegionMap, strength float64) image.Image {
+	elevations := regionMap.Elevations
+	img := image.NewRGBA(image.Rect(0, 0, len(elevations), len(elevations[0])))
+	encode := func(v float64) uint8 {
+		return uint8(math.Round((v + 1) / 2 * 255))
+	}
+	for i := range elevations {
+		for j := range elevations[i] {
+			dzdx, dzdy := getElevationGradient(elevations, i, j)
+			// Image rows increase downward, so the y component is flipped
+			// to point up.
+			nx, ny, nz := -dzdx*strength, dzdy*strength, 1.0
+			length := math.Sqrt(nx*nx + ny*ny + nz*nz)
+			img.SetRGBA(i, j, color.RGBA{encode(nx / length), encode(ny / length), encode(nz / length), 255})
+		}
+	}
+	return img
+}
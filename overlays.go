@@ -0,0 +1,110 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// ContourOptions controls the elevation contour line overlay.
+type ContourOptions struct {
+	// Interval is the elevation difference between adjacent contour lines.
+	Interval float64    `json:"interval"`
+	Color    color.RGBA `json:"color"`
+}
+
+// DefaultContourOptions returns dark contour lines at a moderate interval.
+func DefaultContourOptions() ContourOptions {
+	return ContourOptions{
+		Interval: 0.25,
+		Color:    color.RGBA{48, 48, 48, 255},
+	}
+}
+
+// drawContours draws a line wherever the elevation crosses a multiple of
+// the contour interval.
+func drawContours(img *image.RGBA, elevations [][]float64, opts ContourOptions) {
+	if opts.Interval <= 0 {
+		return
+	}
+	level := func(e float64) float64 {
+		return math.Floor(e / opts.Interval)
+	}
+	width := len(elevations)
+	height := len(elevations[0])
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			l := level(elevations[i][j])
+			if (i+1 < width && level(elevations[i+1][j]) != l) || (j+1 < height && level(elevations[i][j+1]) != l) {
+				img.SetRGBA(i, j, opts.Color)
+			}
+		}
+	}
+}
+
+// TileGridOptions controls the tile grid overlay.
+type TileGridOptions struct {
+	// Color is the color of the grid lines. Colors that are not fully
+	// opaque are blended with the map beneath them.
+	Color color.RGBA `json:"color"`
+}
+
+// LightTileGrid returns translucent white tile grid lines.
+func LightTileGrid() TileGridOptions {
+	return TileGridOptions{Color: color.RGBA{255, 255, 255, 128}}
+}
+
+// DarkTileGrid returns translucent black tile grid lines.
+func DarkTileGrid() TileGridOptions {
+	return TileGridOptions{Color: color.RGBA{0, 0, 0, 128}}
+}
+
+// drawTileGrid draws a line along the top and left edges of every tile.
+func drawTileGrid(img *image.RGBA, opts TileGridOptions) {
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			if x%8 == 0 || y%8 == 0 {
+				img.SetRGBA(x, y, blendColor(img.RGBAAt(x, y), opts.Color))
+			}
+		}
+	}
+}
+
+// blendColor draws a non-premultiplied color over an opaque color.
+func blendColor(dst color.RGBA, src color.RGBA) color.RGBA {
+	a := uint32(src.A)
+	mix := func(d, s uint8) uint8 {
+		return uint8((uint32(s)*a + uint32(d)*(255-a)) / 255)
+	}
+	return color.RGBA{mix(dst.R, src.R), mix(dst.G, src.G), mix(dst.B, src.B), dst.A}
+}
+
+// CoastlineOptions controls the coastline outline.
+type CoastlineOptions struct {
+	Color color.RGBA `json:"color"`
+}
+
+// DefaultCoastlineOptions returns a dark green coastline outline.
+func DefaultCoastlineOptions() CoastlineOptions {
+	return CoastlineOptions{Color: color.RGBA{0, 64, 0, 255}}
+}
+
+// drawCoastline outlines every land pixel that borders water.
+func drawCoastline(img *image.RGBA, elevations [][]float64, opts CoastlineOptions) {
+	width := len(elevations)
+	height := len(elevations[0])
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			if elevations[i][j] <= 0 {
+				continue
+			}
+			for _, n := range (Tile{i, j}).neighbors() {
+				if n.X >= 0 && n.Y >= 0 && n.X < width && n.Y < height && elevations[n.X][n.Y] <= 0 {
+					img.SetRGBA(i, j, opts.Color)
+					break
+				}
+			}
+		}
+	}
+}
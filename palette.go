@@ -0,0 +1,63 @@
+package porygion
+
+import "image/color"
+
+// Palette is the set of colors used to render a region map.
+type Palette struct {
+	// Water holds the two water shades, which alternate each row.
+	Water [2]color.RGBA `json:"water"`
+	// DeepWater holds the two shades used for deep ocean, when rendering
+	// with ocean depth. When both are left unset, darker versions of the
+	// Water shades are used.
+	DeepWater [2]color.RGBA `json:"deep_water"`
+	// Land holds the land shades, from the lowest elevation to the highest.
+	Land [5]color.RGBA `json:"land"`
+	// RouteWater and RouteLand are the colors used in place of Water and
+	// Land, where a route passes over them.
+	RouteWater [2]color.RGBA `json:"route_water"`
+	RouteLand  [5]color.RGBA `json:"route_land"`
+	City       color.RGBA    `json:"city"`
+}
+
+// DefaultPalette returns the standard palette used to render region maps.
+func DefaultPalette() Palette {
+	return Palette{
+		Water:      [2]color.RGBA{colorWater0, colorWater1},
+		DeepWater:  [2]color.RGBA{colorDeepWater0, colorDeepWater1},
+		Land:       [5]color.RGBA{colorLand0, colorLand1, colorLand2, colorLand3, colorLand4},
+		RouteWater: [2]color.RGBA{colorRouteWater0, colorRouteWater1},
+		RouteLand:  [5]color.RGBA{colorRouteLand0, colorRouteLand1, colorRouteLand2, colorRouteLand3, colorRouteLand4},
+		City:       colorCity,
+	}
+}
+
+// deepWaterShade darkens the water shades, for palettes that don't
+// specify their own deep water shades.
+const deepWaterShade = 0.75
+
+// terrainBand identifies which of a palette's shades are used for a pixel.
+type terrainBand struct {
+	water bool
+	deep  bool
+	index int
+}
+
+func (p Palette) terrainColor(b terrainBand) color.RGBA {
+	if b.water && b.deep {
+		if p.DeepWater == ([2]color.RGBA{}) {
+			return shadeColor(p.Water[b.index], deepWaterShade)
+		}
+		return p.DeepWater[b.index]
+	}
+	if b.water {
+		return p.Water[b.index]
+	}
+	return p.Land[b.index]
+}
+
+func (p Palette) routeColor(b terrainBand) color.RGBA {
+	if b.water {
+		return p.RouteWater[b.index]
+	}
+	return p.RouteLand[b.index]
+}
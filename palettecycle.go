@@ -0,0 +1,120 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// PaletteCycleAnimation is a region map as a single indexed-color image,
+// whose water is animated by changing its palette each frame, instead of
+// its pixels. This is how the water on the GBA Town Map is animated.
+type PaletteCycleAnimation struct {
+	// Image is the first frame of the animation. Its palette is the same
+	// as the first of the Palettes.
+	Image *image.Paletted
+	// Palettes holds the full palette for each frame of the animation.
+	Palettes []color.Palette
+	// Cycles are the ranges of palette indexes that rotate by one slot
+	// each frame. Applying these rotations to the first frame's palette
+	// produces the palettes for the other frames.
+	Cycles []PaletteCycle
+}
+
+// PaletteCycle is a range of palette indexes whose colors rotate each
+// frame. The color at each index moves to the previous index, and the
+// first color wraps around to the end.
+type PaletteCycle struct {
+	Start  int
+	Length int
+}
+
+// paletteCycleFrames is the number of frames in a palette-cycled water
+// animation. The alternating water rows repeat after two frames.
+const paletteCycleFrames = 2
+
+// RenderPaletteCycledRegionMap renders a full region map as an indexed-color
+// image, along with the palette for each frame of its water animation.
+// Every color that changes between frames gets its own palette index, so
+// the render options must produce at most 16 distinct colors, including
+// the animated ones.
+func RenderPaletteCycledRegionMap(regionMap RegionMap, opts RenderOptions) (PaletteCycleAnimation, error) {
+	frames := make([]*image.RGBA, paletteCycleFrames)
+	for i := range frames {
+		frameOpts := opts
+		frameOpts.waterPhase = i
+		frames[i] = renderRegionMapImage(regionMap, frameOpts).(*image.RGBA)
+	}
+
+	type colorPair [paletteCycleFrames]color.RGBA
+	bounds := frames[0].Bounds()
+	getPair := func(x, y int) colorPair {
+		return colorPair{frames[0].RGBAAt(x, y), frames[1].RGBAAt(x, y)}
+	}
+	used := map[colorPair]bool{}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			used[getPair(x, y)] = true
+		}
+	}
+	if len(used) > maxPalettedColors {
+		return PaletteCycleAnimation{}, fmt.Errorf("Animated region map needs %d palette colors, but at most %d are supported", len(used), maxPalettedColors)
+	}
+
+	indexes := map[colorPair]uint8{}
+	pairs := []colorPair{}
+	addPair := func(p colorPair) bool {
+		if _, ok := indexes[p]; ok || !used[p] {
+			return false
+		}
+		indexes[p] = uint8(len(pairs))
+		pairs = append(pairs, p)
+		return true
+	}
+	// Colors that never change come first, in the palette's order, so
+	// that the animated colors are kept together at the end.
+	for _, c := range getPalettedColors(getRenderPalette(opts)) {
+		addPair(colorPair{c, c})
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			if p := getPair(x, y); p[0] == p[1] {
+				addPair(p)
+			}
+		}
+	}
+	cycles := []PaletteCycle{}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			p := getPair(x, y)
+			start := len(pairs)
+			if p[0] == p[1] || !addPair(p) {
+				continue
+			}
+			// Alternating water rows swap colors each frame, so the pair
+			// and its mirror form a two-color rotation.
+			if addPair(colorPair{p[1], p[0]}) {
+				cycles = append(cycles, PaletteCycle{Start: start, Length: 2})
+			}
+		}
+	}
+
+	palettes := make([]color.Palette, paletteCycleFrames)
+	for i := range palettes {
+		palettes[i] = make(color.Palette, len(pairs))
+		for j, p := range pairs {
+			palettes[i][j] = p[i]
+		}
+	}
+	img := image.NewPaletted(bounds, palettes[0])
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			img.SetColorIndex(x, y, indexes[getPair(x, y)])
+		}
+	}
+	return PaletteCycleAnimation{
+		Image:    img,
+		Palettes: palettes,
+		Cycles:   cycles,
+	}, nil
+}
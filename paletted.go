@@ -0,0 +1,75 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// maxPalettedColors is the number of colors in a single GBA palette.
+const maxPalettedColors = 16
+
+// RenderPalettedRegionMap renders a full region map as an indexed-color
+// image, using only the exact colors that appear in it. The palette's
+// colors that are used come first, in a fixed order, followed by any other
+// colors that the render options added. Render options that produce more
+// than 16 distinct colors, like hillshading, result in an error.
+func RenderPalettedRegionMap(regionMap RegionMap, opts RenderOptions) (*image.Paletted, error) {
+	src := renderRegionMapImage(regionMap, opts).(*image.RGBA)
+	return convertToPaletted(src, getPalettedColors(getRenderPalette(opts)))
+}
+
+// getPalettedColors returns the colors of the palette in a fixed order.
+func getPalettedColors(p Palette) []color.RGBA {
+	colors := []color.RGBA{}
+	colors = append(colors, p.Water[:]...)
+	for i := range p.DeepWater {
+		colors = append(colors, p.terrainColor(terrainBand{water: true, deep: true, index: i}))
+	}
+	colors = append(colors, p.Land[:]...)
+	colors = append(colors, p.RouteWater[:]...)
+	colors = append(colors, p.RouteLand[:]...)
+	colors = append(colors, p.City, colorCityOutline)
+	return colors
+}
+
+// convertToPaletted converts an image into an indexed-color image. The
+// preferred colors are placed first in the palette, but only if they are
+// used in the image.
+func convertToPaletted(src *image.RGBA, preferred []color.RGBA) (*image.Paletted, error) {
+	bounds := src.Bounds()
+	used := map[color.RGBA]bool{}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			used[src.RGBAAt(x, y)] = true
+		}
+	}
+	indexes := map[color.RGBA]uint8{}
+	colors := color.Palette{}
+	addColor := func(c color.RGBA) {
+		if _, ok := indexes[c]; ok || !used[c] {
+			return
+		}
+		indexes[c] = uint8(len(colors))
+		colors = append(colors, c)
+	}
+	for _, c := range preferred {
+		addColor(c)
+	}
+	if len(used) > maxPalettedColors {
+		return nil, fmt.Errorf("Rendered region map has %d colors, but indexed output supports at most %d colors", len(used), maxPalettedColors)
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			addColor(src.RGBAAt(x, y))
+		}
+	}
+
+	dst := image.NewPaletted(bounds, colors)
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			dst.SetColorIndex(x, y, indexes[src.RGBAAt(x, y)])
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,354 @@
+package porygion
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// RouteOptions controls how routes are planned between cities.
+type RouteOptions struct {
+	// Costs, when set, plans each route along the cheapest path through
+	// the terrain, rather than a simple L-shaped path.
+	Costs *RouteCosts `json:"costs"`
+	// FollowCoasts biases routes between two coastal cities to run along
+	// the coastline, rather than cutting inland. These routes are always
+	// planned by terrain cost, using DefaultRouteCosts when Costs is nil.
+	FollowCoasts bool `json:"follow_coasts"`
+	// VictoryRoad, when set, flags the connection between two cities as the
+	// climactic final route. Rather than taking the cheapest path, it takes
+	// a deliberately long detour through the most mountainous terrain.
+	VictoryRoad *CityPair `json:"victory_road"`
+	// RouteMask reports whether routes may pass through a tile. When nil,
+	// routes are kept inside the same area that cities may be placed in.
+	RouteMask func(t Tile) bool `json:"-"`
+}
+
+// validateVictoryRoad checks that both cities of the victory road are
+// cities of the region map, since the victory road is always connected,
+// even when the planner wouldn't otherwise connect its cities.
+func validateVictoryRoad(pair *CityPair, cities []Tile) error {
+	if pair == nil {
+		return nil
+	}
+	if pair.A == pair.B {
+		return fmt.Errorf("Invalid victory road: both ends are city %v", pair.A)
+	}
+	for _, t := range []Tile{pair.A, pair.B} {
+		found := false
+		for _, city := range cities {
+			if city == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Invalid victory road: %v is not a city", t)
+		}
+	}
+	return nil
+}
+
+// RouteCosts are the terrain cost weights used by the route planner. The
+// cost of moving onto a tile is computed from these weights, and each route
+// follows the cheapest path between its two cities.
+type RouteCosts struct {
+	// StepCost is the base cost of moving onto any tile.
+	StepCost float64 `json:"step_cost"`
+	// WaterPenalty is added when moving onto a water tile.
+	WaterPenalty float64 `json:"water_penalty"`
+	// SlopePenalty is multiplied by the elevation change between the two
+	// tiles of a step.
+	SlopePenalty float64 `json:"slope_penalty"`
+	// MountainPenalty is added when moving onto a tile whose elevation is
+	// above MountainElevation.
+	MountainPenalty   float64 `json:"mountain_penalty"`
+	MountainElevation float64 `json:"mountain_elevation"`
+	// ReuseBonus is subtracted when moving onto a tile that already has a
+	// route, which encourages routes to share corridors.
+	ReuseBonus float64 `json:"reuse_bonus"`
+	// CoastBonus is subtracted when a coastal route moves onto a land tile
+	// that borders water. It only applies when FollowCoasts is enabled.
+	CoastBonus float64 `json:"coast_bonus"`
+}
+
+// DefaultRouteCosts returns a reasonable set of route cost weights.
+func DefaultRouteCosts() RouteCosts {
+	return RouteCosts{
+		StepCost:          1.0,
+		WaterPenalty:      4.0,
+		SlopePenalty:      10.0,
+		MountainPenalty:   0.0,
+		MountainElevation: 0.85,
+		ReuseBonus:        0.5,
+		CoastBonus:        0.8,
+	}
+}
+
+// coastalCityDistance is the maximum number of tiles between a city and the
+// water, for the city to be considered coastal.
+const coastalCityDistance = 2
+
+// victoryRoadDetour limits how far out of its way the victory road may go,
+// as a multiple of the direct distance between its two cities.
+const victoryRoadDetour = 2
+
+// minStepCost keeps every step strictly positive, so that the cheapest path
+// is always well-defined, no matter how the weights are configured.
+const minStepCost = 0.01
+
+// routePlanner connects pairs of cities with routes.
+type routePlanner struct {
+	opts           RouteOptions
+	costs          RouteCosts
+	allowed        func(t Tile) bool
+	tilesWidth     int
+	tilesHeight    int
+	tileElevations [][]float64
+	// rng is the seeded random source that L-shaped routes are picked
+	// with.
+	rng *rand.Rand
+}
+
+func newRoutePlanner(elevations [][]float64, opts RouteOptions, rng *rand.Rand) routePlanner {
+	p := routePlanner{
+		rng:         rng,
+		opts:        opts,
+		costs:       DefaultRouteCosts(),
+		allowed:     opts.RouteMask,
+		tilesWidth:  len(elevations) / 8,
+		tilesHeight: len(elevations[0]) / 8,
+	}
+	if opts.Costs != nil {
+		p.costs = *opts.Costs
+	}
+	if p.allowed == nil {
+		p.allowed = isInPlacementArea
+	}
+	p.tileElevations = make([][]float64, p.tilesWidth)
+	for i := range p.tileElevations {
+		p.tileElevations[i] = make([]float64, p.tilesHeight)
+		for j := range p.tileElevations[i] {
+			p.tileElevations[i][j] = getTileElevation(elevations, Tile{i, j})
+		}
+	}
+	return p
+}
+
+// connect lays a route between two cities, and returns the route tiles in
+// order, starting from cityA.
+func (p routePlanner) connect(cityA, cityB Tile, routeTiles map[Tile]bool) []Tile {
+	if p.isVictoryRoad(cityA, cityB) {
+		path := p.findVictoryRoadPath(cityA, cityB)
+		for _, t := range path {
+			routeTiles[t] = true
+		}
+		return path
+	}
+	coastal := p.opts.FollowCoasts && p.isCoastalCity(cityA) && p.isCoastalCity(cityB)
+	var cost func(from, to Tile) float64
+	switch {
+	case coastal:
+		cost = func(from, to Tile) float64 { return p.terrainCost(from, to, routeTiles, true) }
+	case p.opts.Costs != nil:
+		cost = func(from, to Tile) float64 { return p.terrainCost(from, to, routeTiles, false) }
+	default:
+		if path, ok := connectCities(p.rng, cityA, cityB, routeTiles, p.allowed); ok {
+			return path
+		}
+		// Neither L-shaped path stays inside the allowed area, so find
+		// the shortest path around the obstruction instead.
+		cost = func(from, to Tile) float64 { return 1 }
+	}
+	path := p.findCheapestPath(cityA, cityB, cost, nil)
+	for _, t := range path {
+		routeTiles[t] = true
+	}
+	return path
+}
+
+func (p routePlanner) terrainCost(from, to Tile, routeTiles map[Tile]bool, coastal bool) float64 {
+	costs := p.costs
+	elevation := p.tileElevations[to.X][to.Y]
+	cost := costs.StepCost
+	if elevation < 0 {
+		cost += costs.WaterPenalty
+	}
+	cost += costs.SlopePenalty * math.Abs(elevation-p.tileElevations[from.X][from.Y])
+	if elevation > costs.MountainElevation {
+		cost += costs.MountainPenalty
+	}
+	if routeTiles[to] {
+		cost -= costs.ReuseBonus
+	}
+	if coastal && elevation >= 0 && p.isNearWater(to, 1) {
+		cost -= costs.CoastBonus
+	}
+	if cost < minStepCost {
+		cost = minStepCost
+	}
+	return cost
+}
+
+func (p routePlanner) isVictoryRoad(cityA, cityB Tile) bool {
+	return p.opts.VictoryRoad != nil && cityA != cityB && p.opts.VictoryRoad.matches(cityA, cityB)
+}
+
+// findVictoryRoadPath plans a route that climbs through the highest terrain
+// between two cities. It detours through the highest reachable peak, and
+// prefers high ground, rather than easy ground, along the way.
+func (p routePlanner) findVictoryRoadPath(cityA, cityB Tile) []Tile {
+	// Peaks that force a real detour are preferred over peaks that are
+	// already on the way.
+	direct := cityA.Distance(cityB)
+	maxDistance := direct * victoryRoadDetour
+	minDistance := direct + direct/2
+	peak := cityA
+	peakElevation := math.Inf(-1)
+	peakDetours := false
+	highest := 0.0
+	for i := 0; i < p.tilesWidth; i++ {
+		for j := 0; j < p.tilesHeight; j++ {
+			t := Tile{i, j}
+			elevation := p.tileElevations[i][j]
+			if elevation > highest {
+				highest = elevation
+			}
+			if !p.allowed(t) || elevation < 0 || t == cityA || t == cityB {
+				continue
+			}
+			distance := t.Distance(cityA) + t.Distance(cityB)
+			if distance > maxDistance {
+				continue
+			}
+			detours := distance >= minDistance
+			if (detours && !peakDetours) || (detours == peakDetours && elevation > peakElevation) {
+				peak = t
+				peakElevation = elevation
+				peakDetours = detours
+			}
+		}
+	}
+
+	cost := func(from, to Tile) float64 {
+		elevation := p.tileElevations[to.X][to.Y]
+		cost := p.costs.StepCost + highest - elevation
+		if elevation < 0 {
+			cost += p.costs.WaterPenalty
+		}
+		if cost < minStepCost {
+			cost = minStepCost
+		}
+		return cost
+	}
+	if peak == cityA {
+		return p.findCheapestPath(cityA, cityB, cost, nil)
+	}
+	// Climb to the peak, and then descend to the destination without
+	// retracing any of the climb.
+	climb := p.findCheapestPath(cityA, peak, cost, nil)
+	blocked := map[Tile]bool{}
+	for _, t := range climb {
+		blocked[t] = true
+	}
+	descent := p.findCheapestPath(peak, cityB, cost, blocked)
+	if len(climb) == 0 || len(descent) == 0 {
+		return p.findCheapestPath(cityA, cityB, cost, nil)
+	}
+	return append(climb, descent...)
+}
+
+func (p routePlanner) isCoastalCity(city Tile) bool {
+	return p.isNearWater(city, coastalCityDistance)
+}
+
+// isNearWater reports whether there is a water tile within the given
+// manhattan distance of the tile.
+func (p routePlanner) isNearWater(t Tile, distance int) bool {
+	for dx := -distance; dx <= distance; dx++ {
+		for dy := -distance; dy <= distance; dy++ {
+			n := Tile{t.X + dx, t.Y + dy}
+			if n.X < 0 || n.Y < 0 || n.X >= p.tilesWidth || n.Y >= p.tilesHeight {
+				continue
+			}
+			if t.Distance(n) <= distance && p.tileElevations[n.X][n.Y] < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canEnter reports whether a route heading for the destination may pass
+// through the tile.
+func (p routePlanner) canEnter(t Tile, destination Tile) bool {
+	if t.X < 0 || t.Y < 0 || t.X >= p.tilesWidth || t.Y >= p.tilesHeight {
+		return false
+	}
+	return t == destination || p.allowed(t)
+}
+
+// findCheapestPath uses Dijkstra's algorithm to find the cheapest path
+// between two cities, where stepCost is the cost of moving between two
+// adjacent tiles. Routes never pass through blocked tiles. The returned
+// path starts at cityA, and excludes cityB.
+func (p routePlanner) findCheapestPath(cityA, cityB Tile, stepCost func(from, to Tile) float64, blocked map[Tile]bool) []Tile {
+	costs := map[Tile]float64{cityA: 0}
+	previous := map[Tile]Tile{}
+	queue := &tileQueue{}
+	heap.Push(queue, tileQueueItem{cityA, 0})
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(tileQueueItem)
+		if item.cost > costs[item.tile] {
+			continue
+		}
+		if item.tile == cityB {
+			break
+		}
+		for _, n := range item.tile.neighbors() {
+			if !p.canEnter(n, cityB) || blocked[n] {
+				continue
+			}
+			cost := item.cost + stepCost(item.tile, n)
+			if existing, ok := costs[n]; !ok || cost < existing {
+				costs[n] = cost
+				previous[n] = item.tile
+				heap.Push(queue, tileQueueItem{n, cost})
+			}
+		}
+	}
+
+	path := []Tile{}
+	for t := cityB; t != cityA; {
+		prev, ok := previous[t]
+		if !ok {
+			return []Tile{}
+		}
+		path = append(path, prev)
+		t = prev
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+type tileQueueItem struct {
+	tile Tile
+	cost float64
+}
+
+// tileQueue is a min-heap of tiles, ordered by cost.
+type tileQueue []tileQueueItem
+
+func (q tileQueue) Len() int            { return len(q) }
+func (q tileQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q tileQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *tileQueue) Push(x interface{}) { *q = append(*q, x.(tileQueueItem)) }
+func (q *tileQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
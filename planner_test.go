@@ -0,0 +1,212 @@
+package porygion
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestVictoryRoad(t *testing.T) {
+	base, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	// Pick two cities that aren't connected directly, so that the victory
+	// road has to be forced in.
+	var pair CityPair
+	for _, a := range base.Cities {
+		for _, b := range base.Cities {
+			if a != b && findRouteConnection(base.Connections, a, b) == -1 {
+				pair = CityPair{a, b}
+			}
+		}
+	}
+	if pair.A == pair.B {
+		t.Fatalf("every pair of cities is connected")
+	}
+	config := NewConfig(MapParams{Seed: 3, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	config.Routes.VictoryRoad = &pair
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	regionMap, err := config.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	numVictoryRoads := 0
+	for _, c := range regionMap.Connections {
+		if c.VictoryRoad {
+			numVictoryRoads++
+			if !pair.matches(c.CityA, c.CityB) {
+				t.Errorf("victory road connects %v and %v, want %v and %v", c.CityA, c.CityB, pair.A, pair.B)
+			}
+			if len(c.Tiles) == 0 {
+				t.Errorf("victory road has no tiles")
+			}
+		}
+	}
+	if numVictoryRoads != 1 {
+		t.Errorf("region map has %d victory roads, want 1", numVictoryRoads)
+	}
+}
+
+func TestVictoryRoadMustConnectCities(t *testing.T) {
+	base, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	city := base.Cities[0]
+	tests := []struct {
+		name     string
+		pair     CityPair
+		validate string
+		generate string
+	}{
+		{"off the map", CityPair{city, Tile{100, 100}}, "outside the 30x20 tile map", ""},
+		{"negative", CityPair{Tile{-1, 3}, city}, "outside the 30x20 tile map", ""},
+		{"same city", CityPair{city, city}, "both ends are city", ""},
+		{"not a city", CityPair{city, Tile{0, 0}}, "", "{0 0} is not a city"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewConfig(MapParams{Seed: 3, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+			config.Routes.VictoryRoad = &test.pair
+			err := config.Validate()
+			if test.validate != "" {
+				if err == nil || !strings.Contains(err.Error(), test.validate) {
+					t.Errorf("Validate error = %v, want %q", err, test.validate)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate: %s", err)
+			}
+			if _, err := config.Generate(); err == nil || !strings.Contains(err.Error(), test.generate) {
+				t.Errorf("Generate error = %v, want %q", err, test.generate)
+			}
+		})
+	}
+
+	// Rerouting an existing map checks the pair against its cities too.
+	pair := CityPair{city, Tile{0, 0}}
+	if _, err := GenerateRegionMapWithRouteOptions(3, base, RouteOptions{VictoryRoad: &pair}); err == nil {
+		t.Errorf("GenerateRegionMapWithRouteOptions succeeded, want an error")
+	}
+}
+
+// testPlannerElevations returns the elevations of a flat 30x20 tile map,
+// with a wall of water tiles down column 10 that stops short of row 13.
+func testPlannerElevations() [][]float64 {
+	elevations := getNewElevationMap(240, 160)
+	for x, column := range elevations {
+		for y := range column {
+			column[y] = 0.5
+			if x/8 == 10 && y/8 < 13 {
+				column[y] = -0.5
+			}
+		}
+	}
+	return elevations
+}
+
+func TestRouteCosts(t *testing.T) {
+	tests := []struct {
+		name         string
+		waterPenalty float64
+		maxLength    int
+	}{
+		{"crosses cheap water", 0, 2},
+		{"avoids expensive water", 100, 18},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			costs := RouteCosts{StepCost: 1, WaterPenalty: test.waterPenalty}
+			planner := newRoutePlanner(testPlannerElevations(), RouteOptions{Costs: &costs}, rand.New(rand.NewSource(1)))
+			path := planner.connect(Tile{9, 5}, Tile{11, 5}, map[Tile]bool{})
+			if len(path) > test.maxLength {
+				t.Errorf("path has %d tiles, want at most %d", len(path), test.maxLength)
+			}
+			crossesWater := false
+			for _, tile := range path {
+				if planner.tileElevations[tile.X][tile.Y] < 0 {
+					crossesWater = true
+				}
+			}
+			if crossesWater != (test.waterPenalty == 0) {
+				t.Errorf("path %v crosses water = %t", path, crossesWater)
+			}
+		})
+	}
+
+	costs := DefaultRouteCosts()
+	config := NewConfig(MapParams{Seed: 3, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	config.Routes.Costs = &costs
+	regionMap, err := config.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	checkRoutePaths(t, regionMap)
+	if !regionMap.ValidateConnectivity().Connected {
+		t.Errorf("region map routed by cost isn't connected")
+	}
+}
+
+func TestRouteMask(t *testing.T) {
+	allowed := func(tile Tile) bool { return tile.X != 10 || tile.Y >= 13 }
+	planner := newRoutePlanner(testPlannerElevations(), RouteOptions{RouteMask: allowed}, rand.New(rand.NewSource(1)))
+	path := planner.connect(Tile{9, 5}, Tile{11, 5}, map[Tile]bool{})
+	if len(path) == 0 {
+		t.Fatalf("no path around the mask")
+	}
+	for _, tile := range path {
+		if !allowed(tile) {
+			t.Errorf("path %v passes through %v, which the mask forbids", path, tile)
+		}
+	}
+
+	// Without a mask, routes stay inside the placement area.
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	for _, tile := range regionMap.Routes {
+		if !isInPlacementArea(tile) {
+			t.Errorf("route %v is outside the placement area", tile)
+		}
+	}
+}
+
+func TestFollowCoasts(t *testing.T) {
+	// Flat land, with the sea along the top three rows of tiles.
+	elevations := getNewElevationMap(240, 160)
+	for _, column := range elevations {
+		for y := range column {
+			column[y] = 0.5
+			if y/8 < 3 {
+				column[y] = -0.5
+			}
+		}
+	}
+	planner := newRoutePlanner(elevations, RouteOptions{FollowCoasts: true}, rand.New(rand.NewSource(1)))
+	cityA, cityB := Tile{5, 4}, Tile{15, 4}
+	path := planner.connect(cityA, cityB, map[Tile]bool{})
+	if len(path) == 0 {
+		t.Fatalf("no path between the coastal cities")
+	}
+	for _, tile := range path {
+		if tile != cityA && tile.Y != 3 {
+			t.Errorf("path %v leaves the coast at %v", path, tile)
+		}
+	}
+
+	config := NewConfig(MapParams{Seed: 3, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	config.Routes.FollowCoasts = true
+	regionMap, err := config.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	checkRoutePaths(t, regionMap)
+	if !regionMap.ValidateConnectivity().Connected {
+		t.Errorf("region map with coast-following routes isn't connected")
+	}
+}
@@ -0,0 +1,188 @@
+package porygion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pluginProtocolVersion is the version of the plugin protocol. It is
+// increased whenever the messages change in a way that old plugins can't
+// handle.
+const pluginProtocolVersion = 1
+
+// PluginStage is a stage of the generation pipeline that a plugin runs
+// after.
+type PluginStage string
+
+// Generation stages, in the order that they run.
+const (
+	// PluginStageElevations runs after the terrain is generated, before
+	// cities are placed. It's the place for passes like erosion.
+	PluginStageElevations PluginStage = "elevations"
+	// PluginStageCities runs after the cities are placed, before the
+	// routes are planned between them.
+	PluginStageCities PluginStage = "cities"
+	// PluginStageRoutes runs after the routes are planned, on the finished
+	// map.
+	PluginStageRoutes PluginStage = "routes"
+)
+
+// pluginStages are the valid plugin stages.
+var pluginStages = []PluginStage{PluginStageElevations, PluginStageCities, PluginStageRoutes}
+
+// Plugin is an external program that implements a custom pass of the
+// generation pipeline, so that community extensions can be used without
+// recompiling porygion. The program is run once per map, and speaks JSON
+// over its standard input and output. It reads one request:
+//
+//	{"protocol": 1, "stage": "elevations", "seed": 42, "map": {...}}
+//
+// where map is the region map generated so far, in the format written by
+// RegionMap.MarshalJSON. It must write one response, either with the
+// updated region map, or with an error:
+//
+//	{"map": {...}}
+//	{"error": "message"}
+//
+// The updated map must keep its size. Anything the plugin writes to its
+// standard error is passed through, for logging.
+type Plugin struct {
+	Stage   PluginStage
+	Command string
+	Args    []string
+}
+
+// pluginRequest is the message sent to a plugin.
+type pluginRequest struct {
+	Protocol int         `json:"protocol"`
+	Stage    PluginStage `json:"stage"`
+	Seed     int64       `json:"seed"`
+	Map      RegionMap   `json:"map"`
+}
+
+// pluginResponse is the message that a plugin replies with.
+type pluginResponse struct {
+	Map   *RegionMap `json:"map"`
+	Error string     `json:"error"`
+}
+
+// ParsePlugin parses a plugin from a string like "elevations=./erode -n 3",
+// which gives the stage, and then the command and its arguments, separated
+// by spaces.
+func ParsePlugin(s string) (Plugin, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return Plugin{}, fmt.Errorf("Invalid plugin %q. Expected a stage and command, like elevations=./erode", s)
+	}
+	fields := strings.Fields(s[i+1:])
+	if len(fields) == 0 {
+		return Plugin{}, fmt.Errorf("Invalid plugin %q: missing command", s)
+	}
+	p := Plugin{Stage: PluginStage(s[:i]), Command: fields[0], Args: fields[1:]}
+	return p, p.Validate()
+}
+
+// Validate checks that the plugin has a valid stage and a command.
+func (p Plugin) Validate() error {
+	if p.Command == "" {
+		return fmt.Errorf("Plugin has no command")
+	}
+	names := []string{}
+	for _, stage := range pluginStages {
+		if p.Stage == stage {
+			return nil
+		}
+		names = append(names, string(stage))
+	}
+	return fmt.Errorf("Unknown plugin stage '%s'. Valid stages are: %s", p.Stage, strings.Join(names, ", "))
+}
+
+// Run runs the plugin on a region map, and returns the updated map.
+func (p Plugin) Run(seed int64, regionMap RegionMap) (RegionMap, error) {
+	request, err := json.Marshal(pluginRequest{pluginProtocolVersion, p.Stage, seed, regionMap})
+	if err != nil {
+		return RegionMap{}, fmt.Errorf("Failed to encode plugin request: %s", err)
+	}
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.Command, p.Args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	var response pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return RegionMap{}, fmt.Errorf("Plugin %s failed: %s", p.Command, runErr)
+		}
+		return RegionMap{}, fmt.Errorf("Plugin %s sent an invalid response: %s", p.Command, err)
+	}
+	if response.Error != "" {
+		return RegionMap{}, fmt.Errorf("Plugin %s failed: %s", p.Command, response.Error)
+	}
+	if runErr != nil {
+		return RegionMap{}, fmt.Errorf("Plugin %s failed: %s", p.Command, runErr)
+	}
+	if response.Map == nil {
+		return RegionMap{}, fmt.Errorf("Plugin %s didn't send a region map", p.Command)
+	}
+	updated := *response.Map
+	if updated.PixelWidth != regionMap.PixelWidth || updated.PixelHeight != regionMap.PixelHeight {
+		return RegionMap{}, fmt.Errorf("Plugin %s changed the region map's size from %dx%d to %dx%d", p.Command, regionMap.PixelWidth, regionMap.PixelHeight, updated.PixelWidth, updated.PixelHeight)
+	}
+	return updated, nil
+}
+
+// GenerateWithPlugins generates the region map described by the config,
+// like Generate, and runs each plugin after its stage of the pipeline.
+// Plugins for the same stage run in order. Without any plugins, the map is
+// the same as Generate's.
+func (c Config) GenerateWithPlugins(plugins []Plugin) (RegionMap, error) {
+	for _, p := range plugins {
+		if err := p.Validate(); err != nil {
+			return RegionMap{}, err
+		}
+	}
+	hook := func(stage PluginStage, regionMap RegionMap) (RegionMap, error) {
+		for _, p := range plugins {
+			if p.Stage != stage {
+				continue
+			}
+			var err error
+			if regionMap, err = p.Run(c.Seed, regionMap); err != nil {
+				return RegionMap{}, err
+			}
+		}
+		return regionMap, nil
+	}
+	return generateRegionMap(c.Seed, c.PixelWidth, c.PixelHeight, c.NumCities, c.Routes, c.ElevationWorkers, nil, hook)
+}
+
+// stageHook is called after each stage of generation, and may replace the
+// region map generated so far.
+type stageHook func(stage PluginStage, regionMap RegionMap) (RegionMap, error)
+
+// run calls the hook, if there is one, and checks that the updated region
+// map is still valid.
+func (h stageHook) run(stage PluginStage, regionMap RegionMap) (RegionMap, error) {
+	if h == nil {
+		return regionMap, nil
+	}
+	updated, err := h(stage, regionMap)
+	if err != nil {
+		return RegionMap{}, err
+	}
+	if err := validateElevations(updated.Elevations, regionMap.PixelWidth, regionMap.PixelHeight); err != nil {
+		return RegionMap{}, fmt.Errorf("Invalid region map after the %s stage: %s", stage, err)
+	}
+	for _, city := range updated.Cities {
+		if city.X < 0 || city.Y < 0 || city.X >= regionMap.PixelWidth/8 || city.Y >= regionMap.PixelHeight/8 {
+			return RegionMap{}, fmt.Errorf("Invalid region map after the %s stage: city (%d, %d) is outside of the map", stage, city.X, city.Y)
+		}
+	}
+	return updated, nil
+}
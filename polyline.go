@@ -0,0 +1,75 @@
+package porygion
+
+// RouteStep is a single tile along a route's path, along with the
+// direction of travel when leaving the tile.
+type RouteStep struct {
+	Tile      Tile
+	Direction Direction
+}
+
+// Polyline returns the ordered path of the connection, starting at CityA
+// and ending at CityB. The final step keeps the direction of travel that
+// was used to arrive at CityB.
+func (c RouteConnection) Polyline() []RouteStep {
+	path := c.path()
+	steps := make([]RouteStep, len(path))
+	for i, t := range path {
+		steps[i].Tile = t
+		if i+1 < len(path) {
+			steps[i].Direction = t.directionTo(path[i+1])
+		} else if i > 0 {
+			steps[i].Direction = steps[i-1].Direction
+		}
+	}
+	return steps
+}
+
+// Vertices returns the endpoints and turning points of the connection's
+// path, in order from CityA to CityB.
+func (c RouteConnection) Vertices() []Tile {
+	steps := c.Polyline()
+	vertices := []Tile{}
+	for i, s := range steps {
+		if i == 0 || i == len(steps)-1 || s.Direction != steps[i-1].Direction {
+			vertices = append(vertices, s.Tile)
+		}
+	}
+	return vertices
+}
+
+// Length returns the number of steps needed to travel from CityA to CityB.
+func (c RouteConnection) Length() int {
+	return len(c.Tiles) + 1
+}
+
+// DistanceAlong returns the number of steps from CityA to the given tile,
+// when following the connection's path. ok is false when the tile is not
+// on the path.
+func (c RouteConnection) DistanceAlong(t Tile) (distance int, ok bool) {
+	for i, p := range c.path() {
+		if p == t {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// TileAt returns the tile that is the given number of steps from CityA,
+// when following the connection's path. ok is false when the distance is
+// beyond either end of the path.
+func (c RouteConnection) TileAt(distance int) (t Tile, ok bool) {
+	path := c.path()
+	if distance < 0 || distance >= len(path) {
+		return Tile{}, false
+	}
+	return path[distance], true
+}
+
+// path returns the full path of the connection, including both cities.
+func (c RouteConnection) path() []Tile {
+	path := make([]Tile, 0, len(c.Tiles)+2)
+	path = append(path, c.CityA)
+	path = append(path, c.Tiles...)
+	path = append(path, c.CityB)
+	return path
+}
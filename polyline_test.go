@@ -0,0 +1,79 @@
+package porygion
+
+import (
+	"reflect"
+	"testing"
+)
+
+// checkRoutePaths checks that every connection's path is an unbroken run
+// of adjacent tiles from CityA to CityB, and that its tiles are routes.
+func checkRoutePaths(t *testing.T, regionMap RegionMap) {
+	t.Helper()
+	routes := map[Tile]bool{}
+	for _, r := range regionMap.Routes {
+		routes[r] = true
+	}
+	for _, c := range regionMap.Connections {
+		steps := c.Polyline()
+		if len(steps) != c.Length()+1 || steps[0].Tile != c.CityA || steps[len(steps)-1].Tile != c.CityB {
+			t.Errorf("polyline of %v-%v doesn't run between its cities", c.CityA, c.CityB)
+			continue
+		}
+		for i := 1; i < len(steps); i++ {
+			if steps[i-1].Tile.Distance(steps[i].Tile) != 1 {
+				t.Errorf("path of %v-%v jumps from %v to %v", c.CityA, c.CityB, steps[i-1].Tile, steps[i].Tile)
+			}
+		}
+		for _, r := range c.Tiles {
+			if !routes[r] {
+				t.Errorf("path of %v-%v passes through %v, which isn't a route", c.CityA, c.CityB, r)
+			}
+		}
+	}
+}
+
+func TestPolyline(t *testing.T) {
+	c := RouteConnection{
+		CityA: Tile{1, 1},
+		CityB: Tile{3, 3},
+		Tiles: []Tile{{2, 1}, {3, 1}, {3, 2}},
+	}
+	want := []RouteStep{
+		{Tile{1, 1}, East},
+		{Tile{2, 1}, East},
+		{Tile{3, 1}, South},
+		{Tile{3, 2}, South},
+		{Tile{3, 3}, South},
+	}
+	if steps := c.Polyline(); !reflect.DeepEqual(steps, want) {
+		t.Errorf("Polyline() = %v, want %v", steps, want)
+	}
+	if vertices := c.Vertices(); !reflect.DeepEqual(vertices, []Tile{{1, 1}, {3, 1}, {3, 3}}) {
+		t.Errorf("Vertices() = %v", vertices)
+	}
+	if length := c.Length(); length != 4 {
+		t.Errorf("Length() = %d, want 4", length)
+	}
+	if d, ok := c.DistanceAlong(Tile{3, 2}); !ok || d != 3 {
+		t.Errorf("DistanceAlong({3 2}) = %d, %t, want 3", d, ok)
+	}
+	if _, ok := c.DistanceAlong(Tile{5, 5}); ok {
+		t.Errorf("DistanceAlong({5 5}) found a tile that isn't on the path")
+	}
+	if tile, ok := c.TileAt(4); !ok || tile != c.CityB {
+		t.Errorf("TileAt(4) = %v, %t, want %v", tile, ok, c.CityB)
+	}
+	for _, d := range []int{-1, 5} {
+		if _, ok := c.TileAt(d); ok {
+			t.Errorf("TileAt(%d) found a tile beyond the path", d)
+		}
+	}
+}
+
+func TestGeneratedPolylines(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	checkRoutePaths(t, regionMap)
+}
@@ -3,50 +3,90 @@ package porygion
 import (
 	"fmt"
 	"image"
+	"math"
 	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 
-	"github.com/muesli/clusters"
-	"github.com/muesli/kmeans"
 	simplex "github.com/ojrac/opensimplex-go"
 )
 
 // RegionMap represents a generated region map.
 type RegionMap struct {
+	// Seed is the seed that the region map's terrain was generated from.
+	Seed        int64
 	PixelWidth  int
 	PixelHeight int
 	Elevations  [][]float64
 	Cities      []Tile
 	Routes      []Tile
+	Connections []RouteConnection
+	// CityNames optionally maps city tiles to their display names.
+	CityNames map[Tile]string
+	// CityKinds optionally maps city tiles to their kinds. Cities without
+	// a kind are towns.
+	CityKinds map[Tile]CityKind
 }
 
 // GenerateRegionMap generates a new complete region map.
 func GenerateRegionMap(seed int64, pixelWidth, pixelHeight int, numCities int) (RegionMap, error) {
-	rand.Seed(seed)
+	return generateRegionMap(seed, pixelWidth, pixelHeight, numCities, RouteOptions{}, 0, nil, nil)
+}
+
+// generateRegionMap generates a new complete region map, planning its
+// routes with the given options, and its elevations with the given
+// number of workers. When debug is non-nil, the internal state
+// of each generation step is recorded in it. When hook is non-nil, it's
+// called after each stage, and may change the map before the next stage.
+func generateRegionMap(seed int64, pixelWidth, pixelHeight int, numCities int, routeOpts RouteOptions, workers int, debug *DebugInfo, hook stageHook) (RegionMap, error) {
+	rng := rand.New(rand.NewSource(seed))
 	elevations := getNewElevationMap(pixelWidth, pixelHeight)
-	generateElevations(elevations)
-	validTiles := getValidLandmarkTiles(elevations)
-	partitions := partitionTilesByLocation(100, 100, pixelWidth/8, pixelHeight/8, validTiles)
-	cities := generateCities(partitions, numCities)
-	cityClusters, err := clusterCities(cities)
-	if err != nil {
-		return RegionMap{}, err
+	var baseElevations [][]float64
+	if debug != nil {
+		baseElevations = getNewElevationMap(pixelWidth, pixelHeight)
 	}
-	routes := generateRoutes(cityClusters)
-	return RegionMap{
+	generateElevations(rng, elevations, baseElevations, workers)
+	regionMap := RegionMap{
+		Seed:        seed,
 		PixelWidth:  pixelWidth,
 		PixelHeight: pixelHeight,
 		Elevations:  elevations,
-		Cities:      cities,
-		Routes:      routes,
-	}, nil
+	}
+	regionMap, err := hook.run(PluginStageElevations, regionMap)
+	if err != nil {
+		return RegionMap{}, err
+	}
+	validTiles := getValidLandmarkTiles(regionMap.Elevations)
+	partitions := partitionTilesByLocation(cityPartitionSize, cityPartitionSize, pixelWidth/8, pixelHeight/8, validTiles)
+	regionMap.Cities = generateCities(rng, partitions, numCities, debug)
+	if regionMap, err = hook.run(PluginStageCities, regionMap); err != nil {
+		return RegionMap{}, err
+	}
+	cityClusters, err := clusterCities(rng, regionMap.Cities)
+	if err != nil {
+		return RegionMap{}, err
+	}
+	if debug != nil {
+		debug.BaseElevations = baseElevations
+		debug.PartitionSize = cityPartitionSize
+		debug.ValidTiles = validTiles
+		debug.Clusters = cityClusters
+	}
+	regionMap.Routes, regionMap.Connections, err = generateRoutes(regionMap.Cities, cityClusters, newRoutePlanner(regionMap.Elevations, routeOpts, rng))
+	if err != nil {
+		return RegionMap{}, err
+	}
+	return hook.run(PluginStageRoutes, regionMap)
 }
 
 // GenerateBaseRegionMap generates a new region map containing only elevations.
 func GenerateBaseRegionMap(seed int64, pixelWidth, pixelHeight int) RegionMap {
-	rand.Seed(seed)
+	rng := rand.New(rand.NewSource(seed))
 	elevations := getNewElevationMap(pixelWidth, pixelHeight)
-	generateElevations(elevations)
+	generateElevations(rng, elevations, nil, 0)
 	return RegionMap{
+		Seed:        seed,
 		PixelWidth:  pixelWidth,
 		PixelHeight: pixelHeight,
 		Elevations:  elevations,
@@ -56,10 +96,10 @@ func GenerateBaseRegionMap(seed int64, pixelWidth, pixelHeight int) RegionMap {
 // GenerateRegionMapWithCities generates a new region map with new city locations, using
 // the provided region map.
 func GenerateRegionMapWithCities(seed int64, numCities int, regionMap RegionMap) RegionMap {
-	rand.Seed(seed)
+	rng := rand.New(rand.NewSource(seed))
 	validTiles := getValidLandmarkTiles(regionMap.Elevations)
-	partitions := partitionTilesByLocation(100, 100, regionMap.PixelWidth/8, regionMap.PixelHeight/8, validTiles)
-	cities := generateCities(partitions, numCities)
+	partitions := partitionTilesByLocation(cityPartitionSize, cityPartitionSize, regionMap.PixelWidth/8, regionMap.PixelHeight/8, validTiles)
+	cities := generateCities(rng, partitions, numCities, nil)
 	regionMap.Cities = cities
 	return regionMap
 }
@@ -67,34 +107,89 @@ func GenerateRegionMapWithCities(seed int64, numCities int, regionMap RegionMap)
 // GenerateRegionMapWithRoutes generates a new region map with new route locations, using
 // the provided region map.
 func GenerateRegionMapWithRoutes(seed int64, regionMap RegionMap) (RegionMap, error) {
-	rand.Seed(seed)
-	cityClusters, err := clusterCities(regionMap.Cities)
+	return GenerateRegionMapWithRouteOptions(seed, regionMap, RouteOptions{})
+}
+
+// GenerateRegionMapWithRouteOptions generates a new region map with new route locations,
+// using the provided region map and route planning options.
+func GenerateRegionMapWithRouteOptions(seed int64, regionMap RegionMap, opts RouteOptions) (RegionMap, error) {
+	rng := rand.New(rand.NewSource(seed))
+	cityClusters, err := clusterCities(rng, regionMap.Cities)
+	if err != nil {
+		return RegionMap{}, err
+	}
+	routes, connections, err := generateRoutes(regionMap.Cities, cityClusters, newRoutePlanner(regionMap.Elevations, opts, rng))
 	if err != nil {
 		return RegionMap{}, err
 	}
-	routes := generateRoutes(cityClusters)
 	regionMap.Routes = routes
+	regionMap.Connections = connections
 	return regionMap, nil
 }
 
 // RenderBaseRegionMap renders a region map using only its elevations.
 func RenderBaseRegionMap(regionMap RegionMap) image.Image {
-	img := renderRegionMapImage(regionMap.Elevations, []Tile{}, []Tile{})
+	regionMap.Cities = nil
+	regionMap.Routes = nil
+	regionMap.Connections = nil
+	img := renderRegionMapImage(regionMap, RenderOptions{})
 	return img
 }
 
 // RenderRegionMapWithCities renders a region map using only its elevations and cities.
 func RenderRegionMapWithCities(regionMap RegionMap) image.Image {
-	img := renderRegionMapImage(regionMap.Elevations, regionMap.Cities, []Tile{})
+	regionMap.Routes = nil
+	regionMap.Connections = nil
+	img := renderRegionMapImage(regionMap, RenderOptions{})
 	return img
 }
 
 // RenderFullRegionMap renders a full region map.
 func RenderFullRegionMap(regionMap RegionMap) image.Image {
-	img := renderRegionMapImage(regionMap.Elevations, regionMap.Cities, regionMap.Routes)
+	img := renderRegionMapImage(regionMap, RenderOptions{})
+	return img
+}
+
+// RenderFullRegionMapScaled renders a full region map, enlarged by an
+// integer scale factor.
+func RenderFullRegionMapScaled(regionMap RegionMap, scale int) image.Image {
+	img := renderRegionMapImage(regionMap, RenderOptions{Scale: scale})
 	return img
 }
 
+// RenderRegionMap renders a full region map, using the given render options.
+func RenderRegionMap(regionMap RegionMap, opts RenderOptions) image.Image {
+	img := renderRegionMapImage(regionMap, opts)
+	return img
+}
+
+// RenderTerrainLayer renders only the terrain of a region map.
+func RenderTerrainLayer(regionMap RegionMap, opts RenderOptions) image.Image {
+	return renderLayerImage(regionMap, opts, func(img *image.RGBA, palette Palette) {
+		drawTerrain(img, regionMap, palette, opts)
+	})
+}
+
+// RenderRoutesLayer renders only the routes of a region map. Everything other
+// than the routes is transparent.
+func RenderRoutesLayer(regionMap RegionMap, opts RenderOptions) image.Image {
+	return renderLayerImage(regionMap, opts, func(img *image.RGBA, palette Palette) {
+		drawRoutes(img, regionMap, palette, opts)
+	})
+}
+
+// RenderCitiesLayer renders only the cities of a region map. Everything other
+// than the cities is transparent.
+func RenderCitiesLayer(regionMap RegionMap, opts RenderOptions) image.Image {
+	return renderLayerImage(regionMap, opts, func(img *image.RGBA, palette Palette) {
+		drawCities(img, regionMap, palette, opts)
+	})
+}
+
+// cityPartitionSize is the width and height, in tiles, of the partitions
+// that cities are spread across.
+const cityPartitionSize = 100
+
 func getNewElevationMap(width, height int) [][]float64 {
 	elevations := make([][]float64, width)
 	for i := range elevations {
@@ -103,12 +198,46 @@ func getNewElevationMap(width, height int) [][]float64 {
 	return elevations
 }
 
-func generateElevations(elevations [][]float64) {
-	baseNoise := simplex.New(rand.Int63())
-	secondaryNoise := simplex.New(rand.Int63())
-	jitterNoise := simplex.New(rand.Int63())
-	jitterCoeffNoise := simplex.New(rand.Int63())
-	for i := range elevations {
+// generateElevations fills the elevation map with layered noise. When
+// baseElevations is not nil, it is filled with the base noise layer alone.
+// The map is split into one band of columns per worker, and the bands are
+// filled concurrently. Evaluating the noise doesn't change it, so the
+// workers can share it, and the result doesn't depend on the number of
+// workers. When workers is less than 1, there is one per CPU.
+func generateElevations(rng *rand.Rand, elevations, baseElevations [][]float64, workers int) {
+	baseNoise := simplex.New(rng.Int63())
+	secondaryNoise := simplex.New(rng.Int63())
+	jitterNoise := simplex.New(rng.Int63())
+	jitterCoeffNoise := simplex.New(rng.Int63())
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(elevations) {
+		workers = len(elevations)
+	}
+	if workers < 1 {
+		return
+	}
+	bandWidth := (len(elevations) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(elevations); start += bandWidth {
+		end := start + bandWidth
+		if end > len(elevations) {
+			end = len(elevations)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			generateElevationBand(elevations, baseElevations, start, end, baseNoise, secondaryNoise, jitterNoise, jitterCoeffNoise)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// generateElevationBand fills the columns from start up to end of the
+// elevation map with layered noise.
+func generateElevationBand(elevations, baseElevations [][]float64, start, end int, baseNoise, secondaryNoise, jitterNoise, jitterCoeffNoise simplex.Noise) {
+	for i := start; i < end; i++ {
 		for j := range elevations[i] {
 			baseElevation := baseNoise.Eval2(float64(i)/100.0, float64(j)/100.0) + 0.2
 			secondaryElevation := secondaryNoise.Eval2(float64(i)/20.0, float64(j)/20.0) * 0.15
@@ -116,6 +245,9 @@ func generateElevations(elevations [][]float64) {
 			jitterCoeff := jitterCoeffNoise.Eval2(float64(i)/50.0, float64(j)/50.0) * 0.6
 			elevation := baseElevation + secondaryElevation + jitterElevation*jitterCoeff
 			elevations[i][j] = elevation
+			if baseElevations != nil {
+				baseElevations[i][j] = baseElevation
+			}
 		}
 	}
 }
@@ -167,7 +299,7 @@ func partitionTilesByLocation(partitionWidth, partitionHeight, tileWidth, tileHe
 	return partitions
 }
 
-func generateCities(partitions map[string][]Tile, numCities int) []Tile {
+func generateCities(rng *rand.Rand, partitions map[string][]Tile, numCities int, debug *DebugInfo) []Tile {
 	// First, get a randomized order of the partitions.
 	partitionKeys := make([]string, len(partitions))
 	i := 0
@@ -175,50 +307,42 @@ func generateCities(partitions map[string][]Tile, numCities int) []Tile {
 		partitionKeys[i] = k
 		i++
 	}
-	rand.Shuffle(len(partitionKeys), func(i, j int) { partitionKeys[i], partitionKeys[j] = partitionKeys[j], partitionKeys[i] })
+	// Sort the keys before shuffling, since map order is random, and the
+	// same seed should always give the same cities.
+	sort.Strings(partitionKeys)
+	rng.Shuffle(len(partitionKeys), func(i, j int) { partitionKeys[i], partitionKeys[j] = partitionKeys[j], partitionKeys[i] })
 
 	// Loop through partitions, placing one city at a time.
 	cities := map[Tile]bool{}
+	result := []Tile{}
 	for c := 0; c < numCities; c++ {
 		partition := partitions[partitionKeys[c%len(partitionKeys)]]
 		// Attempt to place the city many times, in case several attempts fail,
 		// due to contraints.
 		for i := 0; i < 50; i++ {
-			if city, ok := tryPickCityTile(partition); ok {
+			if city, ok := tryPickCityTile(rng, partition, debug); ok {
 				if _, ok = cities[city]; !ok {
 					cities[city] = true
+					result = append(result, city)
 					break
 				}
 			}
 		}
 	}
-	result := make([]Tile, len(cities))
-	i = 0
-	for city := range cities {
-		result[i] = city
-		i++
-	}
 	return result
 }
 
-func tryPickCityTile(partition []Tile) (Tile, bool) {
+func tryPickCityTile(rng *rand.Rand, partition []Tile, debug *DebugInfo) (Tile, bool) {
 	// Pick a random tile from the partition, and evaluate whether or not
 	// we can place a city there.
 	for j := 0; j < 50; j++ {
-		candidate := partition[rand.Intn(len(partition))]
+		candidate := partition[rng.Intn(len(partition))]
 		// Only allow cities on a 2x2 grid, to avoid adjacent cities
-		// and routes.
-		if candidate.X%2 != 1 || candidate.Y%2 != 1 {
-			continue
-		}
-		// Don't allow cities to be placed where the in-game UI elements allow.
-		if candidate.X < 1 || candidate.Y < 2 || candidate.X > 28 || candidate.Y > 16 {
-			continue
-		}
-		if candidate.X > 14 && candidate.Y > 14 {
-			continue
-		}
-		if candidate.X > 19 && candidate.Y < 5 {
+		// and routes, and keep them clear of the in-game UI elements.
+		if candidate.X%2 != 1 || candidate.Y%2 != 1 || !isInPlacementArea(candidate) {
+			if debug != nil {
+				debug.RejectedCandidates = append(debug.RejectedCandidates, candidate)
+			}
 			continue
 		}
 		return candidate, true
@@ -226,29 +350,91 @@ func tryPickCityTile(partition []Tile) (Tile, bool) {
 	return Tile{}, false
 }
 
-func clusterCities(cities []Tile) ([][]Tile, error) {
-	// Cluster the cities into 2 groups, using k-means.
-	var points clusters.Observations
-	for _, city := range cities {
-		points = append(points, clusters.Coordinates{float64(city.X), float64(city.Y)})
+// isInPlacementArea reports whether a tile is clear of the in-game UI elements,
+// so that cities and routes can be placed there.
+func isInPlacementArea(t Tile) bool {
+	if t.X < 1 || t.Y < 2 || t.X > 28 || t.Y > 16 {
+		return false
 	}
-	km := kmeans.New()
-	clusters, err := km.Partition(points, 2)
-	if err != nil {
-		return [][]Tile{}, fmt.Errorf("Failed to cluster cities: %s", err)
+	if t.X > 14 && t.Y > 14 {
+		return false
 	}
-	cityClusters := make([][]Tile, len(clusters))
-	for i, cluster := range clusters {
-		for _, o := range cluster.Observations {
-			coords := o.Coordinates()
-			cityClusters[i] = append(cityClusters[i], Tile{int(coords[0]), int(coords[1])})
+	if t.X > 19 && t.Y < 5 {
+		return false
+	}
+	return true
+}
+
+// maxClusterIterations limits the number of k-means iterations used to
+// cluster the cities. It is only reached in unusual, oscillating layouts.
+const maxClusterIterations = 100
+
+func clusterCities(rng *rand.Rand, cities []Tile) ([][]Tile, error) {
+	// Cluster the cities into 2 groups, using k-means. The initial centers
+	// are two distinct cities, picked with the seeded random source so that
+	// the clusters are the same every time.
+	if len(cities) < 2 {
+		return [][]Tile{}, fmt.Errorf("Failed to cluster cities: at least 2 cities are needed, but there are %d", len(cities))
+	}
+	type center struct{ x, y float64 }
+	first := rng.Intn(len(cities))
+	second := rng.Intn(len(cities) - 1)
+	if second >= first {
+		second++
+	}
+	centers := [2]center{
+		{float64(cities[first].X), float64(cities[first].Y)},
+		{float64(cities[second].X), float64(cities[second].Y)},
+	}
+	assignments := make([]int, len(cities))
+	for iteration := 0; iteration < maxClusterIterations; iteration++ {
+		changed := false
+		for i, city := range cities {
+			nearest := 0
+			bestDistance := math.Inf(1)
+			for c, center := range centers {
+				dx := float64(city.X) - center.x
+				dy := float64(city.Y) - center.y
+				if d := dx*dx + dy*dy; d < bestDistance {
+					nearest = c
+					bestDistance = d
+				}
+			}
+			if iteration == 0 || assignments[i] != nearest {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		var sums [2]center
+		var counts [2]int
+		for i, city := range cities {
+			sums[assignments[i]].x += float64(city.X)
+			sums[assignments[i]].y += float64(city.Y)
+			counts[assignments[i]]++
+		}
+		for c := range centers {
+			// An empty cluster keeps its old center.
+			if counts[c] > 0 {
+				centers[c] = center{sums[c].x / float64(counts[c]), sums[c].y / float64(counts[c])}
+			}
 		}
 	}
+	cityClusters := make([][]Tile, 2)
+	for i, city := range cities {
+		cityClusters[assignments[i]] = append(cityClusters[assignments[i]], city)
+	}
 	return cityClusters, nil
 }
 
-func generateRoutes(cityClusters [][]Tile) []Tile {
+func generateRoutes(cities []Tile, cityClusters [][]Tile, planner routePlanner) ([]Tile, []RouteConnection, error) {
+	if err := validateVictoryRoad(planner.opts.VictoryRoad, cities); err != nil {
+		return nil, nil, err
+	}
 	routeTiles := map[Tile]bool{}
+	connections := []RouteConnection{}
 	// Connect cities within each cluster to each other.
 	for _, cities := range cityClusters {
 		if len(cities) < 2 {
@@ -289,12 +475,14 @@ func generateRoutes(cityClusters [][]Tile) []Tile {
 			if nearestCity == nil {
 				continue
 			}
-			connectCities(*city, *nearestCity, routeTiles)
+			path := planner.connect(*city, *nearestCity, routeTiles)
+			connections = addRouteConnection(connections, *city, *nearestCity, path)
 			connectedCities[*city] = true
 			connectedCities[*nearestCity] = true
 			*city = *nearestCity
 		}
-		connectCities(*firstCity, lastCity, routeTiles)
+		path := planner.connect(*firstCity, lastCity, routeTiles)
+		connections = addRouteConnection(connections, *firstCity, lastCity, path)
 	}
 
 	// Connect the two clusters of cities together by
@@ -312,48 +500,81 @@ func generateRoutes(cityClusters [][]Tile) []Tile {
 			}
 		}
 	}
-	connectCities(cityA, cityB, routeTiles)
+	path := planner.connect(cityA, cityB, routeTiles)
+	connections = addRouteConnection(connections, cityA, cityB, path)
+
+	// The victory road is always included, even when its two cities
+	// weren't connected above.
+	if pair := planner.opts.VictoryRoad; pair != nil && findRouteConnection(connections, pair.A, pair.B) == -1 {
+		path := planner.connect(pair.A, pair.B, routeTiles)
+		connections = addRouteConnection(connections, pair.A, pair.B, path)
+	}
+	for i, c := range connections {
+		connections[i].VictoryRoad = planner.isVictoryRoad(c.CityA, c.CityB)
+	}
+	classifyRouteConnections(connections)
 
 	// Return a slice of tiles, rather than a map.
-	result := make([]Tile, len(routeTiles))
-	i := 0
-	for tile := range routeTiles {
-		result[i] = tile
-		i++
+	return getSortedTiles(routeTiles), connections, nil
+}
+
+// connectCities lays an L-shaped route between two cities, and returns
+// the route tiles in order, starting from cityA. If the randomly-chosen
+// L-shape leaves the allowed area, the other L-shape is tried instead.
+func connectCities(rng *rand.Rand, cityA Tile, cityB Tile, routeTiles map[Tile]bool, allowed func(Tile) bool) ([]Tile, bool) {
+	horizontalFirst := rng.Intn(2) == 0
+	path := getLShapedPath(cityA, cityB, horizontalFirst)
+	if !isPathAllowed(path, cityA, allowed) {
+		path = getLShapedPath(cityA, cityB, !horizontalFirst)
+		if !isPathAllowed(path, cityA, allowed) {
+			return nil, false
+		}
 	}
-	return result
+	for _, t := range path {
+		routeTiles[t] = true
+	}
+	return path, true
 }
 
-func connectCities(cityA Tile, cityB Tile, routeTiles map[Tile]bool) {
-	if rand.Intn(2) == 0 {
-		start := connectHorizontalRoute(cityA, cityB, routeTiles)
-		connectVerticalRoute(start, cityB, routeTiles)
+func getLShapedPath(cityA Tile, cityB Tile, horizontalFirst bool) []Tile {
+	path := []Tile{}
+	if horizontalFirst {
+		start := connectHorizontalRoute(cityA, cityB, &path)
+		connectVerticalRoute(start, cityB, &path)
 	} else {
-		start := connectVerticalRoute(cityA, cityB, routeTiles)
-		connectHorizontalRoute(start, cityB, routeTiles)
+		start := connectVerticalRoute(cityA, cityB, &path)
+		connectHorizontalRoute(start, cityB, &path)
 	}
+	return path
 }
 
-func connectHorizontalRoute(start Tile, end Tile, routeTiles map[Tile]bool) Tile {
+func isPathAllowed(path []Tile, city Tile, allowed func(Tile) bool) bool {
+	for _, t := range path {
+		if t != city && !allowed(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func connectHorizontalRoute(start Tile, end Tile, path *[]Tile) Tile {
 	inc := 1
 	if start.X > end.X {
 		inc = -1
 	}
 	for i := start.X; i != end.X; i += inc {
-		t := Tile{i, start.Y}
-		routeTiles[t] = true
+		*path = append(*path, Tile{i, start.Y})
 	}
 	return Tile{end.X, start.Y}
 }
 
-func connectVerticalRoute(start Tile, end Tile, routeTiles map[Tile]bool) Tile {
+func connectVerticalRoute(start Tile, end Tile, path *[]Tile) Tile {
 	inc := 1
 	if start.Y > end.Y {
 		inc = -1
 	}
 	for j := start.Y; j != end.Y; j += inc {
-		t := Tile{start.X, j}
-		routeTiles[t] = true
+		*path = append(*path, Tile{start.X, j})
 	}
 	return Tile{start.X, end.Y}
 }
@@ -0,0 +1,59 @@
+package porygion
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testRegionMap generates a small region map with named cities of every
+// kind, so that encoders have to round-trip all of its fields.
+func testRegionMap(t *testing.T) RegionMap {
+	t.Helper()
+	regionMap, err := GenerateRegionMap(7, 96, 64, 6)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	regionMap.CityNames = map[Tile]string{}
+	regionMap.CityKinds = map[Tile]CityKind{}
+	for i, city := range regionMap.Cities {
+		regionMap.CityNames[city] = string(rune('A' + i))
+		if kind := CityKind(i % int(CityLeague+1)); kind != CityTown {
+			regionMap.CityKinds[city] = kind
+		}
+	}
+	return regionMap
+}
+
+func TestGenerateRegionMapIsDeterministic(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	const want = "5b62a16337d5b99cc89bca7880478d473bafa9c056afb22bda6f96691167dff4"
+	if got := regionMap.Fingerprint(); got != want {
+		t.Errorf("Fingerprint() = %s, want %s", got, want)
+	}
+}
+
+func TestElevationWorkersDontChangeTheMap(t *testing.T) {
+	config := NewConfig(MapParams{Seed: 3, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	config.ElevationWorkers = 1
+	want, err := config.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	// Worker counts that don't divide the width evenly, and more workers
+	// than columns, must give the same map.
+	for _, workers := range []int{0, 2, 3, 7, 16, 1000} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			config.ElevationWorkers = workers
+			got, err := config.Generate()
+			if err != nil {
+				t.Fatalf("Generate: %s", err)
+			}
+			if got.Fingerprint() != want.Fingerprint() {
+				t.Errorf("Fingerprint() = %s, want %s", got.Fingerprint(), want.Fingerprint())
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package porygion
+
+// ElevationProfile returns the average elevation of each tile along the
+// connection's path, in order from CityA to CityB.
+func (r RegionMap) ElevationProfile(c RouteConnection) []float64 {
+	path := c.path()
+	profile := make([]float64, len(path))
+	for i, t := range path {
+		profile[i] = getTileElevation(r.Elevations, t)
+	}
+	return profile
+}
+
+// ElevationProfiles returns the elevation profile of every connection in
+// the region map, in the same order as the region map's connections.
+func (r RegionMap) ElevationProfiles() [][]float64 {
+	profiles := make([][]float64, len(r.Connections))
+	for i, c := range r.Connections {
+		profiles[i] = r.ElevationProfile(c)
+	}
+	return profiles
+}
+
+// getTileElevation returns the average elevation of the pixels in a tile.
+func getTileElevation(elevations [][]float64, t Tile) float64 {
+	total := 0.0
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			total += elevations[t.X*8+x][t.Y*8+y]
+		}
+	}
+	return total / 64
+}
@@ -0,0 +1,29 @@
+package porygion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestElevationProfile(t *testing.T) {
+	// Every tile's elevation is its column, and every pixel in a tile has
+	// the same elevation.
+	regionMap := RegionMap{PixelWidth: 40, PixelHeight: 40, Elevations: getNewElevationMap(40, 40)}
+	for x, column := range regionMap.Elevations {
+		for y := range column {
+			column[y] = float64(x / 8)
+		}
+	}
+	regionMap.Connections = []RouteConnection{{
+		CityA: Tile{1, 1},
+		CityB: Tile{3, 3},
+		Tiles: []Tile{{2, 1}, {3, 1}, {3, 2}},
+	}}
+	want := []float64{1, 2, 3, 3, 3}
+	if profile := regionMap.ElevationProfile(regionMap.Connections[0]); !reflect.DeepEqual(profile, want) {
+		t.Errorf("ElevationProfile() = %v, want %v", profile, want)
+	}
+	if profiles := regionMap.ElevationProfiles(); !reflect.DeepEqual(profiles, [][]float64{want}) {
+		t.Errorf("ElevationProfiles() = %v, want %v", profiles, [][]float64{want})
+	}
+}
@@ -0,0 +1,259 @@
+package porygion
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profiles are named generation presets, loaded from a TOML file where
+// each table is a profile:
+//
+//	[hoenn-like]
+//	width = 240
+//	height = 160
+//	cities = 12
+//	route_costs = true
+//
+//	[archipelago-small]
+//	extends = "hoenn-like"
+//	width = 160
+//	cities = 6
+//	theme = "hgss"
+//
+// A profile that extends another starts with all of the other profile's
+// settings, and overrides the ones it sets itself.
+type Profiles struct {
+	tables map[string]tomlTable
+	names  []string
+}
+
+// profileKeys are the settings that profiles may contain, and how each one
+// is applied to a config.
+var profileKeys = map[string]func(c *Config, v interface{}) error{
+	"seed": func(c *Config, v interface{}) error {
+		seed, err := getProfileInt(v)
+		c.Seed = seed
+		return err
+	},
+	"width":  profileIntSetting(func(c *Config) *int { return &c.PixelWidth }),
+	"height": profileIntSetting(func(c *Config) *int { return &c.PixelHeight }),
+	"cities": profileIntSetting(func(c *Config) *int { return &c.NumCities }),
+	"scale":  profileIntSetting(func(c *Config) *int { return &c.Render.Scale }),
+	"theme": func(c *Config, v interface{}) error {
+		theme, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+		c.Theme = theme
+		return nil
+	},
+	"follow_coasts":      profileBoolSetting(func(c *Config) *bool { return &c.Routes.FollowCoasts }),
+	"narrow_spur_routes": profileBoolSetting(func(c *Config) *bool { return &c.Render.NarrowSpurRoutes }),
+	"autotile_routes":    profileBoolSetting(func(c *Config) *bool { return &c.Render.AutotileRoutes }),
+	"legend":             profileBoolSetting(func(c *Config) *bool { return &c.Render.Legend }),
+	"night":              profileBoolSetting(func(c *Config) *bool { return &c.Render.Night }),
+	"ocean_depth":        profileBoolSetting(func(c *Config) *bool { return &c.Render.OceanDepth }),
+	"dither":             profileBoolSetting(func(c *Config) *bool { return &c.Render.Dither }),
+	"transparent_water":  profileBoolSetting(func(c *Config) *bool { return &c.Render.TransparentWater }),
+	"route_costs": profileOptionSetting(func(c *Config, on bool) {
+		c.Routes.Costs = nil
+		if on {
+			costs := DefaultRouteCosts()
+			c.Routes.Costs = &costs
+		}
+	}),
+	"hillshade": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.Hillshade = nil
+		if on {
+			hillshade := DefaultHillshadeOptions()
+			c.Render.Hillshade = &hillshade
+		}
+	}),
+	"contours": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.Contours = nil
+		if on {
+			contours := DefaultContourOptions()
+			c.Render.Contours = &contours
+		}
+	}),
+	"coastline": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.Coastline = nil
+		if on {
+			coastline := DefaultCoastlineOptions()
+			c.Render.Coastline = &coastline
+		}
+	}),
+	"route_casing": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.RouteCasing = nil
+		if on {
+			casing := DefaultRouteCasingOptions()
+			c.Render.RouteCasing = &casing
+		}
+	}),
+	"compass": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.Compass = nil
+		if on {
+			compass := DefaultCompassOptions()
+			c.Render.Compass = &compass
+		}
+	}),
+	"scale_bar": profileOptionSetting(func(c *Config, on bool) {
+		c.Render.ScaleBar = nil
+		if on {
+			scaleBar := DefaultScaleBarOptions()
+			c.Render.ScaleBar = &scaleBar
+		}
+	}),
+}
+
+// profileExtendsKey is the profile setting that names the profile it
+// extends.
+const profileExtendsKey = "extends"
+
+// LoadProfiles reads a TOML file of profiles. The settings of every
+// profile, and the profiles they extend, are checked when the file is
+// loaded, so that mistakes are reported right away. Profiles don't have to
+// be complete configs, since a base profile may only hold settings that
+// the profiles extending it share, so they are only validated as configs
+// by Config.
+func LoadProfiles(r io.Reader) (Profiles, error) {
+	tables, names, err := parseTOMLTables(r)
+	if err != nil {
+		return Profiles{}, fmt.Errorf("Failed to read profiles: %s", err)
+	}
+	profiles := Profiles{tables: tables, names: names}
+	for _, name := range names {
+		c := NewConfig(MapParams{})
+		if err := profiles.apply(&c, name, map[string]bool{}); err != nil {
+			return Profiles{}, err
+		}
+	}
+	return profiles, nil
+}
+
+// Names returns the names of the profiles, in the order they appear in
+// the file.
+func (p Profiles) Names() []string {
+	return append([]string(nil), p.names...)
+}
+
+// Config returns the generation config of the named profile, including
+// the settings it inherits from the profiles it extends.
+func (p Profiles) Config(name string) (Config, error) {
+	c := NewConfig(MapParams{})
+	if err := p.apply(&c, name, map[string]bool{}); err != nil {
+		return Config{}, err
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("Invalid profile '%s': %s", name, err)
+	}
+	return c, nil
+}
+
+// Apply applies the settings of the named profile, including the ones it
+// inherits, to a config. Settings that the profile doesn't set are left as
+// they are, so that the caller's defaults, like a random seed, are kept.
+// The config isn't validated, so that the caller can override settings
+// first.
+func (p Profiles) Apply(c *Config, name string) error {
+	return p.apply(c, name, map[string]bool{})
+}
+
+// apply applies the named profile's settings to the config, after the
+// settings of the profile it extends. seen holds the profiles that are
+// already being applied, to catch profiles that extend each other.
+func (p Profiles) apply(c *Config, name string, seen map[string]bool) error {
+	table, ok := p.tables[name]
+	if !ok {
+		return fmt.Errorf("Unknown profile '%s'", name)
+	}
+	if seen[name] {
+		return fmt.Errorf("Profile '%s' extends itself through a cycle of profiles", name)
+	}
+	seen[name] = true
+	if parent, ok := table[profileExtendsKey]; ok {
+		parentName, ok := parent.(string)
+		if !ok {
+			return fmt.Errorf("Invalid profile '%s': %s must be a string", name, profileExtendsKey)
+		}
+		if err := p.apply(c, parentName, seen); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == profileExtendsKey {
+			continue
+		}
+		setting, ok := profileKeys[key]
+		if !ok {
+			return fmt.Errorf("Invalid profile '%s': unknown setting '%s'. Valid settings are: %s", name, key, strings.Join(getProfileKeyNames(), ", "))
+		}
+		if err := setting(c, table[key]); err != nil {
+			return fmt.Errorf("Invalid profile '%s': %s: %s", name, key, err)
+		}
+	}
+	return nil
+}
+
+// getProfileKeyNames returns the names of the profile settings, in
+// alphabetical order.
+func getProfileKeyNames() []string {
+	names := []string{profileExtendsKey}
+	for name := range profileKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getProfileInt(v interface{}) (int64, error) {
+	i, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	return i, nil
+}
+
+// profileIntSetting returns a setting that stores an integer in a field
+// of the config.
+func profileIntSetting(field func(c *Config) *int) func(c *Config, v interface{}) error {
+	return func(c *Config, v interface{}) error {
+		i, err := getProfileInt(v)
+		if err != nil {
+			return err
+		}
+		if i < 0 || i > maxBinaryDimension {
+			return fmt.Errorf("%d is out of range", i)
+		}
+		*field(c) = int(i)
+		return nil
+	}
+}
+
+// profileBoolSetting returns a setting that stores a boolean in a field of
+// the config.
+func profileBoolSetting(field func(c *Config) *bool) func(c *Config, v interface{}) error {
+	return profileOptionSetting(func(c *Config, on bool) {
+		*field(c) = on
+	})
+}
+
+// profileOptionSetting returns a setting that turns an option on or off.
+func profileOptionSetting(set func(c *Config, on bool)) func(c *Config, v interface{}) error {
+	return func(c *Config, v interface{}) error {
+		on, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false")
+		}
+		set(c, on)
+		return nil
+	}
+}
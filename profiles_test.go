@@ -0,0 +1,113 @@
+package porygion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	input := `
+[base]
+width = 240
+height = 160
+cities = 12
+route_costs = true
+
+[child]
+extends = "base"
+width = 160
+theme = "hgss"
+route_costs = false
+hillshade = true
+`
+	profiles, err := LoadProfiles(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadProfiles: %s", err)
+	}
+	if names := profiles.Names(); !reflect.DeepEqual(names, []string{"base", "child"}) {
+		t.Errorf("Names() = %q", names)
+	}
+
+	base, err := profiles.Config("base")
+	if err != nil {
+		t.Fatalf("Config(base): %s", err)
+	}
+	want := NewConfig(MapParams{PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	costs := DefaultRouteCosts()
+	want.Routes.Costs = &costs
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("Config(base) = %+v, want %+v", base, want)
+	}
+
+	child, err := profiles.Config("child")
+	if err != nil {
+		t.Fatalf("Config(child): %s", err)
+	}
+	want = NewConfig(MapParams{PixelWidth: 160, PixelHeight: 160, NumCities: 12})
+	hillshade := DefaultHillshadeOptions()
+	want.Theme = "hgss"
+	want.Render.Hillshade = &hillshade
+	if !reflect.DeepEqual(child, want) {
+		t.Errorf("Config(child) = %+v, want %+v", child, want)
+	}
+
+	if _, err := profiles.Config("missing"); err == nil {
+		t.Errorf("Config(missing) succeeded, want an error")
+	}
+}
+
+func TestLoadProfilesErrors(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"unknown setting", "[a]\nwidth = 64\nheight = 64\nwdith = 64", "unknown setting 'wdith'"},
+		{"wrong type", "[a]\nwidth = \"wide\"", "width"},
+		{"unknown parent", "[a]\nextends = \"b\"", "Unknown profile 'b'"},
+		{"cycle", "[a]\nextends = \"b\"\n[b]\nextends = \"a\"", "cycle"},
+		{"syntax error", "[a]\nwidth", "Failed to read profiles: Line 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadProfiles(strings.NewReader(test.input))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("LoadProfiles error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestPartialProfiles(t *testing.T) {
+	input := `
+[dark]
+theme = "hgss"
+
+[small]
+extends = "dark"
+width = 4
+height = 64
+`
+	profiles, err := LoadProfiles(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadProfiles: %s", err)
+	}
+	if _, err := profiles.Config("dark"); err == nil || !strings.Contains(err.Error(), "Invalid map size 0x0") {
+		t.Errorf("Config(dark) error = %v, want an invalid size", err)
+	}
+	if _, err := profiles.Config("small"); err == nil || !strings.Contains(err.Error(), "Invalid map size 4x64") {
+		t.Errorf("Config(small) error = %v, want an invalid size", err)
+	}
+
+	c := NewConfig(MapParams{Seed: 42, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	if err := profiles.Apply(&c, "dark"); err != nil {
+		t.Fatalf("Apply(dark): %s", err)
+	}
+	want := NewConfig(MapParams{Seed: 42, PixelWidth: 240, PixelHeight: 160, NumCities: 12})
+	want.Theme = "hgss"
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Apply(dark) = %+v, want %+v", c, want)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate after Apply(dark): %s", err)
+	}
+}
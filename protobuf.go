@@ -0,0 +1,299 @@
+package porygion
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Protocol buffer wire types.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// MarshalProto encodes the region map in the protocol buffer format
+// described by regionmap.proto. Elevations are stored as 32-bit floats.
+func (r RegionMap) MarshalProto() ([]byte, error) {
+	e := protoEncoder{}
+	e.varint(1, uint64(r.Seed))
+	e.varint(2, uint64(r.PixelWidth))
+	e.varint(3, uint64(r.PixelHeight))
+	elevations := protoEncoder{}
+	for _, column := range r.Elevations {
+		for _, elevation := range column {
+			elevations.buf = appendFixed32(elevations.buf, math.Float32bits(float32(elevation)))
+		}
+	}
+	e.bytes(4, elevations.buf)
+	for _, city := range r.Cities {
+		c := protoEncoder{}
+		c.message(1, encodeProtoTile(city))
+		c.string(2, r.CityNames[city])
+		c.varint(3, uint64(r.CityKinds[city]))
+		e.message(5, c)
+	}
+	for _, route := range r.Routes {
+		e.message(6, encodeProtoTile(route))
+	}
+	for _, conn := range r.Connections {
+		c := protoEncoder{}
+		c.message(1, encodeProtoTile(conn.CityA))
+		c.message(2, encodeProtoTile(conn.CityB))
+		for _, t := range conn.Tiles {
+			c.message(3, encodeProtoTile(t))
+		}
+		c.varint(4, uint64(conn.Class))
+		if conn.VictoryRoad {
+			c.varint(5, 1)
+		}
+		e.message(7, c)
+	}
+	return e.buf, nil
+}
+
+// UnmarshalProto decodes a region map from the protocol buffer format
+// described by regionmap.proto, replacing the contents of the region map.
+func (r *RegionMap) UnmarshalProto(data []byte) error {
+	var regionMap RegionMap
+	var elevations []float64
+	err := decodeProtoMessage(data, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			regionMap.Seed = int64(v.varint)
+		case 2:
+			regionMap.PixelWidth = int(int32(v.varint))
+		case 3:
+			regionMap.PixelHeight = int(int32(v.varint))
+		case 4:
+			if v.wireType == protoFixed32 {
+				elevations = append(elevations, float64(math.Float32frombits(uint32(v.varint))))
+				return nil
+			}
+			if len(v.bytes)%4 != 0 {
+				return fmt.Errorf("Invalid packed elevations length %d", len(v.bytes))
+			}
+			for i := 0; i < len(v.bytes); i += 4 {
+				elevations = append(elevations, float64(math.Float32frombits(binary.LittleEndian.Uint32(v.bytes[i:]))))
+			}
+		case 5:
+			city, name, kind, err := decodeProtoCity(v.bytes)
+			if err != nil {
+				return err
+			}
+			regionMap.Cities = append(regionMap.Cities, city)
+			if name != "" {
+				if regionMap.CityNames == nil {
+					regionMap.CityNames = map[Tile]string{}
+				}
+				regionMap.CityNames[city] = name
+			}
+			if kind != CityTown {
+				if regionMap.CityKinds == nil {
+					regionMap.CityKinds = map[Tile]CityKind{}
+				}
+				regionMap.CityKinds[city] = kind
+			}
+		case 6:
+			t, err := decodeProtoTile(v.bytes)
+			if err != nil {
+				return err
+			}
+			regionMap.Routes = append(regionMap.Routes, t)
+		case 7:
+			c, err := decodeProtoRouteConnection(v.bytes)
+			if err != nil {
+				return err
+			}
+			regionMap.Connections = append(regionMap.Connections, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to decode region map: %s", err)
+	}
+	width, height := regionMap.PixelWidth, regionMap.PixelHeight
+	if width <= 0 || height <= 0 || len(elevations) != width*height {
+		return fmt.Errorf("Failed to decode region map: %d elevations don't fit a %dx%d map", len(elevations), width, height)
+	}
+	regionMap.Elevations = getNewElevationMap(width, height)
+	for i, column := range regionMap.Elevations {
+		copy(column, elevations[i*height:(i+1)*height])
+	}
+	*r = regionMap
+	return nil
+}
+
+func encodeProtoTile(t Tile) protoEncoder {
+	e := protoEncoder{}
+	e.varint(1, uint64(int64(t.X)))
+	e.varint(2, uint64(int64(t.Y)))
+	return e
+}
+
+func decodeProtoTile(data []byte) (Tile, error) {
+	var t Tile
+	err := decodeProtoMessage(data, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			t.X = int(int32(v.varint))
+		case 2:
+			t.Y = int(int32(v.varint))
+		}
+		return nil
+	})
+	return t, err
+}
+
+func decodeProtoCity(data []byte) (Tile, string, CityKind, error) {
+	var city Tile
+	var name string
+	var kind CityKind
+	err := decodeProtoMessage(data, func(field int, v protoValue) error {
+		var err error
+		switch field {
+		case 1:
+			city, err = decodeProtoTile(v.bytes)
+		case 2:
+			name = string(v.bytes)
+		case 3:
+			if v.varint > uint64(CityLeague) {
+				return fmt.Errorf("Invalid city kind %d", v.varint)
+			}
+			kind = CityKind(v.varint)
+		}
+		return err
+	})
+	return city, name, kind, err
+}
+
+func decodeProtoRouteConnection(data []byte) (RouteConnection, error) {
+	var c RouteConnection
+	err := decodeProtoMessage(data, func(field int, v protoValue) error {
+		var err error
+		switch field {
+		case 1:
+			c.CityA, err = decodeProtoTile(v.bytes)
+		case 2:
+			c.CityB, err = decodeProtoTile(v.bytes)
+		case 3:
+			var t Tile
+			t, err = decodeProtoTile(v.bytes)
+			c.Tiles = append(c.Tiles, t)
+		case 4:
+			if v.varint > uint64(RouteSpur) {
+				return fmt.Errorf("Invalid route class %d", v.varint)
+			}
+			c.Class = RouteClass(v.varint)
+		case 5:
+			c.VictoryRoad = v.varint != 0
+		}
+		return err
+	})
+	return c, err
+}
+
+// protoEncoder appends protocol buffer fields to a buffer. Fields with
+// default values are omitted, as in proto3.
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) tag(field, wireType int) {
+	e.buf = appendUvarint(e.buf, uint64(field<<3|wireType))
+}
+
+func (e *protoEncoder) varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, protoVarint)
+	e.buf = appendUvarint(e.buf, v)
+}
+
+func (e *protoEncoder) bytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.tag(field, protoBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *protoEncoder) string(field int, s string) {
+	e.bytes(field, []byte(s))
+}
+
+// message appends an embedded message. Unlike other fields, empty messages
+// are still written, since their presence is meaningful.
+func (e *protoEncoder) message(field int, m protoEncoder) {
+	e.tag(field, protoBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(m.buf)))
+	e.buf = append(e.buf, m.buf...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func appendFixed32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// protoValue is a single decoded field value. Varint and fixed-size values
+// are stored in varint, and length-delimited values in bytes.
+type protoValue struct {
+	wireType int
+	varint   uint64
+	bytes    []byte
+}
+
+// decodeProtoMessage calls fn with each field of a protocol buffer message,
+// in the order they appear. Unknown fields are passed to fn too, and can be
+// ignored.
+func decodeProtoMessage(data []byte, fn func(field int, v protoValue) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("Invalid field key")
+		}
+		data = data[n:]
+		v := protoValue{wireType: int(key & 7)}
+		switch v.wireType {
+		case protoVarint:
+			v.varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("Invalid varint")
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("Truncated fixed64 field")
+			}
+			v.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("Truncated fixed32 field")
+			}
+			v.varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("Invalid length-delimited field")
+			}
+			v.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return fmt.Errorf("Unsupported wire type %d", v.wireType)
+		}
+		if err := fn(int(key>>3), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package porygion
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegionMapProtoRoundTrip(t *testing.T) {
+	regionMap := testRegionMap(t)
+	data, err := regionMap.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto: %s", err)
+	}
+	var decoded RegionMap
+	if err := decoded.UnmarshalProto(data); err != nil {
+		t.Fatalf("UnmarshalProto: %s", err)
+	}
+	// The elevations are stored as 32-bit floats, so everything else must
+	// match exactly, and the elevations must match as 32-bit floats.
+	for i, column := range regionMap.Elevations {
+		for j, elevation := range column {
+			if decoded.Elevations[i][j] != float64(float32(elevation)) {
+				t.Fatalf("Elevation (%d, %d) is %g, want %g", i, j, decoded.Elevations[i][j], elevation)
+			}
+		}
+	}
+	decoded.Elevations = regionMap.Elevations
+	if !reflect.DeepEqual(decoded, regionMap) {
+		t.Errorf("decoded region map differs from the original")
+	}
+}
+
+func TestRegionMapProtoRoundTripIsStable(t *testing.T) {
+	// The first round trip rounds the elevations to 32-bit floats, but
+	// after that, round trips must not change the map at all.
+	roundTrip := func(r RegionMap) RegionMap {
+		data, err := r.MarshalProto()
+		if err != nil {
+			t.Fatalf("MarshalProto: %s", err)
+		}
+		var decoded RegionMap
+		if err := decoded.UnmarshalProto(data); err != nil {
+			t.Fatalf("UnmarshalProto: %s", err)
+		}
+		return decoded
+	}
+	once := roundTrip(testRegionMap(t))
+	if twice := roundTrip(once); twice.Fingerprint() != once.Fingerprint() {
+		t.Errorf("Fingerprint() changed from %s to %s", once.Fingerprint(), twice.Fingerprint())
+	}
+}
+
+func TestRegionMapUnmarshalProtoErrors(t *testing.T) {
+	tile := func(x, y int) protoEncoder {
+		e := protoEncoder{}
+		e.varint(1, uint64(x))
+		e.varint(2, uint64(y))
+		return e
+	}
+	header := func() protoEncoder {
+		e := protoEncoder{}
+		e.varint(2, 1)
+		e.varint(3, 1)
+		elevations := protoEncoder{}
+		elevations.buf = appendFixed32(elevations.buf, math.Float32bits(0))
+		e.bytes(4, elevations.buf)
+		return e
+	}
+	city := func(kind uint64) []byte {
+		c := protoEncoder{}
+		c.message(1, tile(0, 0))
+		c.varint(3, kind)
+		e := header()
+		e.message(5, c)
+		return e.buf
+	}
+	connection := func(class uint64) []byte {
+		c := protoEncoder{}
+		c.message(1, tile(0, 0))
+		c.message(2, tile(0, 0))
+		c.varint(4, class)
+		e := header()
+		e.message(7, c)
+		return e.buf
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"valid city kind", city(uint64(CityLeague)), ""},
+		{"invalid city kind", city(uint64(CityLeague) + 1), "Invalid city kind 5"},
+		{"huge city kind", city(math.MaxUint64), "Invalid city kind"},
+		{"valid route class", connection(uint64(RouteSpur)), ""},
+		{"invalid route class", connection(uint64(RouteSpur) + 1), "Invalid route class 2"},
+		{"truncated", header().buf[:5], "Invalid length-delimited field"},
+		{"elevations don't fit", header().buf[:4], "0 elevations don't fit a 1x1 map"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var regionMap RegionMap
+			err := regionMap.UnmarshalProto(test.data)
+			switch {
+			case test.want == "":
+				if err != nil {
+					t.Errorf("UnmarshalProto: %s", err)
+				}
+			case err == nil || !strings.Contains(err.Error(), test.want):
+				t.Errorf("UnmarshalProto error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
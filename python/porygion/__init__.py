@@ -0,0 +1,171 @@
+"""Python bindings for the porygion region map generator.
+
+The bindings call into the libporygion C shared library, so build it first:
+
+    go build -buildmode=c-shared -o python/porygion/libporygion.so ./cmd/libporygion
+
+The library is loaded from the path in the PORYGION_LIBRARY environment
+variable, from this package's directory, or from the system library path,
+in that order.
+
+    import porygion
+    region_map = porygion.generate(seed=42, cities=10)
+    with open("map.png", "wb") as f:
+        f.write(region_map.render_png(theme="frlg", scale=2))
+"""
+
+import ctypes
+import ctypes.util
+import json
+import os
+import random
+import sys
+
+__all__ = ["PorygionError", "RegionMap", "generate", "load", "export_formats"]
+
+class PorygionError(Exception):
+    """An error reported by the generator."""
+
+
+def _library_names():
+    if sys.platform == "darwin":
+        return ["libporygion.dylib", "libporygion.so"]
+    if sys.platform == "win32":
+        return ["porygion.dll", "libporygion.dll"]
+    return ["libporygion.so"]
+
+
+def _load_library():
+    path = os.environ.get("PORYGION_LIBRARY")
+    if not path:
+        here = os.path.dirname(os.path.abspath(__file__))
+        for name in _library_names():
+            if os.path.exists(os.path.join(here, name)):
+                path = os.path.join(here, name)
+                break
+    if not path:
+        path = ctypes.util.find_library("porygion")
+    if not path:
+        raise PorygionError(
+            "Couldn't find the libporygion shared library. Build it with "
+            "'go build -buildmode=c-shared ./cmd/libporygion', and set PORYGION_LIBRARY to its path."
+        )
+    lib = ctypes.CDLL(path)
+    error = ctypes.POINTER(ctypes.c_void_p)
+    lib.porygion_generate.argtypes = [ctypes.c_longlong, ctypes.c_int, ctypes.c_int, ctypes.c_int, error]
+    lib.porygion_generate.restype = ctypes.c_void_p
+    lib.porygion_render_png.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.c_int, ctypes.POINTER(ctypes.c_size_t), error]
+    lib.porygion_render_png.restype = ctypes.c_void_p
+    lib.porygion_export.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.POINTER(ctypes.c_size_t), error]
+    lib.porygion_export.restype = ctypes.c_void_p
+    lib.porygion_export_formats.argtypes = []
+    lib.porygion_export_formats.restype = ctypes.c_void_p
+    lib.porygion_free.argtypes = [ctypes.c_void_p]
+    lib.porygion_free.restype = None
+    return lib
+
+
+_lib = None
+
+
+def _get_library():
+    global _lib
+    if _lib is None:
+        _lib = _load_library()
+    return _lib
+
+
+def _call(fn, *args, length=None):
+    """Calls a library function that returns memory to free, and raises its
+    error, if it fails. Returns the bytes of the result, which is a C string
+    unless a length is given."""
+    lib = _get_library()
+    error = ctypes.c_void_p()
+    extra = (ctypes.byref(length),) if length is not None else ()
+    result = fn(*args, *extra, ctypes.byref(error))
+    if not result:
+        message = ctypes.string_at(error.value).decode("utf-8") if error.value else "Unknown error"
+        if error.value:
+            lib.porygion_free(error.value)
+        raise PorygionError(message)
+    try:
+        if length is not None:
+            return ctypes.string_at(result, length.value)
+        return ctypes.string_at(result)
+    finally:
+        lib.porygion_free(result)
+
+
+class RegionMap:
+    """A generated region map, stored as the JSON that the Go package uses."""
+
+    def __init__(self, map_json):
+        if isinstance(map_json, bytes):
+            map_json = map_json.decode("utf-8")
+        self.json = map_json
+        self._data = None
+
+    @property
+    def data(self):
+        """The region map's JSON, decoded into a dict."""
+        if self._data is None:
+            self._data = json.loads(self.json)
+        return self._data
+
+    @property
+    def seed(self):
+        return self.data["seed"]
+
+    @property
+    def width(self):
+        return self.data["pixel_width"]
+
+    @property
+    def height(self):
+        return self.data["pixel_height"]
+
+    @property
+    def cities(self):
+        """The (x, y) tiles of the cities."""
+        return [(city["x"], city["y"]) for city in self.data["cities"]]
+
+    def render_png(self, theme=None, scale=1):
+        """Renders the region map, and returns the PNG bytes."""
+        lib = _get_library()
+        theme = theme.encode("utf-8") if theme else None
+        return _call(lib.porygion_render_png, self.json.encode("utf-8"), theme, scale, length=ctypes.c_size_t())
+
+    def export(self, format):
+        """Converts the region map into one of export_formats(), and returns
+        the exported bytes."""
+        lib = _get_library()
+        return _call(lib.porygion_export, self.json.encode("utf-8"), format.encode("utf-8"), length=ctypes.c_size_t())
+
+    def save(self, path):
+        """Saves the region map as JSON, which load() can read back."""
+        with open(path, "w") as f:
+            f.write(self.json)
+
+
+def generate(seed=None, width=240, height=160, cities=12):
+    """Generates a region map. The seed is random if it isn't given."""
+    if seed is None:
+        seed = random.randrange(-(1 << 63), 1 << 63)
+    lib = _get_library()
+    return RegionMap(_call(lib.porygion_generate, seed, width, height, cities))
+
+
+def load(path):
+    """Loads a region map that was saved as JSON."""
+    with open(path) as f:
+        return RegionMap(f.read())
+
+
+def export_formats():
+    """Returns the names of the formats that RegionMap.export supports."""
+    lib = _get_library()
+    result = lib.porygion_export_formats()
+    try:
+        return ctypes.string_at(result).decode("utf-8").split(",")
+    finally:
+        lib.porygion_free(result)
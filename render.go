@@ -9,6 +9,8 @@ import (
 var (
 	colorWater0      = color.RGBA{152, 208, 248, 255}
 	colorWater1      = color.RGBA{160, 176, 248, 255}
+	colorDeepWater0  = color.RGBA{104, 160, 240, 255}
+	colorDeepWater1  = color.RGBA{112, 128, 240, 255}
 	colorLand0       = color.RGBA{0, 112, 0, 255}
 	colorLand1       = color.RGBA{56, 168, 8, 255}
 	colorLand2       = color.RGBA{96, 208, 0, 255}
@@ -21,69 +23,269 @@ var (
 	colorRouteLand2  = color.RGBA{240, 208, 80, 255}
 	colorRouteLand3  = color.RGBA{232, 224, 112, 255}
 	colorRouteLand4  = color.RGBA{232, 224, 168, 255}
+	colorCity        = color.RGBA{255, 0, 0, 255}
 )
 
-var routeConversionColors = map[color.Color]color.RGBA{
-	colorWater0: colorRouteWater0,
-	colorWater1: colorRouteWater1,
-	colorLand0:  colorRouteLand0,
-	colorLand1:  colorRouteLand1,
-	colorLand2:  colorRouteLand2,
-	colorLand3:  colorRouteLand3,
-	colorLand4:  colorRouteLand4,
+// RenderOptions controls optional features when rendering a region map.
+type RenderOptions struct {
+	// NarrowSpurRoutes draws spur routes as thin paths, so that the
+	// trunk routes stand out.
+	NarrowSpurRoutes bool `json:"narrow_spur_routes"`
+	// Palette is the set of colors to render with. When nil, the
+	// DefaultPalette is used.
+	Palette *Palette `json:"palette"`
+	// Scale is an integer factor to enlarge the rendered image by, using
+	// nearest-neighbor scaling. Values less than 2 leave the image at its
+	// original size.
+	Scale int `json:"scale"`
+	// Hillshade, when set, shades the land based on the slope of the
+	// terrain, to give it depth.
+	Hillshade *HillshadeOptions `json:"hillshade"`
+	// Contours, when set, draws elevation contour lines over the terrain.
+	Contours *ContourOptions `json:"contours"`
+	// TileGrid, when set, draws the outline of every 8x8 tile over the map.
+	TileGrid *TileGridOptions `json:"tile_grid"`
+	// Labels, when set, draws the names from the region map's CityNames
+	// next to their cities.
+	Labels *LabelOptions `json:"labels"`
+	// Legend adds a strip beneath the map, which shows what each of the
+	// palette's colors represents.
+	Legend bool `json:"legend"`
+	// TransparentWater renders water as fully transparent pixels, so that
+	// the land can be composited over a custom background.
+	TransparentWater bool `json:"transparent_water"`
+	// AutotileRoutes draws every route as a thin path, shaped to connect
+	// with its neighboring routes and cities, rather than as solid tiles.
+	AutotileRoutes bool `json:"autotile_routes"`
+	// Night renders the map with the night variant of the palette.
+	Night bool `json:"night"`
+	// OceanDepth renders deep ocean, far from land or far below sea level,
+	// in darker shades than the shallow water near the coasts.
+	OceanDepth bool `json:"ocean_depth"`
+	// Coastline, when set, outlines the land wherever it meets water.
+	Coastline *CoastlineOptions `json:"coastline"`
+	// Highlight, when set, draws a selection cursor around some tiles.
+	Highlight *HighlightOptions `json:"highlight"`
+	// RouteCasing, when set, draws a darker border along the edges of the
+	// routes, so that they stand out against similar land colors.
+	RouteCasing *RouteCasingOptions `json:"route_casing"`
+	// Compass, when set, draws a compass rose in a corner of the map.
+	Compass *CompassOptions `json:"compass"`
+	// ScaleBar, when set, draws a bar in a corner of the map that shows
+	// the size of a number of tiles.
+	ScaleBar *ScaleBarOptions `json:"scale_bar"`
+	// LandColors, when set, replaces the palette's land shades with custom
+	// elevation color stops, optionally blended into a smooth gradient.
+	LandColors *LandColorOptions `json:"land_colors"`
+	// Dither blends neighboring land shades with an ordered dither
+	// pattern, instead of drawing hard bands, so that elevation changes
+	// stay visible with palettes that have very few colors.
+	Dither bool `json:"dither"`
+	// FogOfWar, when set, darkens or grays out the tiles that haven't
+	// been visited, to draw a progressively revealed map.
+	FogOfWar *FogOfWarOptions `json:"-"`
+
+	// waterPhase offsets which rows use each water shade, which is used
+	// to animate the water.
+	waterPhase int
+}
+
+func renderRegionMapImage(regionMap RegionMap, opts RenderOptions) image.Image {
+	palette := getRenderPalette(opts)
+	img := newRegionMapImage(regionMap)
+	drawRegionMap(img, regionMap, palette, opts)
+	if opts.Scale > 1 {
+		img = scaleImage(img, opts.Scale)
+	}
+	// Labels are drawn after scaling, so that the text stays crisp.
+	if opts.Labels != nil {
+		drawCityLabels(img, regionMap, *opts.Labels, opts.Scale)
+	}
+	if opts.Compass != nil || opts.ScaleBar != nil {
+		drawDecorations(img, opts, opts.Scale)
+	}
+	if opts.Legend {
+		img = appendLegend(img, palette)
+	}
+	return img
+}
+
+// drawRegionMap draws every layer of the region map onto an image, at its
+// original size. The image's bounds must start at the origin.
+func drawRegionMap(img *image.RGBA, regionMap RegionMap, palette Palette, opts RenderOptions) {
+	drawTerrain(img, regionMap, palette, opts)
+	routes := getRouteMask(regionMap, opts)
+	cities := getCityMask(regionMap)
+	compositeLayers(img, regionMap.Elevations, routes, cities, palette, opts)
+	if opts.FogOfWar != nil {
+		drawFogOfWar(img, *opts.FogOfWar)
+	}
+	if opts.TileGrid != nil {
+		drawTileGrid(img, *opts.TileGrid)
+	}
+	if opts.Highlight != nil {
+		drawHighlights(img, *opts.Highlight)
+	}
+}
+
+// renderLayerImage renders a single layer of the region map onto a fully
+// transparent image.
+func renderLayerImage(regionMap RegionMap, opts RenderOptions, drawLayer func(img *image.RGBA, palette Palette)) image.Image {
+	img := newRegionMapImage(regionMap)
+	drawLayer(img, getRenderPalette(opts))
+	if opts.Scale > 1 {
+		img = scaleImage(img, opts.Scale)
+	}
+	return img
+}
+
+func getRenderPalette(opts RenderOptions) Palette {
+	palette := DefaultPalette()
+	if opts.Palette != nil {
+		palette = *opts.Palette
+	}
+	if opts.Night {
+		palette = NightPalette(palette)
+	}
+	return palette
+}
+
+func newRegionMapImage(regionMap RegionMap) *image.RGBA {
+	width := len(regionMap.Elevations)
+	height := len(regionMap.Elevations[0])
+	return image.NewRGBA(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+}
+
+func drawTerrain(img *image.RGBA, regionMap RegionMap, palette Palette, opts RenderOptions) {
+	elevations := regionMap.Elevations
+	var deep [][]bool
+	if opts.OceanDepth {
+		deep = classifyDeepWater(elevations)
+	}
+	var landStops elevationStops
+	gradient := false
+	if opts.LandColors != nil && len(opts.LandColors.Stops) > 0 {
+		landStops = sortElevationStops(opts.LandColors.Stops)
+		gradient = opts.LandColors.Gradient
+	} else if opts.Dither {
+		landStops = getDitherStops(palette)
+	}
+	for i := range elevations {
+		for j := range elevations[i] {
+			band := getTerrainBand(elevations[i][j], j+opts.waterPhase)
+			band.deep = deep != nil && deep[i][j]
+			if band.water && opts.TransparentWater {
+				img.SetRGBA(i, j, color.RGBA{})
+				continue
+			}
+			if !band.water && landStops != nil {
+				if opts.Dither {
+					img.SetRGBA(i, j, landStops.dither(elevations[i][j], i, j))
+				} else {
+					img.SetRGBA(i, j, landStops.color(elevations[i][j], gradient))
+				}
+				continue
+			}
+			img.SetRGBA(i, j, palette.terrainColor(band))
+		}
+	}
+	if opts.Hillshade != nil {
+		applyHillshade(img, elevations, *opts.Hillshade)
+	}
+	if opts.Contours != nil {
+		drawContours(img, elevations, *opts.Contours)
+	}
+	if opts.Coastline != nil {
+		drawCoastline(img, elevations, *opts.Coastline)
+	}
+}
+
+// drawRoutes composites only the route layer onto the image.
+func drawRoutes(img *image.RGBA, regionMap RegionMap, palette Palette, opts RenderOptions) {
+	compositeLayers(img, regionMap.Elevations, getRouteMask(regionMap, opts), nil, palette, opts)
+}
+
+// drawCities composites only the city layer onto the image.
+func drawCities(img *image.RGBA, regionMap RegionMap, palette Palette, opts RenderOptions) {
+	compositeLayers(img, regionMap.Elevations, nil, getCityMask(regionMap), palette, opts)
 }
 
-func renderRegionMapImage(elevations [][]float64, cities []Tile, routes []Tile) image.Image {
-	width := len(elevations)
-	height := len(elevations[0])
-	img := image.NewRGBA(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
-	for i := 0; i < width; i++ {
-		for j := 0; j < height; j++ {
-			c := getColorForElevation(elevations[i][j], j)
-			img.SetRGBA(i, j, c)
+// scaleImage enlarges an image by an integer factor, using nearest-neighbor
+// scaling.
+func scaleImage(src *image.RGBA, scale int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for x := 0; x < bounds.Dx(); x++ {
+		for y := 0; y < bounds.Dy(); y++ {
+			c := src.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+			for i := 0; i < scale; i++ {
+				for j := 0; j < scale; j++ {
+					dst.SetRGBA(x*scale+i, y*scale+j, c)
+				}
+			}
 		}
 	}
-	for _, route := range routes {
-		for i := 0; i < 8; i++ {
-			for j := 0; j < 8; j++ {
-				x := route.X*8 + i
-				y := route.Y*8 + j
-				c := routeConversionColors[img.At(x, y)]
-				img.SetRGBA(x, y, c)
+	return dst
+}
+
+// getSpurOnlyRouteTiles returns the route tiles that are used by spur
+// routes, but not by any trunk route.
+func getSpurOnlyRouteTiles(connections []RouteConnection) map[Tile]bool {
+	trunkTiles := map[Tile]bool{}
+	for _, c := range connections {
+		if c.Class == RouteTrunk {
+			for _, t := range c.Tiles {
+				trunkTiles[t] = true
 			}
 		}
 	}
-	for _, city := range cities {
-		for i := 0; i < 8; i++ {
-			for j := 0; j < 8; j++ {
-				x := city.X*8 + i
-				y := city.Y*8 + j
-				img.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+	spurTiles := map[Tile]bool{}
+	for _, c := range connections {
+		if c.Class == RouteSpur {
+			for _, t := range c.Tiles {
+				if !trunkTiles[t] {
+					spurTiles[t] = true
+				}
 			}
 		}
 	}
-	return img
+	return spurTiles
+}
+
+// Ocean depth classification thresholds.
+const (
+	// oceanShelfDistance is the distance from land, in pixels, beyond which
+	// water is deep.
+	oceanShelfDistance = 16
+	// deepOceanElevation is the elevation below which water is deep, even
+	// when it is close to land.
+	deepOceanElevation = -0.35
+)
+
+// classifyDeepWater reports, for every pixel, whether the water there is
+// deep, rather than shallow. Land is never deep.
+func classifyDeepWater(elevations [][]float64) [][]bool {
+	distances := getDistancesToLand(elevations)
+	deep := make([][]bool, len(elevations))
+	for i := range elevations {
+		deep[i] = make([]bool, len(elevations[i]))
+		for j, e := range elevations[i] {
+			deep[i][j] = e <= 0 && (distances[i][j] > oceanShelfDistance || e < deepOceanElevation)
+		}
+	}
+	return deep
 }
 
-func getColorForElevation(elevation float64, y int) color.RGBA {
+func getTerrainBand(elevation float64, y int) terrainBand {
 	if elevation > 0 {
-		switch {
-		case elevation > 1.10:
-			return colorLand4
-		case elevation > 0.85:
-			return colorLand3
-		case elevation > 0.60:
-			return colorLand2
-		case elevation > 0.35:
-			return colorLand1
-		default:
-			return colorLand0
+		for i := len(elevationBandLevels) - 1; i > 0; i-- {
+			if elevation > elevationBandLevels[i] {
+				return terrainBand{index: i}
+			}
 		}
+		return terrainBand{index: 0}
 	}
 
 	// The water alternates blue hues each row.
-	if y%2 == 0 {
-		return colorWater0
-	}
-	return colorWater1
+	return terrainBand{water: true, index: y % 2}
 }
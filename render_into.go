@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// RenderRegionMapInto renders a full region map into the top-left corner of
+// a caller-provided image, so that image buffers can be reused across many
+// renders. When dst is an *image.RGBA whose bounds start at the origin, and
+// the options neither scale the map nor add a legend, the map, along with
+// its labels, compass, and scale bar, is drawn directly into dst without
+// allocating a new image.
+func RenderRegionMapInto(dst draw.Image, regionMap RegionMap, opts RenderOptions) error {
+	size := getRenderedSize(regionMap, opts)
+	bounds := dst.Bounds()
+	if bounds.Dx() < size.X || bounds.Dy() < size.Y {
+		return fmt.Errorf("Destination image is %dx%d, but the rendered region map is %dx%d", bounds.Dx(), bounds.Dy(), size.X, size.Y)
+	}
+
+	if rgba, ok := dst.(*image.RGBA); ok && bounds.Min == (image.Point{}) && opts.Scale <= 1 && !opts.Legend {
+		img := rgba.SubImage(image.Rect(0, 0, size.X, size.Y)).(*image.RGBA)
+		drawRegionMap(img, regionMap, getRenderPalette(opts), opts)
+		if opts.Labels != nil {
+			drawCityLabels(img, regionMap, *opts.Labels, opts.Scale)
+		}
+		if opts.Compass != nil || opts.ScaleBar != nil {
+			drawDecorations(img, opts, opts.Scale)
+		}
+		return nil
+	}
+
+	img := renderRegionMapImage(regionMap, opts)
+	draw.Draw(dst, img.Bounds().Add(bounds.Min), img, image.Point{}, draw.Src)
+	return nil
+}
+
+// getRenderedSize returns the size of the image that renderRegionMapImage
+// produces with the given options.
+func getRenderedSize(regionMap RegionMap, opts RenderOptions) image.Point {
+	size := image.Point{len(regionMap.Elevations), len(regionMap.Elevations[0])}
+	if opts.Scale > 1 {
+		size = size.Mul(opts.Scale)
+	}
+	if opts.Legend {
+		size.Y += renderLegend(getRenderPalette(opts), size.X).Bounds().Dy()
+	}
+	return size
+}
@@ -0,0 +1,50 @@
+package porygion
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"testing"
+)
+
+func TestRenderRegionMapIntoMatchesRenderRegionMap(t *testing.T) {
+	regionMap := testRegionMap(t)
+	compass := DefaultCompassOptions()
+	scaleBar := DefaultScaleBarOptions()
+	labels := DefaultLabelOptions()
+	hillshade := DefaultHillshadeOptions()
+	tests := []struct {
+		name string
+		opts RenderOptions
+	}{
+		{"default", RenderOptions{}},
+		{"decorations", RenderOptions{Compass: &compass, ScaleBar: &scaleBar}},
+		{"labels", RenderOptions{Labels: &labels, Hillshade: &hillshade}},
+		{"scaled", RenderOptions{Scale: 2, Compass: &compass}},
+		{"legend", RenderOptions{Legend: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := RenderRegionMap(regionMap, test.opts).(*image.RGBA)
+			// The destination is larger than the map, and starts away from
+			// the origin for one of the two renders, so that both the
+			// direct and the copying paths are used.
+			for _, min := range []image.Point{{}, {3, 5}} {
+				dst := image.NewRGBA(image.Rectangle{min, min.Add(want.Bounds().Size()).Add(image.Pt(4, 4))})
+				if err := RenderRegionMapInto(dst, regionMap, test.opts); err != nil {
+					t.Fatalf("RenderRegionMapInto: %s", err)
+				}
+				got := image.NewRGBA(want.Bounds())
+				draw.Draw(got, got.Bounds(), dst, min, draw.Src)
+				if !bytes.Equal(got.Pix, want.Pix) {
+					t.Errorf("RenderRegionMapInto at %v differs from RenderRegionMap", min)
+				}
+			}
+		})
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	if err := RenderRegionMapInto(small, regionMap, RenderOptions{}); err == nil {
+		t.Errorf("RenderRegionMapInto a small image succeeded, want an error")
+	}
+}
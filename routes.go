@@ -0,0 +1,254 @@
+package porygion
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// RouteClass describes the role a route plays in the region's road network.
+type RouteClass int
+
+// Route classes.
+const (
+	// RouteTrunk is a primary artery on the main loop through the region.
+	RouteTrunk RouteClass = iota
+	// RouteSpur is a secondary side path that branches off the main loop.
+	RouteSpur
+)
+
+func (c RouteClass) String() string {
+	switch c {
+	case RouteTrunk:
+		return "trunk"
+	case RouteSpur:
+		return "spur"
+	}
+	return "unknown"
+}
+
+// RouteConnection is a single route connecting two cities.
+type RouteConnection struct {
+	CityA, CityB Tile
+	// Tiles are the route tiles between the two cities, excluding the
+	// cities themselves. They are ordered from CityA to CityB.
+	Tiles []Tile
+	Class RouteClass
+	// VictoryRoad is true when the connection was planned as a long,
+	// mountainous final route.
+	VictoryRoad bool
+}
+
+// CityPair identifies the connection between two cities. The order of the
+// cities does not matter.
+type CityPair struct {
+	A Tile `json:"a"`
+	B Tile `json:"b"`
+}
+
+func (p CityPair) matches(cityA, cityB Tile) bool {
+	return (p.A == cityA && p.B == cityB) || (p.A == cityB && p.B == cityA)
+}
+
+func addRouteConnection(connections []RouteConnection, cityA, cityB Tile, path []Tile) []RouteConnection {
+	if cityA == cityB {
+		return connections
+	}
+	// The same pair of cities may be connected more than once while
+	// building the network, but only the first connection is recorded.
+	if findRouteConnection(connections, cityA, cityB) != -1 {
+		return connections
+	}
+	if len(path) > 0 && path[0] == cityA {
+		path = path[1:]
+	}
+	return append(connections, RouteConnection{
+		CityA: cityA,
+		CityB: cityB,
+		Tiles: path,
+	})
+}
+
+// findRouteConnection returns the index of the connection between the two
+// cities, or -1 if they are not directly connected.
+func findRouteConnection(connections []RouteConnection, cityA, cityB Tile) int {
+	for i, c := range connections {
+		if (CityPair{c.CityA, c.CityB}).matches(cityA, cityB) {
+			return i
+		}
+	}
+	return -1
+}
+
+// classifyRouteConnections marks each connection as either a trunk or a spur.
+// Dead-end branches are repeatedly pruned from the city graph, and whatever
+// survives forms the main loop. When a group of cities has no loop at all,
+// the longest chain of connections through it is used as the trunk instead.
+func classifyRouteConnections(connections []RouteConnection) {
+	adjacent := map[Tile][]int{}
+	for i, c := range connections {
+		adjacent[c.CityA] = append(adjacent[c.CityA], i)
+		adjacent[c.CityB] = append(adjacent[c.CityB], i)
+	}
+
+	degrees := map[Tile]int{}
+	leaves := []Tile{}
+	for city, edges := range adjacent {
+		degrees[city] = len(edges)
+		if len(edges) == 1 {
+			leaves = append(leaves, city)
+		}
+	}
+	pruned := make([]bool, len(connections))
+	for len(leaves) > 0 {
+		city := leaves[0]
+		leaves = leaves[1:]
+		for _, e := range adjacent[city] {
+			if pruned[e] {
+				continue
+			}
+			pruned[e] = true
+			other := connections[e].other(city)
+			degrees[city]--
+			degrees[other]--
+			if degrees[other] == 1 {
+				leaves = append(leaves, other)
+			}
+		}
+	}
+	for i := range connections {
+		if pruned[i] {
+			connections[i].Class = RouteSpur
+		} else {
+			connections[i].Class = RouteTrunk
+		}
+	}
+
+	// Fall back to the longest chain for groups of cities without a loop.
+	visited := map[Tile]bool{}
+	for _, c := range connections {
+		if visited[c.CityA] {
+			continue
+		}
+		component, _ := walkCityGraph(c.CityA, connections, adjacent)
+		hasTrunk := false
+		for _, city := range component {
+			visited[city] = true
+			for _, e := range adjacent[city] {
+				if !pruned[e] {
+					hasTrunk = true
+				}
+			}
+		}
+		if hasTrunk {
+			continue
+		}
+		far, _ := walkCityGraph(c.CityA, connections, adjacent)
+		start := far[len(far)-1]
+		chain, via := walkCityGraph(start, connections, adjacent)
+		for city := chain[len(chain)-1]; city != start; {
+			e := via[city]
+			connections[e].Class = RouteTrunk
+			city = connections[e].other(city)
+		}
+	}
+}
+
+// walkCityGraph performs a breadth-first walk of the city graph. It returns
+// the cities in the order they were reached, along with the connection used
+// to reach each city.
+func walkCityGraph(start Tile, connections []RouteConnection, adjacent map[Tile][]int) ([]Tile, map[Tile]int) {
+	order := []Tile{start}
+	via := map[Tile]int{}
+	seen := map[Tile]bool{start: true}
+	for i := 0; i < len(order); i++ {
+		city := order[i]
+		for _, e := range adjacent[city] {
+			other := connections[e].other(city)
+			if !seen[other] {
+				seen[other] = true
+				via[other] = e
+				order = append(order, other)
+			}
+		}
+	}
+	return order, via
+}
+
+func (c RouteConnection) other(city Tile) Tile {
+	if c.CityA == city {
+		return c.CityB
+	}
+	return c.CityA
+}
+
+// RerouteConnection returns a copy of the region map where only the route
+// between the two given cities has been regenerated. All other routes are
+// left untouched.
+func (r RegionMap) RerouteConnection(cityA, cityB Tile, seed int64) (RegionMap, error) {
+	return r.RerouteConnectionWithOptions(cityA, cityB, seed, RouteOptions{})
+}
+
+// RerouteConnectionWithOptions is like RerouteConnection, but plans the new
+// route using the given route planning options.
+func (r RegionMap) RerouteConnectionWithOptions(cityA, cityB Tile, seed int64, opts RouteOptions) (RegionMap, error) {
+	index := findRouteConnection(r.Connections, cityA, cityB)
+	if index == -1 {
+		return RegionMap{}, fmt.Errorf("No route connects cities %v and %v", cityA, cityB)
+	}
+
+	// Remove the old route's tiles, unless another route shares them.
+	conn := r.Connections[index]
+	shared := map[Tile]bool{}
+	for i, c := range r.Connections {
+		if i == index {
+			continue
+		}
+		for _, t := range c.Tiles {
+			shared[t] = true
+		}
+	}
+
+	planner := newRoutePlanner(r.Elevations, opts, rand.New(rand.NewSource(seed)))
+	path := planner.connect(conn.CityA, conn.CityB, copyTileSet(shared))
+	if len(path) > 0 && path[0] == conn.CityA {
+		path = path[1:]
+	}
+
+	removed := map[Tile]bool{}
+	for _, t := range conn.Tiles {
+		if !shared[t] {
+			removed[t] = true
+		}
+	}
+	routeTiles := map[Tile]bool{}
+	routes := []Tile{}
+	for _, t := range r.Routes {
+		if !removed[t] && !routeTiles[t] {
+			routeTiles[t] = true
+			routes = append(routes, t)
+		}
+	}
+	for _, t := range path {
+		if !routeTiles[t] {
+			routeTiles[t] = true
+			routes = append(routes, t)
+		}
+	}
+
+	connections := make([]RouteConnection, len(r.Connections))
+	copy(connections, r.Connections)
+	conn.Tiles = path
+	conn.VictoryRoad = planner.isVictoryRoad(conn.CityA, conn.CityB)
+	connections[index] = conn
+	r.Routes = routes
+	r.Connections = connections
+	return r, nil
+}
+
+func copyTileSet(tiles map[Tile]bool) map[Tile]bool {
+	result := make(map[Tile]bool, len(tiles))
+	for t := range tiles {
+		result[t] = true
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package porygion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyRouteConnections(t *testing.T) {
+	a, b, c, d, e, f := Tile{1, 1}, Tile{3, 1}, Tile{5, 1}, Tile{7, 1}, Tile{9, 1}, Tile{5, 3}
+	tests := []struct {
+		name        string
+		connections []RouteConnection
+		want        []RouteClass
+	}{
+		{
+			"loop with a spur",
+			[]RouteConnection{{CityA: a, CityB: b}, {CityA: b, CityB: c}, {CityA: c, CityB: a}, {CityA: c, CityB: d}},
+			[]RouteClass{RouteTrunk, RouteTrunk, RouteTrunk, RouteSpur},
+		},
+		{
+			"tree uses the longest chain",
+			[]RouteConnection{{CityA: a, CityB: b}, {CityA: b, CityB: c}, {CityA: c, CityB: d}, {CityA: d, CityB: e}, {CityA: c, CityB: f}},
+			[]RouteClass{RouteTrunk, RouteTrunk, RouteTrunk, RouteTrunk, RouteSpur},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			classifyRouteConnections(test.connections)
+			for i, conn := range test.connections {
+				if conn.Class != test.want[i] {
+					t.Errorf("connection %v-%v is a %s, want a %s", conn.CityA, conn.CityB, conn.Class, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratedRouteClasses(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	trunks := 0
+	for _, c := range regionMap.Connections {
+		switch c.Class {
+		case RouteTrunk:
+			trunks++
+		case RouteSpur:
+		default:
+			t.Errorf("connection %v-%v has class %s", c.CityA, c.CityB, c.Class)
+		}
+	}
+	if trunks == 0 {
+		t.Errorf("no trunk routes among %d connections", len(regionMap.Connections))
+	}
+}
+
+func TestRerouteConnection(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	original := append([]RouteConnection(nil), regionMap.Connections...)
+	conn := regionMap.Connections[0]
+	rerouted, err := regionMap.RerouteConnection(conn.CityB, conn.CityA, 99)
+	if err != nil {
+		t.Fatalf("RerouteConnection: %s", err)
+	}
+	if !reflect.DeepEqual(regionMap.Connections, original) {
+		t.Errorf("RerouteConnection changed the original region map")
+	}
+	if !reflect.DeepEqual(rerouted.Connections[1:], original[1:]) {
+		t.Errorf("RerouteConnection changed the other connections")
+	}
+	if c := rerouted.Connections[0]; c.CityA != conn.CityA || c.CityB != conn.CityB {
+		t.Errorf("rerouted connection is %v-%v, want %v-%v", c.CityA, c.CityB, conn.CityA, conn.CityB)
+	}
+	checkRoutePaths(t, rerouted)
+	if !rerouted.ValidateConnectivity().Connected {
+		t.Errorf("rerouted region map isn't connected")
+	}
+
+	if _, err := regionMap.RerouteConnection(conn.CityA, conn.CityA, 99); err == nil {
+		t.Errorf("RerouteConnection of an unknown connection succeeded")
+	}
+}
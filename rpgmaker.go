@@ -0,0 +1,122 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// rpgMakerLayers is the number of layers in an RPG Maker map's tile data:
+// four tile layers, followed by the shadow and region layers.
+const rpgMakerLayers = 6
+
+// RPGMakerTileIDs maps the features of a region map to tile IDs in an RPG
+// Maker MV or MZ tileset. Autotile IDs are used as they are, without
+// picking the shape that matches their neighbors, so the editor may need
+// to redraw them.
+type RPGMakerTileIDs struct {
+	// TilesetID is the ID of the tileset in the project's database.
+	TilesetID int
+	Water     int
+	// Land holds the land tiles, from the lowest elevation to the highest.
+	Land     [5]int
+	Route    int
+	SeaRoute int
+	// Cities holds the tile for each kind of city, indexed by CityKind.
+	Cities [5]int
+}
+
+// DefaultRPGMakerTileIDs returns tile IDs for RPG Maker MV's default
+// outdoor "Field" tileset.
+func DefaultRPGMakerTileIDs() RPGMakerTileIDs {
+	return RPGMakerTileIDs{
+		TilesetID: 1,
+		Water:     2048,
+		Land:      [5]int{2816, 2864, 2912, 3008, 3104},
+		Route:     3200,
+		SeaRoute:  2096,
+		Cities:    [5]int{48, 49, 50, 51, 52},
+	}
+}
+
+type rpgMakerAudio struct {
+	Name   string `json:"name"`
+	Pan    int    `json:"pan"`
+	Pitch  int    `json:"pitch"`
+	Volume int    `json:"volume"`
+}
+
+type rpgMakerMap struct {
+	AutoplayBgm       bool          `json:"autoplayBgm"`
+	AutoplayBgs       bool          `json:"autoplayBgs"`
+	Battleback1Name   string        `json:"battleback1Name"`
+	Battleback2Name   string        `json:"battleback2Name"`
+	Bgm               rpgMakerAudio `json:"bgm"`
+	Bgs               rpgMakerAudio `json:"bgs"`
+	DisableDashing    bool          `json:"disableDashing"`
+	DisplayName       string        `json:"displayName"`
+	EncounterList     []interface{} `json:"encounterList"`
+	EncounterStep     int           `json:"encounterStep"`
+	Height            int           `json:"height"`
+	Note              string        `json:"note"`
+	ParallaxLoopX     bool          `json:"parallaxLoopX"`
+	ParallaxLoopY     bool          `json:"parallaxLoopY"`
+	ParallaxName      string        `json:"parallaxName"`
+	ParallaxShow      bool          `json:"parallaxShow"`
+	ParallaxSx        int           `json:"parallaxSx"`
+	ParallaxSy        int           `json:"parallaxSy"`
+	ScrollType        int           `json:"scrollType"`
+	SpecifyBattleback bool          `json:"specifyBattleback"`
+	TilesetID         int           `json:"tilesetId"`
+	Width             int           `json:"width"`
+	Data              []int         `json:"data"`
+	Events            []interface{} `json:"events"`
+}
+
+// ExportRPGMakerMap converts a region map into an RPG Maker MV or MZ map
+// file (MapXXX.json), with one map tile for each region map tile. The
+// terrain is placed on the first layer, routes on the second, and cities
+// on the third.
+func ExportRPGMakerMap(regionMap RegionMap, ids RPGMakerTileIDs) ([]byte, error) {
+	tilesWidth, tilesHeight, err := getExportTileSize(regionMap)
+	if err != nil {
+		return nil, err
+	}
+	layers := getTileLayers(regionMap)
+	data := make([]int, tilesWidth*tilesHeight*rpgMakerLayers)
+	set := func(z, i, id int) {
+		data[z*tilesWidth*tilesHeight+i] = id
+	}
+	for i := range layers.terrain {
+		if t := layers.terrain[i]; t == tiledWaterTile {
+			set(0, i, ids.Water)
+		} else {
+			set(0, i, ids.Land[t-tiledLandTile])
+		}
+		switch layers.routes[i] {
+		case tiledRouteTile:
+			set(1, i, ids.Route)
+		case tiledSeaRouteTile:
+			set(1, i, ids.SeaRoute)
+		}
+		if c := layers.cities[i]; c >= 0 {
+			set(2, i, ids.Cities[c-tiledCityTile])
+		}
+	}
+	audio := rpgMakerAudio{Pitch: 100, Volume: 90}
+	encoded, err := json.Marshal(rpgMakerMap{
+		Bgm:           audio,
+		Bgs:           audio,
+		EncounterList: []interface{}{},
+		EncounterStep: 30,
+		Height:        tilesHeight,
+		TilesetID:     ids.TilesetID,
+		Width:         tilesWidth,
+		Data:          data,
+		// Event IDs start at 1, so the first entry is always null.
+		Events: []interface{}{nil},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode RPG Maker map: %s", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,43 @@
+package porygion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportRPGMakerMap(t *testing.T) {
+	regionMap := testRegionMap(t)
+	ids := DefaultRPGMakerTileIDs()
+	data, err := ExportRPGMakerMap(regionMap, ids)
+	if err != nil {
+		t.Fatalf("ExportRPGMakerMap: %s", err)
+	}
+	var m rpgMakerMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("Failed to decode the RPG Maker map: %s", err)
+	}
+	if m.Width != 12 || m.Height != 8 || m.TilesetID != ids.TilesetID || len(m.Data) != 12*8*rpgMakerLayers {
+		t.Fatalf("RPG Maker map is %dx%d with tileset %d and %d tiles", m.Width, m.Height, m.TilesetID, len(m.Data))
+	}
+	if len(m.Events) != 1 || m.Events[0] != nil {
+		t.Errorf("Events = %v, want [null]", m.Events)
+	}
+	layer := func(z int, tile Tile) int {
+		return m.Data[z*m.Width*m.Height+tile.Y*m.Width+tile.X]
+	}
+	for i := 0; i < m.Width*m.Height; i++ {
+		if id := m.Data[i]; id == 0 {
+			t.Errorf("terrain tile %d is empty", i)
+		}
+	}
+	for _, route := range regionMap.Routes {
+		if id := layer(1, route); id != ids.Route && id != ids.SeaRoute {
+			t.Errorf("route %v has tile %d", route, id)
+		}
+	}
+	for _, city := range regionMap.Cities {
+		if id, want := layer(2, city), ids.Cities[regionMap.CityKinds[city]]; id != want {
+			t.Errorf("city %v has tile %d, want %d", city, id, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package porygion
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SearchConstraints are requirements that a region map must meet, for
+// finding seeds with interesting maps. Zero values mean no requirement.
+type SearchConstraints struct {
+	// MinLandPercentage and MaxLandPercentage bound the percentage of
+	// pixels that are above sea level.
+	MinLandPercentage float64
+	MaxLandPercentage float64
+	MinIslands        int
+	MaxIslands        int
+	MinCities         int
+	// MaxRouteLength is the longest that any route between two cities may
+	// be, in tiles.
+	MaxRouteLength int
+}
+
+// Validate checks that the constraints can be met.
+func (c SearchConstraints) Validate() error {
+	if c.MinLandPercentage < 0 || c.MinLandPercentage > 100 || c.MaxLandPercentage < 0 || c.MaxLandPercentage > 100 {
+		return fmt.Errorf("Land percentages must be between 0 and 100")
+	}
+	if c.MaxLandPercentage > 0 && c.MinLandPercentage > c.MaxLandPercentage {
+		return fmt.Errorf("The minimum land percentage %g is more than the maximum %g", c.MinLandPercentage, c.MaxLandPercentage)
+	}
+	if c.MinIslands < 0 || c.MaxIslands < 0 || c.MinCities < 0 || c.MaxRouteLength < 0 {
+		return fmt.Errorf("Island, city, and route constraints can't be negative")
+	}
+	if c.MaxIslands > 0 && c.MinIslands > c.MaxIslands {
+		return fmt.Errorf("The minimum number of islands %d is more than the maximum %d", c.MinIslands, c.MaxIslands)
+	}
+	return nil
+}
+
+// Check returns a description of each constraint that the region map
+// fails, or nil if it meets all of them.
+func (c SearchConstraints) Check(regionMap RegionMap) []string {
+	failures := []string{}
+	if c.MinLandPercentage > 0 || c.MaxLandPercentage > 0 || c.MinIslands > 0 || c.MaxIslands > 0 {
+		islandSizes := getIslandSizes(regionMap.Elevations)
+		land := getLandPercentage(regionMap, islandSizes)
+		if land < c.MinLandPercentage {
+			failures = append(failures, fmt.Sprintf("land is %.1f%%, less than %g%%", land, c.MinLandPercentage))
+		}
+		if c.MaxLandPercentage > 0 && land > c.MaxLandPercentage {
+			failures = append(failures, fmt.Sprintf("land is %.1f%%, more than %g%%", land, c.MaxLandPercentage))
+		}
+		if len(islandSizes) < c.MinIslands {
+			failures = append(failures, fmt.Sprintf("%d islands, fewer than %d", len(islandSizes), c.MinIslands))
+		}
+		if c.MaxIslands > 0 && len(islandSizes) > c.MaxIslands {
+			failures = append(failures, fmt.Sprintf("%d islands, more than %d", len(islandSizes), c.MaxIslands))
+		}
+	}
+	if len(regionMap.Cities) < c.MinCities {
+		failures = append(failures, fmt.Sprintf("%d cities, fewer than %d", len(regionMap.Cities), c.MinCities))
+	}
+	if c.MaxRouteLength > 0 {
+		longest := 0
+		for _, edge := range regionMap.CityGraph().Edges {
+			if edge.Length > longest {
+				longest = edge.Length
+			}
+		}
+		if longest > c.MaxRouteLength {
+			failures = append(failures, fmt.Sprintf("longest route is %d tiles, more than %d", longest, c.MaxRouteLength))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return failures
+}
+
+// Match reports whether the region map meets all of the constraints.
+func (c SearchConstraints) Match(regionMap RegionMap) bool {
+	return c.Check(regionMap) == nil
+}
+
+// String describes the constraints, like "land >= 35%, islands >= 3".
+func (c SearchConstraints) String() string {
+	parts := []string{}
+	if c.MinLandPercentage > 0 {
+		parts = append(parts, fmt.Sprintf("land >= %g%%", c.MinLandPercentage))
+	}
+	if c.MaxLandPercentage > 0 {
+		parts = append(parts, fmt.Sprintf("land <= %g%%", c.MaxLandPercentage))
+	}
+	if c.MinIslands > 0 {
+		parts = append(parts, fmt.Sprintf("islands >= %d", c.MinIslands))
+	}
+	if c.MaxIslands > 0 {
+		parts = append(parts, fmt.Sprintf("islands <= %d", c.MaxIslands))
+	}
+	if c.MinCities > 0 {
+		parts = append(parts, fmt.Sprintf("cities >= %d", c.MinCities))
+	}
+	if c.MaxRouteLength > 0 {
+		parts = append(parts, fmt.Sprintf("route length <= %d", c.MaxRouteLength))
+	}
+	if len(parts) == 0 {
+		return "no constraints"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getLandPercentage returns the percentage of the region map's pixels that
+// belong to the given islands.
+func getLandPercentage(regionMap RegionMap, islandSizes []int) float64 {
+	numPixels := regionMap.PixelWidth * regionMap.PixelHeight
+	if numPixels == 0 {
+		return 0
+	}
+	numLand := 0
+	for _, size := range islandSizes {
+		numLand += size
+	}
+	return float64(numLand) * 100 / float64(numPixels)
+}
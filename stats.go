@@ -0,0 +1,145 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"sort"
+)
+
+// RegionMapStats summarizes the terrain, cities, and routes of a region
+// map.
+type RegionMapStats struct {
+	PixelWidth  int `json:"pixel_width"`
+	PixelHeight int `json:"pixel_height"`
+	// LandPercentage is the percentage of pixels that are above sea level.
+	LandPercentage float64 `json:"land_percentage"`
+	IslandCount    int     `json:"island_count"`
+	// IslandSizes are the sizes, in pixels, of each island, from largest
+	// to smallest.
+	IslandSizes []int `json:"island_sizes"`
+	CityCount   int   `json:"city_count"`
+	// CitySpacings are the Manhattan distances, in tiles, from each city
+	// to its nearest neighbor, from smallest to largest.
+	CitySpacings []int `json:"city_spacings"`
+	// RouteLength is the number of distinct route tiles.
+	RouteLength int `json:"route_length"`
+	// RouteLengths are the lengths, in tiles, of the routes between
+	// cities, from shortest to longest.
+	RouteLengths []int `json:"route_lengths"`
+	// BiomeCoverage is the percentage of pixels covered by each biome,
+	// keyed by biome name. Biomes that don't appear are omitted.
+	BiomeCoverage map[string]float64 `json:"biome_coverage"`
+}
+
+// Stats computes statistics about the region map.
+func (r RegionMap) Stats() RegionMapStats {
+	stats := RegionMapStats{
+		PixelWidth:    r.PixelWidth,
+		PixelHeight:   r.PixelHeight,
+		IslandSizes:   getIslandSizes(r.Elevations),
+		CityCount:     len(r.Cities),
+		CitySpacings:  getCitySpacings(r.Cities),
+		RouteLengths:  []int{},
+		BiomeCoverage: map[string]float64{},
+	}
+	stats.IslandCount = len(stats.IslandSizes)
+
+	numPixels := 0
+	numLand := 0
+	biomeCounts := map[Biome]int{}
+	for _, column := range ClassifyBiomes(r) {
+		for _, biome := range column {
+			biomeCounts[biome]++
+			numPixels++
+		}
+	}
+	for _, size := range stats.IslandSizes {
+		numLand += size
+	}
+	if numPixels > 0 {
+		stats.LandPercentage = float64(numLand) * 100 / float64(numPixels)
+		for biome, count := range biomeCounts {
+			stats.BiomeCoverage[biome.String()] = float64(count) * 100 / float64(numPixels)
+		}
+	}
+
+	routes := map[Tile]bool{}
+	for _, t := range r.Routes {
+		routes[t] = true
+	}
+	stats.RouteLength = len(routes)
+	for _, edge := range r.CityGraph().Edges {
+		stats.RouteLengths = append(stats.RouteLengths, edge.Length)
+	}
+	sort.Ints(stats.RouteLengths)
+	return stats
+}
+
+// ExportStats writes a JSON report of the region map's statistics.
+func ExportStats(regionMap RegionMap) ([]byte, error) {
+	data, err := json.MarshalIndent(regionMap.Stats(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode stats: %s", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// getIslandSizes returns the sizes, in pixels, of the groups of land
+// pixels that are connected horizontally or vertically, from largest to
+// smallest.
+func getIslandSizes(elevations [][]float64) []int {
+	visited := make([][]bool, len(elevations))
+	for i := range elevations {
+		visited[i] = make([]bool, len(elevations[i]))
+	}
+	isLand := func(p image.Point) bool {
+		return p.X >= 0 && p.X < len(elevations) && p.Y >= 0 && p.Y < len(elevations[p.X]) && elevations[p.X][p.Y] > 0
+	}
+	sizes := []int{}
+	for i := range elevations {
+		for j := range elevations[i] {
+			start := image.Point{i, j}
+			if visited[i][j] || !isLand(start) {
+				continue
+			}
+			size := 0
+			stack := []image.Point{start}
+			visited[i][j] = true
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				size++
+				for _, n := range [4]image.Point{{p.X, p.Y - 1}, {p.X + 1, p.Y}, {p.X, p.Y + 1}, {p.X - 1, p.Y}} {
+					if isLand(n) && !visited[n.X][n.Y] {
+						visited[n.X][n.Y] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return sizes
+}
+
+// getCitySpacings returns the distance from each city to its nearest
+// neighbor, sorted. A lone city has no spacing.
+func getCitySpacings(cities []Tile) []int {
+	spacings := []int{}
+	if len(cities) < 2 {
+		return spacings
+	}
+	for i, city := range cities {
+		nearest := -1
+		for j, other := range cities {
+			if d := city.Distance(other); i != j && (nearest < 0 || d < nearest) {
+				nearest = d
+			}
+		}
+		spacings = append(spacings, nearest)
+	}
+	sort.Ints(spacings)
+	return spacings
+}
@@ -0,0 +1,58 @@
+package porygion
+
+import (
+	"image"
+	"image/gif"
+)
+
+// generationStepDelay is the delay between the frames of a generation
+// animation, in 100ths of a second.
+const generationStepDelay = 50
+
+// RenderGenerationSteps generates a new complete region map, exactly like
+// GenerateRegionMap, and renders a frame after each step of the
+// generation pipeline. The frames show the base noise layer, the terrain
+// with its detail layers, the placed cities, and then each route as it is
+// added, ending with the finished map.
+func RenderGenerationSteps(seed int64, pixelWidth, pixelHeight int, numCities int, opts RenderOptions) ([]image.Image, RegionMap, error) {
+	regionMap, debug, err := GenerateRegionMapDebug(seed, pixelWidth, pixelHeight, numCities)
+	if err != nil {
+		return nil, RegionMap{}, err
+	}
+	frames := []image.Image{}
+	addFrame := func(step RegionMap) {
+		frames = append(frames, renderRegionMapImage(step, opts))
+	}
+
+	step := RegionMap{
+		Seed:        regionMap.Seed,
+		PixelWidth:  regionMap.PixelWidth,
+		PixelHeight: regionMap.PixelHeight,
+		Elevations:  debug.BaseElevations,
+	}
+	addFrame(step)
+	step.Elevations = regionMap.Elevations
+	addFrame(step)
+	step.Cities = regionMap.Cities
+	step.CityNames = regionMap.CityNames
+	step.CityKinds = regionMap.CityKinds
+	addFrame(step)
+	step.Routes = []Tile{}
+	for i, c := range regionMap.Connections {
+		step.Routes = append(step.Routes, c.Tiles...)
+		step.Connections = regionMap.Connections[:i+1]
+		addFrame(step)
+	}
+	addFrame(regionMap)
+	return frames, regionMap, nil
+}
+
+// RenderGenerationAnimation renders the steps of generating a region map,
+// as a looping GIF animation. See RenderGenerationSteps.
+func RenderGenerationAnimation(seed int64, pixelWidth, pixelHeight int, numCities int, opts RenderOptions) (*gif.GIF, error) {
+	frames, _, err := RenderGenerationSteps(seed, pixelWidth, pixelHeight, numCities, opts)
+	if err != nil {
+		return nil, err
+	}
+	return encodeAnimation(frames, generationStepDelay), nil
+}
@@ -0,0 +1,254 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Streaming renders the map in bands of rows, so that only one band's
+// pixels are held in memory at a time.
+const (
+	// streamingBandTiles is the height, in tiles, of each band.
+	streamingBandTiles = 8
+	// streamingMarginTiles is the number of tiles above and below a band
+	// that are rendered along with it, and then cropped away. Effects like
+	// hillshading, route casing, and ocean depth look at nearby pixels, so
+	// they need the margin to match the rows of the neighboring bands.
+	streamingMarginTiles = 3
+)
+
+// RenderRegionMapBands renders a region map one band of rows at a time,
+// from top to bottom, and passes each band to fn along with the y
+// position of its top row. Each band is only valid until fn returns. The
+// rendered rows are the same as RenderRegionMap's, but labels,
+// decorations, and the legend need the whole image, so they aren't
+// supported.
+func RenderRegionMapBands(regionMap RegionMap, opts RenderOptions, fn func(y int, band *image.RGBA) error) error {
+	if err := validateStreamingOptions(opts); err != nil {
+		return err
+	}
+	palette := getRenderPalette(opts)
+	scale := getStreamingScale(opts)
+	numBands := (getStreamingTileHeight(regionMap) + streamingBandTiles - 1) / streamingBandTiles
+	for i := 0; i < numBands; i++ {
+		band := renderRegionMapBand(regionMap, palette, opts, i)
+		if err := fn(i*streamingBandTiles*8*scale, band); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteStreamingPNG renders a region map and encodes it as a PNG, one band
+// of rows at a time, rather than rendering the whole image first. This
+// keeps the memory used for very large maps small. It supports the same
+// options as RenderRegionMapBands.
+func WriteStreamingPNG(w io.Writer, regionMap RegionMap, opts RenderOptions) error {
+	if err := validateStreamingOptions(opts); err != nil {
+		return err
+	}
+	img := &bandedImage{
+		regionMap: regionMap,
+		opts:      opts,
+		palette:   getRenderPalette(opts),
+		scale:     getStreamingScale(opts),
+		bandIndex: -1,
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("Failed to write PNG: %s", err)
+	}
+	return nil
+}
+
+func validateStreamingOptions(opts RenderOptions) error {
+	if opts.Labels != nil || opts.Compass != nil || opts.ScaleBar != nil || opts.Legend {
+		return fmt.Errorf("Labels, decorations, and the legend aren't supported when streaming")
+	}
+	return nil
+}
+
+func getStreamingScale(opts RenderOptions) int {
+	if opts.Scale > 1 {
+		return opts.Scale
+	}
+	return 1
+}
+
+// getStreamingTileHeight returns the height of the region map, in tiles.
+// A partial row of tiles at the bottom counts as a whole one.
+func getStreamingTileHeight(regionMap RegionMap) int {
+	return (len(regionMap.Elevations[0]) + 7) / 8
+}
+
+// renderRegionMapBand renders a single band of the region map, scaled by
+// the render options. The band's top row is at y = 0 in the image.
+func renderRegionMapBand(regionMap RegionMap, palette Palette, opts RenderOptions, bandIndex int) *image.RGBA {
+	numTiles := getStreamingTileHeight(regionMap)
+	top := bandIndex * streamingBandTiles
+	bottom := top + streamingBandTiles
+	if bottom > numTiles {
+		bottom = numTiles
+	}
+	cropTop := top - streamingMarginTiles
+	if cropTop < 0 {
+		cropTop = 0
+	}
+	cropBottom := bottom + streamingMarginTiles
+	if cropBottom > numTiles {
+		cropBottom = numTiles
+	}
+
+	crop := cropRegionMapRows(regionMap, cropTop, cropBottom)
+	img := newRegionMapImage(crop)
+	drawRegionMap(img, crop, palette, cropRenderOptionsRows(opts, cropTop))
+	bandHeight := len(crop.Elevations[0]) - (top-cropTop)*8
+	if bandHeight > (bottom-top)*8 {
+		bandHeight = (bottom - top) * 8
+	}
+	bounds := image.Rect(0, (top-cropTop)*8, img.Bounds().Dx(), (top-cropTop)*8+bandHeight)
+	band := img.SubImage(bounds).(*image.RGBA)
+	scale := getStreamingScale(opts)
+	if scale > 1 {
+		return scaleImage(band, scale)
+	}
+	// Copy the band to its own image, so that it starts at the origin.
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		copy(dst.Pix[y*dst.Stride:(y+1)*dst.Stride], band.Pix[y*band.Stride:y*band.Stride+dst.Stride])
+	}
+	return dst
+}
+
+// cropRegionMapRows returns the rows of tiles from top up to, but not
+// including, bottom, as a region map of its own. The elevations share
+// memory with the original region map, rather than being copied.
+func cropRegionMapRows(regionMap RegionMap, top, bottom int) RegionMap {
+	height := len(regionMap.Elevations[0])
+	minY := top * 8
+	maxY := bottom * 8
+	if maxY > height {
+		maxY = height
+	}
+	crop := RegionMap{
+		Seed:        regionMap.Seed,
+		PixelWidth:  regionMap.PixelWidth,
+		PixelHeight: maxY - minY,
+		Elevations:  make([][]float64, len(regionMap.Elevations)),
+		Cities:      cropTileRows(regionMap.Cities, top, bottom),
+		Routes:      cropTileRows(regionMap.Routes, top, bottom),
+		CityKinds:   map[Tile]CityKind{},
+	}
+	for x, column := range regionMap.Elevations {
+		crop.Elevations[x] = column[minY:maxY]
+	}
+	for _, city := range crop.Cities {
+		original := Tile{city.X, city.Y + top}
+		if kind, ok := regionMap.CityKinds[original]; ok {
+			crop.CityKinds[city] = kind
+		}
+	}
+	for _, c := range regionMap.Connections {
+		crop.Connections = append(crop.Connections, RouteConnection{
+			CityA:       Tile{c.CityA.X, c.CityA.Y - top},
+			CityB:       Tile{c.CityB.X, c.CityB.Y - top},
+			Tiles:       cropTileRows(c.Tiles, top, bottom),
+			Class:       c.Class,
+			VictoryRoad: c.VictoryRoad,
+		})
+	}
+	return crop
+}
+
+// cropTileRows returns the tiles in the rows from top up to, but not
+// including, bottom, moved up so that the top row is at y = 0.
+func cropTileRows(tiles []Tile, top, bottom int) []Tile {
+	cropped := []Tile{}
+	for _, t := range tiles {
+		if t.Y >= top && t.Y < bottom {
+			cropped = append(cropped, Tile{t.X, t.Y - top})
+		}
+	}
+	return cropped
+}
+
+// cropRenderOptionsRows moves the tiles referred to by the render options
+// up by the given number of rows, to match a cropped region map.
+func cropRenderOptionsRows(opts RenderOptions, top int) RenderOptions {
+	if opts.FogOfWar != nil {
+		fog := *opts.FogOfWar
+		fog.Visited = map[Tile]bool{}
+		for t, visited := range opts.FogOfWar.Visited {
+			if visited && t.Y >= top {
+				fog.Visited[Tile{t.X, t.Y - top}] = true
+			}
+		}
+		opts.FogOfWar = &fog
+	}
+	if opts.Highlight != nil {
+		highlight := *opts.Highlight
+		highlight.Tiles = cropTileRows(opts.Highlight.Tiles, top, math.MaxInt32)
+		opts.Highlight = &highlight
+	}
+	return opts
+}
+
+// bandedImage is a rendered region map whose pixels are rendered one band
+// at a time, as they are read. Reading the rows in order, like the PNG
+// encoder does, renders each band only once.
+type bandedImage struct {
+	regionMap RegionMap
+	opts      RenderOptions
+	palette   Palette
+	scale     int
+	band      *image.RGBA
+	bandIndex int
+}
+
+func (b *bandedImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (b *bandedImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, len(b.regionMap.Elevations)*b.scale, len(b.regionMap.Elevations[0])*b.scale)
+}
+
+func (b *bandedImage) At(x, y int) color.Color {
+	bandHeight := streamingBandTiles * 8 * b.scale
+	if i := y / bandHeight; i != b.bandIndex {
+		b.band = renderRegionMapBand(b.regionMap, b.palette, b.opts, i)
+		b.bandIndex = i
+	}
+	return b.band.RGBAAt(x, y%bandHeight)
+}
+
+// Opaque reports whether every pixel is opaque, so that the PNG encoder
+// doesn't have to read every pixel to find out.
+func (b *bandedImage) Opaque() bool {
+	if b.opts.TransparentWater {
+		return false
+	}
+	colors := []color.RGBA{b.palette.City, colorCityOutline}
+	colors = append(colors, b.palette.Water[:]...)
+	// Deep water shades that are left unset aren't used.
+	if b.palette.DeepWater != ([2]color.RGBA{}) {
+		colors = append(colors, b.palette.DeepWater[:]...)
+	}
+	colors = append(colors, b.palette.Land[:]...)
+	colors = append(colors, b.palette.RouteWater[:]...)
+	colors = append(colors, b.palette.RouteLand[:]...)
+	if b.opts.LandColors != nil {
+		for _, stop := range b.opts.LandColors.Stops {
+			colors = append(colors, stop.Color)
+		}
+	}
+	for _, c := range colors {
+		if c.A != 0xff {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package porygion
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+func TestRenderRegionMapBandsMatchesRenderRegionMap(t *testing.T) {
+	// The height isn't a multiple of the band height, or even of a tile,
+	// so that the last band is partial.
+	regionMap, err := GenerateRegionMap(11, 64, 196, 5)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	hillshade := DefaultHillshadeOptions()
+	contours := DefaultContourOptions()
+	coastline := DefaultCoastlineOptions()
+	casing := DefaultRouteCasingOptions()
+	tests := []struct {
+		name string
+		opts RenderOptions
+	}{
+		{"default", RenderOptions{}},
+		{"scaled", RenderOptions{Scale: 3}},
+		{"hillshade", RenderOptions{Hillshade: &hillshade}},
+		{"contours", RenderOptions{Contours: &contours}},
+		{"coastline", RenderOptions{Coastline: &coastline}},
+		{"route casing", RenderOptions{RouteCasing: &casing, NarrowSpurRoutes: true}},
+		{"ocean depth", RenderOptions{OceanDepth: true, Scale: 2}},
+		{"autotiled routes", RenderOptions{AutotileRoutes: true, Dither: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := RenderRegionMap(regionMap, test.opts)
+			got := image.NewRGBA(want.Bounds())
+			nextY := 0
+			err := RenderRegionMapBands(regionMap, test.opts, func(y int, band *image.RGBA) error {
+				if y != nextY {
+					t.Fatalf("band starts at y = %d, want %d", y, nextY)
+				}
+				nextY += band.Bounds().Dy()
+				draw.Draw(got, band.Bounds().Add(image.Pt(0, y)), band, band.Bounds().Min, draw.Src)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("RenderRegionMapBands: %s", err)
+			}
+			if nextY != want.Bounds().Dy() {
+				t.Fatalf("bands cover %d rows, want %d", nextY, want.Bounds().Dy())
+			}
+			if !bytes.Equal(got.Pix, want.(*image.RGBA).Pix) {
+				t.Errorf("bands differ from RenderRegionMap")
+			}
+
+			var buf bytes.Buffer
+			if err := WriteStreamingPNG(&buf, regionMap, test.opts); err != nil {
+				t.Fatalf("WriteStreamingPNG: %s", err)
+			}
+			decoded, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode: %s", err)
+			}
+			streamed := image.NewRGBA(decoded.Bounds())
+			draw.Draw(streamed, streamed.Bounds(), decoded, image.Point{}, draw.Src)
+			if !bytes.Equal(streamed.Pix, want.(*image.RGBA).Pix) {
+				t.Errorf("WriteStreamingPNG differs from RenderRegionMap")
+			}
+		})
+	}
+}
+
+func TestRenderRegionMapBandsRejectsWholeImageOptions(t *testing.T) {
+	regionMap := testRegionMap(t)
+	labels := DefaultLabelOptions()
+	compass := DefaultCompassOptions()
+	scaleBar := DefaultScaleBarOptions()
+	for _, opts := range []RenderOptions{
+		{Labels: &labels},
+		{Compass: &compass},
+		{ScaleBar: &scaleBar},
+		{Legend: true},
+	} {
+		err := RenderRegionMapBands(regionMap, opts, func(int, *image.RGBA) error { return nil })
+		if err == nil {
+			t.Errorf("RenderRegionMapBands(%+v) succeeded, want an error", opts)
+		}
+	}
+}
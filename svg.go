@@ -0,0 +1,83 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// svgRouteWidth is the stroke width of routes, in pixels.
+const svgRouteWidth = 4
+
+// WriteSVG writes a region map as an SVG vector image. The coastline and
+// elevation bands are traced as filled paths, routes are drawn as
+// polylines, and cities are drawn as squares.
+func WriteSVG(w io.Writer, regionMap RegionMap, palette Palette) error {
+	elevations := regionMap.Elevations
+	width := len(elevations)
+	height := len(elevations[0])
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(bw, "  <rect id=\"water\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", width, height, svgColor(palette.Water[0]))
+
+	fmt.Fprintf(bw, "  <g id=\"terrain\" fill-rule=\"evenodd\">\n")
+	for i, level := range elevationBandLevels {
+		loops := traceContours(elevations, level)
+		if len(loops) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "    <path fill=\"%s\" d=\"%s\"/>\n", svgColor(palette.Land[i]), svgPathData(loops))
+	}
+	fmt.Fprintf(bw, "  </g>\n")
+
+	fmt.Fprintf(bw, "  <g id=\"routes\" fill=\"none\" stroke=\"%s\" stroke-width=\"%d\" stroke-linejoin=\"round\" stroke-linecap=\"square\">\n", svgColor(palette.RouteLand[0]), svgRouteWidth)
+	for _, c := range regionMap.Connections {
+		points := []string{}
+		for _, t := range c.Vertices() {
+			points = append(points, fmt.Sprintf("%d,%d", t.X*8+4, t.Y*8+4))
+		}
+		fmt.Fprintf(bw, "    <polyline points=\"%s\"/>\n", strings.Join(points, " "))
+	}
+	fmt.Fprintf(bw, "  </g>\n")
+
+	fmt.Fprintf(bw, "  <g id=\"cities\" fill=\"%s\">\n", svgColor(palette.City))
+	for _, city := range regionMap.Cities {
+		fmt.Fprintf(bw, "    <rect x=\"%d\" y=\"%d\" width=\"8\" height=\"8\"", city.X*8, city.Y*8)
+		if name, ok := regionMap.CityNames[city]; ok {
+			fmt.Fprintf(bw, "><title>%s</title></rect>\n", svgEscape(name))
+		} else {
+			fmt.Fprintf(bw, "/>\n")
+		}
+	}
+	fmt.Fprintf(bw, "  </g>\n")
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+func svgPathData(loops [][]contourPoint) string {
+	var sb strings.Builder
+	for _, loop := range loops {
+		for i, p := range loop {
+			if i == 0 {
+				sb.WriteString("M")
+			} else {
+				sb.WriteString("L")
+			}
+			fmt.Fprintf(&sb, "%.2f %.2f", p.X, p.Y)
+		}
+		sb.WriteString("Z")
+	}
+	return sb.String()
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+func svgEscape(s string) string {
+	return svgEscaper.Replace(s)
+}
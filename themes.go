@@ -0,0 +1,79 @@
+package porygion
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// themes are the built-in palettes, keyed by name. Each one approximates
+// the Town Map art style of a different generation of games.
+var themes = map[string]func() Palette{
+	"rse": DefaultPalette,
+	"night": func() Palette {
+		return NightPalette(DefaultPalette())
+	},
+	"frlg": func() Palette {
+		return Palette{
+			Water:      [2]color.RGBA{{120, 192, 232, 255}, {104, 176, 224, 255}},
+			Land:       [5]color.RGBA{{64, 144, 64, 255}, {96, 168, 72, 255}, {136, 192, 88, 255}, {176, 208, 112, 255}, {216, 224, 160, 255}},
+			RouteWater: [2]color.RGBA{{232, 232, 248, 255}, {216, 216, 240, 255}},
+			RouteLand:  [5]color.RGBA{{248, 248, 248, 255}, {248, 248, 240, 255}, {248, 248, 232, 255}, {248, 240, 224, 255}, {240, 232, 216, 255}},
+			City:       color.RGBA{248, 56, 56, 255},
+		}
+	},
+	"gb": func() Palette {
+		darkest := color.RGBA{15, 56, 15, 255}
+		dark := color.RGBA{48, 98, 48, 255}
+		light := color.RGBA{139, 172, 15, 255}
+		lightest := color.RGBA{155, 188, 15, 255}
+		return Palette{
+			Water:      [2]color.RGBA{dark, dark},
+			DeepWater:  [2]color.RGBA{darkest, dark},
+			Land:       [5]color.RGBA{light, light, light, lightest, lightest},
+			RouteWater: [2]color.RGBA{lightest, lightest},
+			RouteLand:  [5]color.RGBA{dark, dark, dark, dark, dark},
+			City:       darkest,
+		}
+	},
+	"hgss": func() Palette {
+		return Palette{
+			Water:      [2]color.RGBA{{64, 128, 216, 255}, {56, 112, 200, 255}},
+			Land:       [5]color.RGBA{{40, 136, 56, 255}, {72, 168, 64, 255}, {112, 192, 72, 255}, {168, 200, 96, 255}, {200, 176, 120, 255}},
+			RouteWater: [2]color.RGBA{{168, 208, 248, 255}, {152, 192, 240, 255}},
+			RouteLand:  [5]color.RGBA{{240, 216, 128, 255}, {240, 216, 136, 255}, {240, 224, 144, 255}, {240, 224, 160, 255}, {240, 232, 176, 255}},
+			City:       color.RGBA{232, 64, 32, 255},
+		}
+	},
+	"dppt": func() Palette {
+		return Palette{
+			Water:      [2]color.RGBA{{112, 168, 200, 255}, {104, 160, 192, 255}},
+			Land:       [5]color.RGBA{{104, 152, 104, 255}, {128, 168, 112, 255}, {152, 184, 128, 255}, {176, 192, 152, 255}, {232, 240, 240, 255}},
+			RouteWater: [2]color.RGBA{{176, 208, 224, 255}, {168, 200, 216, 255}},
+			RouteLand:  [5]color.RGBA{{208, 192, 144, 255}, {216, 200, 152, 255}, {224, 208, 160, 255}, {224, 216, 176, 255}, {200, 200, 208, 255}},
+			City:       color.RGBA{224, 96, 56, 255},
+		}
+	},
+}
+
+// ThemeNames returns the names of the built-in palette themes, in
+// alphabetical order.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemePalette returns the built-in palette theme with the given name.
+// Names are case-insensitive.
+func ThemePalette(name string) (Palette, error) {
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return Palette{}, fmt.Errorf("Unknown theme '%s'. Valid themes are: %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme(), nil
+}
@@ -1,8 +1,11 @@
 package porygion
 
+import "sort"
+
 // Tile is a 8x8-pixel section in a region map.
 type Tile struct {
-	X, Y int
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 // Distance computes the manhattan distance between two Tiles.
@@ -17,3 +20,67 @@ func (t Tile) Distance(other Tile) int {
 	}
 	return xDiff + yDiff
 }
+
+// neighbors returns the four tiles orthogonally adjacent to the tile.
+func (t Tile) neighbors() [4]Tile {
+	return [4]Tile{
+		{t.X - 1, t.Y},
+		{t.X + 1, t.Y},
+		{t.X, t.Y - 1},
+		{t.X, t.Y + 1},
+	}
+}
+
+// Direction is a cardinal direction on the region map.
+type Direction int
+
+// Cardinal directions.
+const (
+	North Direction = iota
+	East
+	South
+	West
+)
+
+func (d Direction) String() string {
+	switch d {
+	case North:
+		return "north"
+	case East:
+		return "east"
+	case South:
+		return "south"
+	case West:
+		return "west"
+	}
+	return "unknown"
+}
+
+// directionTo returns the direction of an adjacent tile, relative to the tile.
+func (t Tile) directionTo(other Tile) Direction {
+	switch {
+	case other.Y < t.Y:
+		return North
+	case other.X > t.X:
+		return East
+	case other.Y > t.Y:
+		return South
+	}
+	return West
+}
+
+// getSortedTiles returns the tiles in a set, sorted from top to bottom,
+// then left to right.
+func getSortedTiles(set map[Tile]bool) []Tile {
+	tiles := make([]Tile, 0, len(set))
+	for t := range set {
+		tiles = append(tiles, t)
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		if tiles[i].Y != tiles[j].Y {
+			return tiles[i].Y < tiles[j].Y
+		}
+		return tiles[i].X < tiles[j].X
+	})
+	return tiles
+}
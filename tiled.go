@@ -0,0 +1,236 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Tile indexes in the tileset generated for tilemap exports. The first
+// tiles follow the standard layout used by NewTileset, and are followed by
+// a marker for each kind of city.
+const (
+	tiledWaterTile    = 0
+	tiledLandTile     = 1
+	tiledRouteTile    = 6
+	tiledSeaRouteTile = 7
+	tiledCityTile     = 8
+	tiledNumTiles     = tiledCityTile + int(CityLeague) + 1
+)
+
+// tiledTileNames are the names given to each tile of the generated tileset,
+// for the exporters that support named tiles.
+var tiledTileNames = [tiledNumTiles]string{
+	"water", "land0", "land1", "land2", "land3", "land4", "route", "sea_route",
+	"town", "city", "capital", "port", "league",
+}
+
+// TilemapExport is a region map converted into a tile-based map format
+// for another editor or engine.
+type TilemapExport struct {
+	// Map is the encoded map file.
+	Map []byte
+	// Tileset is the tileset image that the map refers to. It must be
+	// saved as a PNG at the path that was given to the exporter.
+	Tileset image.Image
+}
+
+type tiledMap struct {
+	Type         string           `json:"type"`
+	Version      string           `json:"version"`
+	Orientation  string           `json:"orientation"`
+	RenderOrder  string           `json:"renderorder"`
+	Width        int              `json:"width"`
+	Height       int              `json:"height"`
+	TileWidth    int              `json:"tilewidth"`
+	TileHeight   int              `json:"tileheight"`
+	Infinite     bool             `json:"infinite"`
+	NextLayerID  int              `json:"nextlayerid"`
+	NextObjectID int              `json:"nextobjectid"`
+	Tilesets     []tiledTileset   `json:"tilesets"`
+	Layers       []tiledTileLayer `json:"layers"`
+}
+
+type tiledTileset struct {
+	FirstGID    int    `json:"firstgid"`
+	Name        string `json:"name"`
+	Image       string `json:"image"`
+	ImageWidth  int    `json:"imagewidth"`
+	ImageHeight int    `json:"imageheight"`
+	TileWidth   int    `json:"tilewidth"`
+	TileHeight  int    `json:"tileheight"`
+	TileCount   int    `json:"tilecount"`
+	Columns     int    `json:"columns"`
+	Margin      int    `json:"margin"`
+	Spacing     int    `json:"spacing"`
+}
+
+type tiledTileLayer struct {
+	Type    string  `json:"type"`
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	Opacity float64 `json:"opacity"`
+	Visible bool    `json:"visible"`
+	Data    []int   `json:"data"`
+}
+
+// ExportTiled converts a region map into a Tiled map with separate terrain,
+// route, and city layers, along with a tileset image generated from the
+// palette. tilesetImage is the path to the tileset image, relative to the
+// map file.
+func ExportTiled(regionMap RegionMap, palette Palette, tilesetImage string) (TilemapExport, error) {
+	tilesWidth, tilesHeight, err := getExportTileSize(regionMap)
+	if err != nil {
+		return TilemapExport{}, err
+	}
+	layers := getTileLayers(regionMap)
+	// Global tile IDs in Tiled start at 1, and 0 is an empty cell.
+	toGIDs := func(indexes []int) []int {
+		gids := make([]int, len(indexes))
+		for i, index := range indexes {
+			gids[i] = index + 1
+		}
+		return gids
+	}
+
+	layer := func(id int, name string, data []int) tiledTileLayer {
+		return tiledTileLayer{
+			Type:    "tilelayer",
+			ID:      id,
+			Name:    name,
+			Width:   tilesWidth,
+			Height:  tilesHeight,
+			Opacity: 1,
+			Visible: true,
+			Data:    data,
+		}
+	}
+	tileset := renderTiledTileset(palette)
+	data, err := json.MarshalIndent(tiledMap{
+		Type:         "map",
+		Version:      "1.10",
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        tilesWidth,
+		Height:       tilesHeight,
+		TileWidth:    8,
+		TileHeight:   8,
+		NextLayerID:  4,
+		NextObjectID: 1,
+		Tilesets: []tiledTileset{{
+			FirstGID:    1,
+			Name:        "porygion",
+			Image:       tilesetImage,
+			ImageWidth:  tileset.Bounds().Dx(),
+			ImageHeight: tileset.Bounds().Dy(),
+			TileWidth:   8,
+			TileHeight:  8,
+			TileCount:   tiledNumTiles,
+			Columns:     tiledNumTiles,
+		}},
+		Layers: []tiledTileLayer{
+			layer(1, "terrain", toGIDs(layers.terrain)),
+			layer(2, "routes", toGIDs(layers.routes)),
+			layer(3, "cities", toGIDs(layers.cities)),
+		},
+	}, "", "  ")
+	if err != nil {
+		return TilemapExport{}, fmt.Errorf("Failed to encode Tiled map: %s", err)
+	}
+	return TilemapExport{Map: data, Tileset: tileset}, nil
+}
+
+// renderTiledTileset draws the tiles used by tilemap exports in a single
+// row. The city markers are drawn over transparent pixels, so that the
+// terrain and routes beneath them show through.
+func renderTiledTileset(palette Palette) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, tiledNumTiles*8, 8))
+	fillTile := func(index int, colorAt func(y int) color.RGBA) {
+		for x := 0; x < 8; x++ {
+			for y := 0; y < 8; y++ {
+				img.SetRGBA(index*8+x, y, colorAt(y))
+			}
+		}
+	}
+	fillTile(tiledWaterTile, func(y int) color.RGBA { return palette.Water[y%2] })
+	for i, c := range palette.Land {
+		c := c
+		fillTile(tiledLandTile+i, func(int) color.RGBA { return c })
+	}
+	fillTile(tiledRouteTile, func(int) color.RGBA { return palette.RouteLand[0] })
+	fillTile(tiledSeaRouteTile, func(y int) color.RGBA { return palette.RouteWater[y%2] })
+	for kind := CityTown; kind <= CityLeague; kind++ {
+		drawCityMarker(img, Tile{tiledCityTile + int(kind), 0}, kind, palette)
+	}
+	return img
+}
+
+// tileLayers holds the tileset index of every tile in each layer of a
+// tilemap export, from left to right, then top to bottom. Empty cells in
+// the route and city layers are -1.
+type tileLayers struct {
+	terrain []int
+	routes  []int
+	cities  []int
+}
+
+// getTileLayers assigns tiles from the generated tileset to each tile of
+// the region map.
+func getTileLayers(regionMap RegionMap) tileLayers {
+	elevations := regionMap.Elevations
+	tilesWidth := len(elevations) / 8
+	tilesHeight := len(elevations[0]) / 8
+	layers := tileLayers{
+		terrain: make([]int, tilesWidth*tilesHeight),
+		routes:  make([]int, tilesWidth*tilesHeight),
+		cities:  make([]int, tilesWidth*tilesHeight),
+	}
+	for i := range layers.routes {
+		layers.routes[i] = -1
+		layers.cities[i] = -1
+	}
+	for i := 0; i < tilesWidth; i++ {
+		for j := 0; j < tilesHeight; j++ {
+			band := getDominantTerrainBand(elevations, Tile{i, j})
+			if band.water {
+				layers.terrain[j*tilesWidth+i] = tiledWaterTile
+			} else {
+				layers.terrain[j*tilesWidth+i] = tiledLandTile + band.index
+			}
+		}
+	}
+	for _, route := range regionMap.Routes {
+		if isInTileBounds(route, tilesWidth, tilesHeight) {
+			index := tiledRouteTile
+			if getDominantTerrainBand(elevations, route).water {
+				index = tiledSeaRouteTile
+			}
+			layers.routes[route.Y*tilesWidth+route.X] = index
+		}
+	}
+	for _, city := range regionMap.Cities {
+		if isInTileBounds(city, tilesWidth, tilesHeight) {
+			layers.cities[city.Y*tilesWidth+city.X] = tiledCityTile + int(regionMap.CityKinds[city])
+		}
+	}
+	return layers
+}
+
+// getExportTileSize returns the size of the region map in whole tiles. It
+// returns an error if the map has no whole tiles.
+func getExportTileSize(regionMap RegionMap) (int, int, error) {
+	elevations := regionMap.Elevations
+	if len(elevations) < 8 || len(elevations[0]) < 8 {
+		return 0, 0, fmt.Errorf("Region map is too small to export as tiles")
+	}
+	return len(elevations) / 8, len(elevations[0]) / 8, nil
+}
+
+func isInTileBounds(t Tile, tilesWidth, tilesHeight int) bool {
+	return t.X >= 0 && t.Y >= 0 && t.X < tilesWidth && t.Y < tilesHeight
+}
@@ -0,0 +1,44 @@
+package porygion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportTiled(t *testing.T) {
+	regionMap := testRegionMap(t)
+	export, err := ExportTiled(regionMap, DefaultPalette(), "tileset.png")
+	if err != nil {
+		t.Fatalf("ExportTiled: %s", err)
+	}
+	var m tiledMap
+	if err := json.Unmarshal(export.Map, &m); err != nil {
+		t.Fatalf("Failed to decode the Tiled map: %s", err)
+	}
+	if m.Width != 12 || m.Height != 8 || len(m.Layers) != 3 || len(m.Tilesets) != 1 {
+		t.Fatalf("Tiled map is %dx%d with %d layers and %d tilesets, want 12x8 with 3 layers and 1 tileset", m.Width, m.Height, len(m.Layers), len(m.Tilesets))
+	}
+	if m.Tilesets[0].Image != "tileset.png" || export.Tileset.Bounds().Dx() != m.Tilesets[0].ImageWidth {
+		t.Errorf("tileset = %+v, but the image is %v", m.Tilesets[0], export.Tileset.Bounds())
+	}
+	terrain, routes, cities := m.Layers[0].Data, m.Layers[1].Data, m.Layers[2].Data
+	for i, gid := range terrain {
+		if gid < 1 || gid > tiledRouteTile {
+			t.Errorf("terrain cell %d has GID %d", i, gid)
+		}
+	}
+	for _, route := range regionMap.Routes {
+		if gid := routes[route.Y*m.Width+route.X]; gid != tiledRouteTile+1 && gid != tiledSeaRouteTile+1 {
+			t.Errorf("route %v has GID %d", route, gid)
+		}
+	}
+	for _, city := range regionMap.Cities {
+		if gid, want := cities[city.Y*m.Width+city.X], tiledCityTile+int(regionMap.CityKinds[city])+1; gid != want {
+			t.Errorf("city %v has GID %d, want %d", city, gid, want)
+		}
+	}
+
+	if _, err := ExportTiled(RegionMap{}, DefaultPalette(), "tileset.png"); err == nil {
+		t.Errorf("ExportTiled of an empty region map succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// Tileset is a sheet of 8x8 tiles used to assemble a region map from
+// sprites, rather than flat colors. Tiles are referenced by their index in
+// the sheet, counting from left to right, then top to bottom.
+type Tileset struct {
+	Image image.Image
+	Water int
+	// Land holds the land tiles, from the lowest elevation to the highest.
+	Land     [5]int
+	Route    int
+	SeaRoute int
+	City     int
+	// RouteAutotiles, when set, holds a route tile for every combination of
+	// neighboring routes. It is indexed by a bitmask of the neighboring
+	// directions, where north is 1, east is 2, south is 4, and west is 8.
+	RouteAutotiles *[16]int
+}
+
+// NewTileset returns a tileset that uses the standard tile layout: water,
+// the five land tiles, route, sea route, and city.
+func NewTileset(img image.Image) Tileset {
+	return Tileset{
+		Image:    img,
+		Water:    0,
+		Land:     [5]int{1, 2, 3, 4, 5},
+		Route:    6,
+		SeaRoute: 7,
+		City:     8,
+	}
+}
+
+// RenderTilesetRegionMap renders a region map by drawing a sprite from the
+// tileset for each of its tiles.
+func RenderTilesetRegionMap(regionMap RegionMap, tileset Tileset) (image.Image, error) {
+	if err := tileset.validate(); err != nil {
+		return nil, err
+	}
+	elevations := regionMap.Elevations
+	tilesWidth := len(elevations) / 8
+	tilesHeight := len(elevations[0]) / 8
+	img := newRegionMapImage(regionMap)
+	network := map[Tile]bool{}
+	for _, route := range regionMap.Routes {
+		network[route] = true
+	}
+	cities := map[Tile]bool{}
+	for _, city := range regionMap.Cities {
+		network[city] = true
+		cities[city] = true
+	}
+	for i := 0; i < tilesWidth; i++ {
+		for j := 0; j < tilesHeight; j++ {
+			t := Tile{i, j}
+			band := getDominantTerrainBand(elevations, t)
+			var index int
+			switch {
+			case cities[t]:
+				index = tileset.City
+			case network[t] && tileset.RouteAutotiles != nil:
+				index = tileset.RouteAutotiles[getRouteNeighborMask(t, network)]
+			case network[t] && band.water:
+				index = tileset.SeaRoute
+			case network[t]:
+				index = tileset.Route
+			case band.water:
+				index = tileset.Water
+			default:
+				index = tileset.Land[band.index]
+			}
+			dst := image.Rect(i*8, j*8, i*8+8, j*8+8)
+			draw.Draw(img, dst, tileset.Image, tileset.tileOrigin(index), draw.Src)
+		}
+	}
+	return img, nil
+}
+
+func (t Tileset) numTiles() int {
+	bounds := t.Image.Bounds()
+	return (bounds.Dx() / 8) * (bounds.Dy() / 8)
+}
+
+func (t Tileset) tileOrigin(index int) image.Point {
+	bounds := t.Image.Bounds()
+	columns := bounds.Dx() / 8
+	return image.Point{bounds.Min.X + (index%columns)*8, bounds.Min.Y + (index/columns)*8}
+}
+
+func (t Tileset) validate() error {
+	if t.Image == nil {
+		return fmt.Errorf("Tileset has no image")
+	}
+	numTiles := t.numTiles()
+	indexes := []int{t.Water, t.Route, t.SeaRoute, t.City}
+	indexes = append(indexes, t.Land[:]...)
+	if t.RouteAutotiles != nil {
+		indexes = append(indexes, t.RouteAutotiles[:]...)
+	}
+	for _, index := range indexes {
+		if index < 0 || index >= numTiles {
+			return fmt.Errorf("Tile index %d is out of range for a tileset with %d tiles", index, numTiles)
+		}
+	}
+	return nil
+}
+
+// getDominantTerrainBand returns the terrain band that covers the most
+// pixels in a tile. Both water shades count as the same band.
+func getDominantTerrainBand(elevations [][]float64, t Tile) terrainBand {
+	counts := map[terrainBand]int{}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			band := getTerrainBand(elevations[t.X*8+x][t.Y*8+y], 0)
+			counts[band]++
+		}
+	}
+	best := terrainBand{water: true}
+	bestCount := -1
+	// Check the bands in a fixed order, so that ties are deterministic.
+	for _, band := range []terrainBand{{water: true}, {index: 0}, {index: 1}, {index: 2}, {index: 3}, {index: 4}} {
+		if counts[band] > bestCount {
+			best = band
+			bestCount = counts[band]
+		}
+	}
+	return best
+}
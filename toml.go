@@ -0,0 +1,154 @@
+package porygion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// tomlTable is a table of a TOML document. Its values are strings, int64s,
+// float64s, or bools.
+type tomlTable map[string]interface{}
+
+// parseTOMLTables parses the subset of TOML used by profile files: tables
+// of key/value pairs, whose values are strings, integers, floats, or
+// booleans. Arrays, inline tables, dotted keys, and multi-line strings
+// aren't supported. The table names are returned in the order they appear.
+func parseTOMLTables(r io.Reader) (map[string]tomlTable, []string, error) {
+	tables := map[string]tomlTable{}
+	names := []string{}
+	var table tomlTable
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 || !isTOMLBlank(line[end+1:]) {
+				return nil, nil, fmt.Errorf("Line %d: invalid table header", lineNum)
+			}
+			name, err := parseTOMLKey(strings.TrimSpace(line[1:end]))
+			if err != nil {
+				return nil, nil, fmt.Errorf("Line %d: %s", lineNum, err)
+			}
+			if _, ok := tables[name]; ok {
+				return nil, nil, fmt.Errorf("Line %d: table %q is defined twice", lineNum, name)
+			}
+			table = tomlTable{}
+			tables[name] = table
+			names = append(names, name)
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, nil, fmt.Errorf("Line %d: expected a key and value", lineNum)
+		}
+		if table == nil {
+			return nil, nil, fmt.Errorf("Line %d: keys must be inside a table", lineNum)
+		}
+		key, err := parseTOMLKey(strings.TrimSpace(line[:eq]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Line %d: %s", lineNum, err)
+		}
+		if _, ok := table[key]; ok {
+			return nil, nil, fmt.Errorf("Line %d: key %q is defined twice", lineNum, key)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Line %d: %s", lineNum, err)
+		}
+		table[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return tables, names, nil
+}
+
+// parseTOMLKey parses a bare or quoted key.
+func parseTOMLKey(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		key, rest, err := parseTOMLString(s)
+		if err != nil {
+			return "", err
+		}
+		if rest != "" {
+			return "", fmt.Errorf("invalid key %s", s)
+		}
+		return key, nil
+	}
+	for _, c := range s {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return "", fmt.Errorf("invalid key %s", s)
+		}
+	}
+	return s, nil
+}
+
+// parseTOMLValue parses a value, which may be followed by a comment.
+func parseTOMLValue(s string) (interface{}, error) {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		value, rest, err := parseTOMLString(s)
+		if err != nil {
+			return nil, err
+		}
+		if !isTOMLBlank(rest) {
+			return nil, fmt.Errorf("unexpected %s after string", rest)
+		}
+		return value, nil
+	}
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch s {
+	case "":
+		return nil, fmt.Errorf("missing value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	// Base 0 accepts the same prefixes and underscores as TOML.
+	if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(strings.Replace(s, "_", "", -1), 64); err == nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid value %s", s)
+}
+
+// parseTOMLString parses a basic "string" or literal 'string' at the start
+// of s, and returns the rest of s after it.
+func parseTOMLString(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			value := s[1:i]
+			if quote == '"' {
+				var err error
+				if value, err = strconv.Unquote(s[:i+1]); err != nil {
+					return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+				}
+			}
+			return value, strings.TrimSpace(s[i+1:]), nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", s)
+}
+
+// isTOMLBlank reports whether s is empty or only a comment.
+func isTOMLBlank(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
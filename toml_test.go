@@ -0,0 +1,83 @@
+package porygion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOMLTables(t *testing.T) {
+	input := `# A comment before any table.
+
+[first]
+name = "Kanto"  # a trailing comment
+literal = 'C:\maps\#1'
+escaped = "tab\there \"quoted\""
+count = 1_000
+hex = 0x1F
+negative = -42
+ratio = 0.5
+exponent = 1e3
+on = true
+off = false
+
+[ "second table" ]
+"quoted key" = "value # not a comment"
+bare-key_2 = 3
+`
+	tables, names, err := parseTOMLTables(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTOMLTables: %s", err)
+	}
+	wantNames := []string{"first", "second table"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("names = %q, want %q", names, wantNames)
+	}
+	wantTables := map[string]tomlTable{
+		"first": {
+			"name":     "Kanto",
+			"literal":  `C:\maps\#1`,
+			"escaped":  "tab\there \"quoted\"",
+			"count":    int64(1000),
+			"hex":      int64(31),
+			"negative": int64(-42),
+			"ratio":    0.5,
+			"exponent": 1000.0,
+			"on":       true,
+			"off":      false,
+		},
+		"second table": {
+			"quoted key": "value # not a comment",
+			"bare-key_2": int64(3),
+		},
+	}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Errorf("tables = %v, want %v", tables, wantTables)
+	}
+}
+
+func TestParseTOMLTablesErrors(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"key outside a table", "a = 1", "Line 1: keys must be inside a table"},
+		{"unclosed header", "[table", "Line 1: invalid table header"},
+		{"text after header", "[table] x", "Line 1: invalid table header"},
+		{"duplicate table", "[a]\n[a]", "Line 2: table \"a\" is defined twice"},
+		{"duplicate key", "[a]\nx = 1\nx = 2", "Line 3: key \"x\" is defined twice"},
+		{"missing value", "[a]\nx =", "Line 2: missing value"},
+		{"missing equals", "[a]\nx", "Line 2: expected a key and value"},
+		{"invalid key", "[a]\nx.y = 1", "Line 2: invalid key x.y"},
+		{"invalid value", "[a]\nx = [1, 2]", "Line 2: invalid value [1, 2]"},
+		{"unterminated string", "[a]\nx = \"abc", `Line 2: unterminated string "abc`},
+		{"text after string", "[a]\nx = \"abc\" def", "Line 2: unexpected def after string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := parseTOMLTables(strings.NewReader(test.input))
+			if err == nil || err.Error() != test.want {
+				t.Errorf("parseTOMLTables error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
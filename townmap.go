@@ -0,0 +1,124 @@
+package porygion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// TownMapTarget is a game whose Town Map graphics a region map can be
+// exported for.
+type TownMapTarget int
+
+// Games that Town Map graphics can be exported for.
+const (
+	TownMapEmerald TownMapTarget = iota
+	TownMapFRLG
+)
+
+// townMapLayout describes the Town Map background of a game.
+type townMapLayout struct {
+	// width and height are the size of the tilemap, in tiles.
+	width, height int
+	// mapX, mapY, mapWidth, and mapHeight are the area of the tilemap, in
+	// tiles, that the region map is drawn into.
+	mapX, mapY, mapWidth, mapHeight int
+	// paletteNum is the palette that the map's tiles use.
+	paletteNum int
+}
+
+// townMapLayouts are the Town Map layouts of each game. pokeemerald's map
+// fills the whole 32x20-tile background with palette 0. pokefirered's
+// tilemaps fill a 32x32-tile screen, and the map is a 22x15-tile grid
+// inside the frame, drawn with palette 1.
+var townMapLayouts = map[TownMapTarget]townMapLayout{
+	TownMapEmerald: {
+		width: 32, height: 20,
+		mapX: 0, mapY: 0, mapWidth: 32, mapHeight: 20,
+		paletteNum: 0,
+	},
+	TownMapFRLG: {
+		width: 32, height: 32,
+		mapX: 1, mapY: 2, mapWidth: 22, mapHeight: 15,
+		paletteNum: 1,
+	},
+}
+
+// TownMap is a region map converted into the graphics formats used by a
+// game's Town Map background.
+type TownMap struct {
+	// Tilemap is the tilemap (.bin), with one little-endian 16-bit entry
+	// per tile. Each entry holds the tile's index in Tiles, and the
+	// number of the palette that the target game draws the map with.
+	Tilemap []byte
+	// Tiles holds the unique tile graphics, in 4bpp format (.4bpp).
+	Tiles []byte
+	// Palette holds the colors for every palette up to and including the
+	// map's palette, 16 colors each (.gbapal). The map's colors are in
+	// the last 16.
+	Palette color.Palette
+}
+
+// ExportEmeraldRegionMap renders a region map and converts it into the
+// Town Map graphics formats used by pokeemerald. It is the same as
+// ExportTownMap with TownMapEmerald.
+func ExportEmeraldRegionMap(regionMap RegionMap, opts RenderOptions) (TownMap, error) {
+	return ExportTownMap(regionMap, opts, TownMapEmerald)
+}
+
+// ExportTownMap renders a region map and converts it into the Town Map
+// graphics formats used by the target game. Region maps smaller than the
+// game's map area are padded with the first palette color, and larger
+// ones are cropped. Tiles outside of the map area are left blank. The
+// render options must produce at most 16 colors, and must not scale the
+// map.
+func ExportTownMap(regionMap RegionMap, opts RenderOptions, target TownMapTarget) (TownMap, error) {
+	layout, ok := townMapLayouts[target]
+	if !ok {
+		return TownMap{}, fmt.Errorf("Unknown Town Map target %d", target)
+	}
+	if opts.Scale > 1 || opts.Legend {
+		return TownMap{}, fmt.Errorf("Town Map export doesn't support scaled maps or legends")
+	}
+	src, err := RenderPalettedRegionMap(regionMap, opts)
+	if err != nil {
+		return TownMap{}, err
+	}
+	img := image.NewPaletted(image.Rect(0, 0, layout.width*8, layout.height*8), src.Palette)
+	// The image's pixels start as index 0, which pads any area that the
+	// region map doesn't cover.
+	for x := 0; x < layout.mapWidth*8 && x < src.Rect.Dx(); x++ {
+		for y := 0; y < layout.mapHeight*8 && y < src.Rect.Dy(); y++ {
+			img.SetColorIndex(layout.mapX*8+x, layout.mapY*8+y, src.ColorIndexAt(x, y))
+		}
+	}
+
+	palette := make(color.Palette, (layout.paletteNum+1)*maxPalettedColors)
+	for i := range palette {
+		palette[i] = color.RGBA{0, 0, 0, 255}
+		if j := i - layout.paletteNum*maxPalettedColors; j >= 0 && j < len(src.Palette) {
+			palette[i] = src.Palette[j]
+		}
+	}
+	tilemap := make([]byte, 0, layout.width*layout.height*2)
+	tiles := []byte{}
+	indexes := map[string]int{}
+	for j := 0; j < layout.height; j++ {
+		for i := 0; i < layout.width; i++ {
+			tile := encodeFlippedTile4bpp(img, i*8, j*8, 0)
+			index, ok := indexes[string(tile)]
+			if !ok {
+				index = len(indexes)
+				indexes[string(tile)] = index
+				tiles = append(tiles, tile...)
+			}
+			entry := index | layout.paletteNum<<gbaPaletteShift
+			tilemap = append(tilemap, byte(entry), byte(entry>>8))
+		}
+	}
+	return TownMap{
+		Tilemap: tilemap,
+		Tiles:   tiles,
+		Palette: palette,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package porygion
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// checkTownMap checks that a Town Map export has a tilemap of the given
+// size, whose entries all refer to its tiles with the given palette.
+func checkTownMap(t *testing.T, townMap TownMap, width, height, paletteNum int) {
+	t.Helper()
+	if len(townMap.Tilemap) != width*height*2 {
+		t.Fatalf("Tilemap has %d bytes, want %d", len(townMap.Tilemap), width*height*2)
+	}
+	if len(townMap.Tiles) == 0 || len(townMap.Tiles)%32 != 0 {
+		t.Fatalf("Tiles has %d bytes, want a whole number of 4bpp tiles", len(townMap.Tiles))
+	}
+	if len(townMap.Palette) != (paletteNum+1)*16 {
+		t.Errorf("Palette has %d colors, want %d", len(townMap.Palette), (paletteNum+1)*16)
+	}
+	numTiles := len(townMap.Tiles) / 32
+	for i := 0; i < len(townMap.Tilemap); i += 2 {
+		entry := int(binary.LittleEndian.Uint16(townMap.Tilemap[i:]))
+		if entry&gbaTileIndexMask >= numTiles || entry>>gbaPaletteShift != paletteNum {
+			t.Fatalf("tilemap entry %d is %#04x, with %d tiles", i/2, entry, numTiles)
+		}
+	}
+}
+
+func TestExportEmeraldRegionMap(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	townMap, err := ExportEmeraldRegionMap(regionMap, RenderOptions{})
+	if err != nil {
+		t.Fatalf("ExportEmeraldRegionMap: %s", err)
+	}
+	checkTownMap(t, townMap, 32, 20, 0)
+
+	if _, err := ExportEmeraldRegionMap(regionMap, RenderOptions{Scale: 2}); err == nil {
+		t.Errorf("ExportEmeraldRegionMap of a scaled map succeeded")
+	}
+	if _, err := ExportTownMap(regionMap, RenderOptions{}, TownMapTarget(-1)); err == nil {
+		t.Errorf("ExportTownMap with an unknown target succeeded")
+	}
+}
+
+func TestExportFRLGTownMap(t *testing.T) {
+	regionMap, err := GenerateRegionMap(3, 240, 160, 12)
+	if err != nil {
+		t.Fatalf("GenerateRegionMap: %s", err)
+	}
+	townMap, err := ExportTownMap(regionMap, RenderOptions{}, TownMapFRLG)
+	if err != nil {
+		t.Fatalf("ExportTownMap: %s", err)
+	}
+	checkTownMap(t, townMap, 32, 32, 1)
+
+	// Tiles outside of the 22x15-tile map area, which starts at tile
+	// (1, 2), are blank.
+	entryAt := func(x, y int) uint16 {
+		return binary.LittleEndian.Uint16(townMap.Tilemap[(y*32+x)*2:])
+	}
+	blank := entryAt(0, 0)
+	for _, tile := range []Tile{{23, 2}, {1, 17}, {31, 31}} {
+		if entry := entryAt(tile.X, tile.Y); entry != blank {
+			t.Errorf("entry at %v is %#04x, want the blank tile %#04x", tile, entry, blank)
+		}
+	}
+	if entryAt(1, 2) == blank && entryAt(22, 16) == blank {
+		t.Errorf("map area is blank")
+	}
+}
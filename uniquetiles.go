@@ -0,0 +1,66 @@
+package porygion
+
+import (
+	"image"
+	"image/draw"
+)
+
+// UniqueTiles is an image split into its distinct 8x8 tiles.
+type UniqueTiles struct {
+	// Tiles are the distinct tiles, in the order they first appear in the
+	// image, from left to right, then top to bottom.
+	Tiles []*image.RGBA
+	// Indexes holds the index in Tiles of each of the image's tiles. Like
+	// a region map's elevations, it is indexed by x, then y.
+	Indexes [][]int
+}
+
+// ExtractUniqueTiles splits an image into 8x8 tiles, and finds the
+// distinct ones. Tiles are only matched when they are exactly the same,
+// without flipping. Partial tiles at the right and bottom edges of the
+// image are ignored.
+func ExtractUniqueTiles(img image.Image) UniqueTiles {
+	bounds := img.Bounds()
+	width := bounds.Dx() / 8
+	height := bounds.Dy() / 8
+	result := UniqueTiles{
+		Tiles:   []*image.RGBA{},
+		Indexes: make([][]int, width),
+	}
+	indexes := map[string]int{}
+	for i := range result.Indexes {
+		result.Indexes[i] = make([]int, height)
+	}
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			tile := image.NewRGBA(image.Rect(0, 0, 8, 8))
+			origin := image.Point{bounds.Min.X + i*8, bounds.Min.Y + j*8}
+			draw.Draw(tile, tile.Bounds(), img, origin, draw.Src)
+			index, ok := indexes[string(tile.Pix)]
+			if !ok {
+				index = len(result.Tiles)
+				indexes[string(tile.Pix)] = index
+				result.Tiles = append(result.Tiles, tile)
+			}
+			result.Indexes[i][j] = index
+		}
+	}
+	return result
+}
+
+// Sheet arranges the unique tiles into a single image, with the given
+// number of tiles in each row. Tiles are placed in order, from left to
+// right, then top to bottom, so a tile's position in the sheet matches
+// the indexes used by a Tileset.
+func (u UniqueTiles) Sheet(columns int) *image.RGBA {
+	if columns < 1 {
+		columns = 1
+	}
+	rows := (len(u.Tiles) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*8, rows*8))
+	for i, tile := range u.Tiles {
+		dst := image.Rect((i%columns)*8, (i/columns)*8, (i%columns)*8+8, (i/columns)*8+8)
+		draw.Draw(sheet, dst, tile, image.Point{}, draw.Src)
+	}
+	return sheet
+}
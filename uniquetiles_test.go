@@ -0,0 +1,35 @@
+package porygion
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+func TestExtractUniqueTiles(t *testing.T) {
+	// Three red, blue, and red tiles, with partial tiles along the right
+	// and bottom edges.
+	img := image.NewRGBA(image.Rect(0, 0, 27, 10))
+	red := color.RGBA{255, 0, 0, 255}
+	draw.Draw(img, image.Rect(0, 0, 8, 8), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(8, 0, 16, 8), image.NewUniform(color.RGBA{0, 0, 255, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(16, 0, 24, 8), image.NewUniform(red), image.Point{}, draw.Src)
+	unique := ExtractUniqueTiles(img)
+	if len(unique.Tiles) != 2 {
+		t.Fatalf("ExtractUniqueTiles found %d tiles, want 2", len(unique.Tiles))
+	}
+	if want := [][]int{{0}, {1}, {0}}; !reflect.DeepEqual(unique.Indexes, want) {
+		t.Errorf("Indexes = %v, want %v", unique.Indexes, want)
+	}
+	if c := unique.Tiles[0].RGBAAt(3, 3); c != red {
+		t.Errorf("first tile is %v, want %v", c, red)
+	}
+
+	for _, test := range []struct{ columns, width, height int }{{1, 8, 16}, {0, 8, 16}, {4, 32, 8}} {
+		if sheet := unique.Sheet(test.columns).Bounds(); sheet.Dx() != test.width || sheet.Dy() != test.height {
+			t.Errorf("Sheet(%d) is %dx%d, want %dx%d", test.columns, sheet.Dx(), sheet.Dy(), test.width, test.height)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package porygion
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type unityTilemap struct {
+	Width          int          `json:"width"`
+	Height         int          `json:"height"`
+	TileWidth      int          `json:"tileWidth"`
+	TileHeight     int          `json:"tileHeight"`
+	Tileset        string       `json:"tileset"`
+	TilesetColumns int          `json:"tilesetColumns"`
+	TileNames      []string     `json:"tileNames"`
+	Layers         []unityLayer `json:"layers"`
+}
+
+type unityLayer struct {
+	Name  string `json:"name"`
+	Tiles []int  `json:"tiles"`
+}
+
+// ExportUnityTilemap converts a region map into a JSON grid of tile IDs, in
+// a simple format that is easy to load into a Unity Tilemap from a script,
+// along with a tileset image generated from the palette. Each layer lists
+// its tile IDs from left to right, then top to bottom, and empty cells are
+// -1. Since Unity's y axis points up, loaders should flip the rows.
+// tilesetImage is the path of the tileset image, relative to the JSON file.
+func ExportUnityTilemap(regionMap RegionMap, palette Palette, tilesetImage string) (TilemapExport, error) {
+	tilesWidth, tilesHeight, err := getExportTileSize(regionMap)
+	if err != nil {
+		return TilemapExport{}, err
+	}
+	layers := getTileLayers(regionMap)
+	data, err := json.MarshalIndent(unityTilemap{
+		Width:          tilesWidth,
+		Height:         tilesHeight,
+		TileWidth:      8,
+		TileHeight:     8,
+		Tileset:        tilesetImage,
+		TilesetColumns: tiledNumTiles,
+		TileNames:      tiledTileNames[:],
+		Layers: []unityLayer{
+			{"terrain", layers.terrain},
+			{"routes", layers.routes},
+			{"cities", layers.cities},
+		},
+	}, "", "  ")
+	if err != nil {
+		return TilemapExport{}, fmt.Errorf("Failed to encode Unity tilemap: %s", err)
+	}
+	return TilemapExport{Map: data, Tileset: renderTiledTileset(palette)}, nil
+}
@@ -0,0 +1,31 @@
+package porygion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportUnityTilemap(t *testing.T) {
+	regionMap := testRegionMap(t)
+	export, err := ExportUnityTilemap(regionMap, DefaultPalette(), "tiles.png")
+	if err != nil {
+		t.Fatalf("ExportUnityTilemap: %s", err)
+	}
+	var m unityTilemap
+	if err := json.Unmarshal(export.Map, &m); err != nil {
+		t.Fatalf("Failed to decode the Unity tilemap: %s", err)
+	}
+	if m.Width != 12 || m.Height != 8 || m.Tileset != "tiles.png" || len(m.TileNames) != tiledNumTiles || len(m.Layers) != 3 {
+		t.Fatalf("Unity tilemap = %+v", m)
+	}
+	for _, layer := range m.Layers {
+		if len(layer.Tiles) != m.Width*m.Height {
+			t.Errorf("layer %s has %d tiles, want %d", layer.Name, len(layer.Tiles), m.Width*m.Height)
+		}
+	}
+	for _, city := range regionMap.Cities {
+		if id, want := m.Layers[2].Tiles[city.Y*m.Width+city.X], tiledCityTile+int(regionMap.CityKinds[city]); id != want {
+			t.Errorf("city %v has tile %d, want %d", city, id, want)
+		}
+	}
+}